@@ -0,0 +1,161 @@
+package main
+
+import (
+	authRepo "DBForum/internal/app/auth/repository"
+	authUseCase "DBForum/internal/app/auth/usecase"
+	"DBForum/internal/app/config"
+	"DBForum/internal/app/database"
+	idempotencyRepo "DBForum/internal/app/idempotency/repository"
+	idempotencyUseCase "DBForum/internal/app/idempotency/usecase"
+	userRepo "DBForum/internal/app/user/repository"
+	"DBForum/internal/app/validation"
+	"bufio"
+	"fmt"
+	"github.com/pkg/errors"
+	"os"
+	"strings"
+	"time"
+)
+
+const usage = `usage: main [-config file] [command]
+
+Without a command the API server is started.
+
+commands:
+  migrate up       apply pending schema migrations
+  migrate status   list migrations and whether they are applied
+  repair forum-users
+                   re-copy user profiles into forum_users rows that drifted
+  passwd NICKNAME  set the user's password, read from the first line of stdin
+  admin grant|revoke NICKNAME
+                   give or take away admin rights; the user needs a password
+  prune idempotency-keys
+                   delete stored idempotent responses older than the retention`
+
+func runCommand(postgres *database.Postgres, conf config.Config, args []string) error {
+	switch args[0] {
+	case "migrate":
+		return runMigrate(postgres, args[1:])
+	case "repair":
+		return runRepair(postgres, args[1:])
+	case "passwd":
+		return runPasswd(postgres, args[1:])
+	case "admin":
+		return runAdmin(postgres, args[1:])
+	case "prune":
+		return runPrune(postgres, conf.Idempotency, args[1:])
+	default:
+		return errors.Errorf("unknown command %q\n%s", args[0], usage)
+	}
+}
+
+func runMigrate(postgres *database.Postgres, args []string) error {
+	migrator, err := database.NewMigrator(postgres.GetPostgres())
+	if err != nil {
+		return err
+	}
+	if len(args) != 1 {
+		return errors.New(usage)
+	}
+
+	switch args[0] {
+	case "up":
+		applied, err := migrator.Up()
+		for _, m := range applied {
+			fmt.Printf("applied %04d_%s\n", m.Version, m.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("schema is up to date")
+		}
+		return nil
+	case "status":
+		statuses, err := migrator.Status()
+		if err != nil {
+			return err
+		}
+		for _, s := range statuses {
+			fmt.Println(s)
+		}
+		return nil
+	default:
+		return errors.Errorf("unknown migrate command %q\n%s", args[0], usage)
+	}
+}
+
+func runRepair(postgres *database.Postgres, args []string) error {
+	if len(args) != 1 || args[0] != "forum-users" {
+		return errors.New(usage)
+	}
+	repaired, err := userRepo.NewRepo(postgres.GetPostgres()).RepairForumUsers()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("repaired %d forum_users rows\n", repaired)
+	return nil
+}
+
+func runPasswd(postgres *database.Postgres, args []string) error {
+	if len(args) != 1 {
+		return errors.New(usage)
+	}
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return errors.Wrap(err, "reading password")
+	}
+	password := strings.TrimRight(line, "\r\n")
+	if err := validation.Param("password", password, validation.Password); err != nil {
+		return err
+	}
+
+	useCase, err := newAuthUseCase(postgres)
+	if err != nil {
+		return err
+	}
+	if err := useCase.SetPassword(args[0], password); err != nil {
+		return err
+	}
+	fmt.Printf("password set for %s\n", args[0])
+	return nil
+}
+
+func runAdmin(postgres *database.Postgres, args []string) error {
+	if len(args) != 2 || (args[0] != "grant" && args[0] != "revoke") {
+		return errors.New(usage)
+	}
+	useCase, err := newAuthUseCase(postgres)
+	if err != nil {
+		return err
+	}
+	if err := useCase.SetAdmin(args[1], args[0] == "grant"); err != nil {
+		return err
+	}
+	fmt.Printf("admin rights %sd for %s\n", args[0], args[1])
+	return nil
+}
+
+func runPrune(postgres *database.Postgres, conf config.Idempotency, args []string) error {
+	if len(args) != 1 || args[0] != "idempotency-keys" {
+		return errors.New(usage)
+	}
+	repo := idempotencyRepo.NewRepo(postgres.GetPostgres())
+	if err := repo.Prepare(); err != nil {
+		return err
+	}
+	pruned, err := idempotencyUseCase.NewUseCase(repo, time.Duration(conf.Retention)).Prune()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("pruned %d idempotency keys\n", pruned)
+	return nil
+}
+
+func newAuthUseCase(postgres *database.Postgres) (*authUseCase.UseCase, error) {
+	repo := authRepo.NewRepo(postgres.GetPostgres())
+	if err := repo.Prepare(); err != nil {
+		return nil, err
+	}
+	return authUseCase.NewUseCase(repo, 0, false), nil
+}
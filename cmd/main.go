@@ -1,118 +1,104 @@
 package main
 
 import (
+	"DBForum/internal/app/config"
 	"DBForum/internal/app/database"
-	forumHandlers "DBForum/internal/app/forum/handlers"
-	forumRepo "DBForum/internal/app/forum/repository"
-	forumUCase "DBForum/internal/app/forum/usecase"
-	postHandlers "DBForum/internal/app/post/handlers"
-	postRepo "DBForum/internal/app/post/repository"
-	postUCase "DBForum/internal/app/post/usecase"
-	serviceHandlers "DBForum/internal/app/service/handlers"
-	serviceRepo "DBForum/internal/app/service/repository"
-	serviceUCase "DBForum/internal/app/service/usecase"
+	"DBForum/internal/app/server"
+	"flag"
 	"fmt"
-	router2 "github.com/fasthttp/router"
 	"github.com/sirupsen/logrus"
 	"github.com/valyala/fasthttp"
-
-	threadHandlers "DBForum/internal/app/thread/handlers"
-	threadRepo "DBForum/internal/app/thread/repository"
-	threadUCase "DBForum/internal/app/thread/usecase"
-
-	userHandlers "DBForum/internal/app/user/handlers"
-	userRepo "DBForum/internal/app/user/repository"
-	userUCase "DBForum/internal/app/user/usecase"
-
-	"log"
-	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
 func main() {
+	configPath := flag.String("config", "", "path to a JSON config file (default $DBFORUM_CONFIG)")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), usage)
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	logrus.SetFormatter(&logrus.JSONFormatter{})
+
+	conf, err := config.Load(*configPath)
+	if err != nil {
+		logrus.Fatal(err)
+	}
 
-	postgres, err := database.NewPostgres()
+	postgres, err := database.NewPostgres(conf.Database)
 
 	if err != nil {
-		log.Fatal(err)
+		logrus.Fatal(err)
 	}
 
-	forumRepository := forumRepo.NewRepo(postgres.GetPostgres())
-	if err := forumRepository.Prepare(); err != nil {
-		log.Fatalln(err)
+	if args := flag.Args(); len(args) > 0 {
+		err := runCommand(postgres, conf, args)
+		_ = postgres.Close()
+		if err != nil {
+			logrus.Fatal(err)
+		}
+		return
 	}
-	postRepository := postRepo.NewRepo(postgres.GetPostgres())
-	if err := postRepository.Prepare(); err != nil {
-		log.Fatalln(err)
+
+	migrator, err := database.NewMigrator(postgres.GetPostgres())
+	if err != nil {
+		logrus.Fatal(err)
 	}
-	serviceRepository := serviceRepo.NewRepo(postgres.GetPostgres())
-	if err := serviceRepository.Prepare(); err != nil {
-		log.Fatalln(err)
+	if err := migrator.Check(); err != nil {
+		logrus.Fatal(err)
 	}
-	threadRepository := threadRepo.NewRepo(postgres.GetPostgres())
-	if err := threadRepository.Prepare(); err != nil {
-		log.Fatalln(err)
+
+	handler, err := server.New(postgres.GetPostgres(), conf.Auth, conf.Idempotency)
+	if err != nil {
+		logrus.Fatal(err)
 	}
-	userRepository := userRepo.NewRepo(postgres.GetPostgres())
-	if err := userRepository.Prepare(); err != nil {
-		log.Fatalln(err)
+
+	srv := &fasthttp.Server{
+		Handler:         handler,
+		CloseOnShutdown: true,
 	}
 
-	forumUseCase := forumUCase.NewUseCase(*forumRepository, *userRepository, *threadRepository)
-	postUseCase := postUCase.NewUseCase(*postRepository, *userRepository, *threadRepository, *forumRepository)
-	serviceUseCase := serviceUCase.NewUseCase(*serviceRepository)
-	threadUseCase := threadUCase.NewUseCase(*threadRepository, *postRepository)
-	userUseCase := userUCase.NewUseCase(*userRepository)
-
-	forumHandler := forumHandlers.NewHandler(*forumUseCase)
-	postHandler := postHandlers.NewHandler(*postUseCase)
-	serviceHandler := serviceHandlers.NewHandler(*serviceUseCase)
-	threadHandler := threadHandlers.NewHandler(*threadUseCase)
-	userHandler := userHandlers.NewHandler(*userUseCase)
-
-	r := router2.New()
-
-	r.POST("/api/forum/create", forumHandler.Create)
-	r.GET("/api/forum/{slug}/details", forumHandler.Details)
-	r.POST("/api/forum/{slug}/create", forumHandler.CreateThread)
-	r.GET("/api/forum/{slug}/users", forumHandler.GetUsers)
-	r.GET("/api/forum/{slug}/threads", forumHandler.GetThreads)
-
-	r.GET("/api/post/{id}/details", postHandler.GetInfo)
-	r.POST("/api/post/{id}/details", postHandler.ChangeMessage)
-
-	r.POST("/api/service/clear", serviceHandler.ClearDB)
-	r.GET("/api/service/status", serviceHandler.Status)
-
-	r.POST("/api/thread/{slug_or_id}/create", threadHandler.CreatePost)
-	r.GET("/api/thread/{slug_or_id}/details", threadHandler.ThreadInfo)
-	r.POST("/api/thread/{slug_or_id}/details", threadHandler.ChangeThread)
-	r.GET("/api/thread/{slug_or_id}/posts", threadHandler.GetPosts)
-	r.POST("/api/thread/{slug_or_id}/vote", threadHandler.VoteThread)
-
-	r.POST("/api/user/{nickname}/create", userHandler.CreateUser)
-	r.GET("/api/user/{nickname}/profile", userHandler.GetUserInfo)
-	r.POST("/api/user/{nickname}/profile", userHandler.ChangeUser)
-
-	fmt.Printf("Starting server on port %s\n", ":5000")
-	if err := fasthttp.ListenAndServe(":5000", r.Handler); err != nil {
-		log.Fatal(err)
+	serverErr := make(chan error, 1)
+	go func() {
+		logrus.Infof("Starting server on %s", conf.Listen)
+		serverErr <- srv.ListenAndServe(conf.Listen)
+	}()
+
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGTERM, syscall.SIGINT)
+
+	select {
+	case err := <-serverErr:
+		_ = postgres.Close()
+		logrus.Fatal(err)
+	case sig := <-stop:
+		logrus.Infof("Received %s, shutting down", sig)
 	}
-}
 
-func commonMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		w.Header().Add("Content-Type", "application/json")
-		next.ServeHTTP(w, r)
-	})
+	shutdown(srv, time.Duration(conf.ShutdownTimeout))
+	if err := postgres.Close(); err != nil {
+		logrus.Error(err)
+	}
 }
 
-func LoggingRequest(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		logrus.WithFields(logrus.Fields{
-			"url":    r.URL,
-			"method": r.Method,
-			"body":   r.Body,
-		}).Info()
-		next.ServeHTTP(w, r)
-	})
+// shutdown stops accepting connections and waits for in-flight requests,
+// giving up after timeout so the pool is closed even if a request hangs.
+func shutdown(server *fasthttp.Server, timeout time.Duration) {
+	done := make(chan error, 1)
+	go func() {
+		done <- server.Shutdown()
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			logrus.Error(err)
+		}
+	case <-time.After(timeout):
+		logrus.Warnf("Shutdown timed out after %s, dropping remaining connections", timeout)
+	}
 }
@@ -1,5 +1,3 @@
-ALTER USER postgres WITH ENCRYPTED PASSWORD 'admin';
-DROP SCHEMA IF EXISTS dbforum CASCADE;
 CREATE EXTENSION IF NOT EXISTS citext;
 CREATE SCHEMA dbforum;
 
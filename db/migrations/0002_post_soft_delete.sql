@@ -0,0 +1,4 @@
+-- Deleted posts stay in the table as tombstones so that the tree arrays of
+-- their replies keep pointing at a real row.
+ALTER TABLE dbforum.post
+    ADD COLUMN is_deleted BOOLEAN DEFAULT false NOT NULL;
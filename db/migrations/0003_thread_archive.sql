@@ -0,0 +1,7 @@
+-- Archived threads stay readable but reject new posts, votes and edits.
+ALTER TABLE dbforum.thread
+    ADD COLUMN is_archived BOOLEAN DEFAULT false NOT NULL;
+
+-- Lets thread deletion check whether an author has anything left in a forum.
+CREATE INDEX posts_forum_slug_author_idx ON dbforum.post (forum_slug, author_nickname);
+CREATE INDEX thread_forum_slug_author_idx ON dbforum.thread (forum_slug, author_nickname);
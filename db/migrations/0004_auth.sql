@@ -0,0 +1,23 @@
+-- Password credentials and bearer tokens. Both tables reference
+-- dbforum.users, which is unlogged, so they have to be unlogged as well.
+CREATE UNLOGGED TABLE dbforum.credentials
+(
+    nickname      CITEXT PRIMARY KEY NOT NULL,
+    password_hash TEXT               NOT NULL,
+
+    FOREIGN KEY (nickname) REFERENCES dbforum.users (nickname)
+);
+
+-- Only the SHA-256 of a token is stored, so a leaked table can't be used to
+-- sign in.
+CREATE UNLOGGED TABLE dbforum.tokens
+(
+    token_hash TEXT PRIMARY KEY         NOT NULL,
+    nickname   CITEXT                   NOT NULL,
+    created    TIMESTAMP WITH TIME ZONE NOT NULL,
+    expires    TIMESTAMP WITH TIME ZONE NOT NULL,
+
+    FOREIGN KEY (nickname) REFERENCES dbforum.users (nickname)
+);
+
+CREATE INDEX tokens_nickname_idx ON dbforum.tokens (nickname);
@@ -0,0 +1,17 @@
+ALTER TABLE dbforum.credentials
+    ADD COLUMN is_admin BOOLEAN DEFAULT false NOT NULL;
+
+-- Requests refused with 403. Unlike the forum data this table is logged and
+-- has no foreign keys, so entries survive a crash and the users they name.
+CREATE TABLE dbforum.audit_log
+(
+    id         BIGSERIAL PRIMARY KEY    NOT NULL,
+    actor      CITEXT                   NOT NULL,
+    method     TEXT                     NOT NULL,
+    route      TEXT                     NOT NULL,
+    path       TEXT                     NOT NULL,
+    request_id TEXT                     NOT NULL,
+    created    TIMESTAMP WITH TIME ZONE NOT NULL
+);
+
+CREATE INDEX audit_log_created_idx ON dbforum.audit_log (created);
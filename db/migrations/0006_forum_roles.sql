@@ -0,0 +1,17 @@
+-- Per-forum roles. The forum owner gets the owner row with the forum;
+-- existing forums are backfilled from forum.user_nickname.
+
+CREATE UNLOGGED TABLE dbforum.forum_roles
+(
+    forum_slug CITEXT NOT NULL,
+    nickname   CITEXT NOT NULL,
+    role       TEXT   NOT NULL CHECK (role IN ('owner', 'moderator', 'member', 'banned')),
+
+    PRIMARY KEY (forum_slug, nickname),
+    FOREIGN KEY (forum_slug) REFERENCES dbforum.forum (slug),
+    FOREIGN KEY (nickname) REFERENCES dbforum.users (nickname)
+);
+
+INSERT INTO dbforum.forum_roles(forum_slug, nickname, role)
+SELECT slug, user_nickname, 'owner'
+FROM dbforum.forum;
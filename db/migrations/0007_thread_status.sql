@@ -0,0 +1,8 @@
+-- Moderator-set thread flags: locked threads take no new posts, closed ones
+-- no votes, pinned ones are listed first in their forum.
+ALTER TABLE dbforum.thread
+    ADD COLUMN is_locked BOOLEAN DEFAULT false NOT NULL,
+    ADD COLUMN is_pinned BOOLEAN DEFAULT false NOT NULL,
+    ADD COLUMN is_closed BOOLEAN DEFAULT false NOT NULL;
+
+CREATE INDEX thread_forum_slug_pinned_created_idx ON dbforum.thread (forum_slug, is_pinned, created);
@@ -0,0 +1,14 @@
+-- Full-text search. The vectors are written by the repositories together
+-- with the text they index: thread titles weigh more than messages.
+ALTER TABLE dbforum.post
+    ADD COLUMN search TSVECTOR;
+ALTER TABLE dbforum.thread
+    ADD COLUMN search TSVECTOR;
+
+UPDATE dbforum.post
+SET search = to_tsvector('simple', message);
+UPDATE dbforum.thread
+SET search = setweight(to_tsvector('simple', title), 'A') || setweight(to_tsvector('simple', message), 'B');
+
+CREATE INDEX posts_search_idx ON dbforum.post USING gin (search);
+CREATE INDEX thread_search_idx ON dbforum.thread USING gin (search);
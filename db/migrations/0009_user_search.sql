@@ -0,0 +1,7 @@
+-- User lookup by nickname prefix and, with pg_trgm, by fuzzy nickname or
+-- full name.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX users_nickname_pattern_idx ON dbforum.users (lower(nickname::text) text_pattern_ops);
+CREATE INDEX users_nickname_trgm_idx ON dbforum.users USING gin ((nickname::text) gin_trgm_ops);
+CREATE INDEX users_fullname_trgm_idx ON dbforum.users USING gin (fullname gin_trgm_ops);
@@ -0,0 +1,3 @@
+-- Per-user activity listings and user search rank by what a user wrote.
+CREATE INDEX posts_author_id_idx ON dbforum.post (author_nickname, id);
+CREATE INDEX thread_author_created_idx ON dbforum.thread (author_nickname, created, id);
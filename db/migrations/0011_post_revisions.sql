@@ -0,0 +1,13 @@
+-- Every effective edit of a post is kept as a revision. The first edit also
+-- stores the original message as revision 1, written by the post's author.
+CREATE UNLOGGED TABLE dbforum.post_revision
+(
+    post_id  BIGINT                   NOT NULL,
+    revision INTEGER                  NOT NULL,
+    message  TEXT                     NOT NULL,
+    editor   CITEXT,
+    created  TIMESTAMP WITH TIME ZONE NOT NULL,
+
+    PRIMARY KEY (post_id, revision),
+    FOREIGN KEY (post_id) REFERENCES dbforum.post (id) ON DELETE CASCADE
+);
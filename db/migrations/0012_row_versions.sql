@@ -0,0 +1,39 @@
+-- Editable rows carry a version that every update changing the row bumps.
+-- It is served as the ETag and checked against If-Match on updates.
+ALTER TABLE dbforum.thread
+    ADD COLUMN version BIGINT DEFAULT 1 NOT NULL;
+
+ALTER TABLE dbforum.post
+    ADD COLUMN version BIGINT DEFAULT 1 NOT NULL;
+
+ALTER TABLE dbforum.users
+    ADD COLUMN version BIGINT DEFAULT 1 NOT NULL;
+
+CREATE OR REPLACE FUNCTION dbforum.bump_version() RETURNS TRIGGER AS
+$$
+BEGIN
+    NEW.version = OLD.version + 1;
+    RETURN NEW;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER thread_version
+    BEFORE UPDATE
+    ON dbforum.thread
+    FOR EACH ROW
+    WHEN (OLD.* IS DISTINCT FROM NEW.*)
+EXECUTE FUNCTION dbforum.bump_version();
+
+CREATE TRIGGER post_version
+    BEFORE UPDATE
+    ON dbforum.post
+    FOR EACH ROW
+    WHEN (OLD.* IS DISTINCT FROM NEW.*)
+EXECUTE FUNCTION dbforum.bump_version();
+
+CREATE TRIGGER users_version
+    BEFORE UPDATE
+    ON dbforum.users
+    FOR EACH ROW
+    WHEN (OLD.* IS DISTINCT FROM NEW.*)
+EXECUTE FUNCTION dbforum.bump_version();
@@ -0,0 +1,18 @@
+-- Responses to creation requests sent with an Idempotency-Key header. The
+-- table is logged and has no foreign keys so that a retry after a restart
+-- still finds the original response. A row with status 0 is a request that
+-- is still running.
+CREATE TABLE dbforum.idempotency_key
+(
+    scope        CITEXT                   NOT NULL,
+    key          TEXT                     NOT NULL,
+    request_hash TEXT                     NOT NULL,
+    status       INTEGER DEFAULT 0        NOT NULL,
+    content_type TEXT    DEFAULT ''       NOT NULL,
+    body         BYTEA,
+    created      TIMESTAMP WITH TIME ZONE NOT NULL,
+
+    PRIMARY KEY (scope, key)
+);
+
+CREATE INDEX idempotency_key_created_idx ON dbforum.idempotency_key (created);
@@ -0,0 +1,11 @@
+-- Votes are not edits: a thread's version, and with it the ETag checked
+-- against If-Match, changes only with the columns its editors set.
+DROP TRIGGER thread_version ON dbforum.thread;
+
+CREATE TRIGGER thread_version
+    BEFORE UPDATE
+    ON dbforum.thread
+    FOR EACH ROW
+    WHEN ((OLD.title, OLD.message, OLD.is_archived, OLD.is_locked, OLD.is_pinned, OLD.is_closed)
+        IS DISTINCT FROM (NEW.title, NEW.message, NEW.is_archived, NEW.is_locked, NEW.is_pinned, NEW.is_closed))
+EXECUTE FUNCTION dbforum.bump_version();
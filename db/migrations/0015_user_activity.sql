@@ -0,0 +1,98 @@
+-- User search breaks ties by how much a user wrote: live posts and threads.
+-- The count is kept here by statement triggers instead of being counted for
+-- every candidate of every search.
+CREATE UNLOGGED TABLE dbforum.user_activity
+(
+    nickname CITEXT PRIMARY KEY NOT NULL,
+    activity BIGINT DEFAULT 0   NOT NULL,
+
+    FOREIGN KEY (nickname) REFERENCES dbforum.users (nickname) ON DELETE CASCADE
+);
+
+INSERT INTO dbforum.user_activity (nickname, activity)
+SELECT nickname, count(*)
+FROM (SELECT author_nickname FROM dbforum.post WHERE NOT is_deleted
+      UNION ALL
+      SELECT author_nickname FROM dbforum.thread) AS written(nickname)
+GROUP BY nickname;
+
+-- add_activity applies per-user changes, locking rows in nickname order so
+-- that concurrent writers can't deadlock on them.
+CREATE OR REPLACE FUNCTION dbforum.add_activity(nicknames CITEXT[], deltas BIGINT[]) RETURNS VOID AS
+$$
+INSERT INTO dbforum.user_activity AS a (nickname, activity)
+SELECT nickname, sum(delta)
+FROM unnest(nicknames, deltas) AS changes(nickname, delta)
+GROUP BY nickname
+HAVING sum(delta) <> 0
+ORDER BY nickname
+ON CONFLICT (nickname) DO UPDATE SET activity = a.activity + EXCLUDED.activity;
+$$ LANGUAGE sql;
+
+-- Each trigger only sees the transition tables of its own event, so the
+-- functions branch on TG_OP.
+CREATE OR REPLACE FUNCTION dbforum.count_post_activity() RETURNS TRIGGER AS
+$$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        PERFORM dbforum.add_activity(array_agg(author_nickname), array_agg(1::bigint))
+        FROM new_rows WHERE NOT is_deleted;
+    ELSIF TG_OP = 'DELETE' THEN
+        PERFORM dbforum.add_activity(array_agg(author_nickname), array_agg(-1::bigint))
+        FROM old_rows WHERE NOT is_deleted;
+    ELSE
+        PERFORM dbforum.add_activity(array_agg(n.author_nickname),
+                                     array_agg(CASE WHEN n.is_deleted THEN -1::bigint ELSE 1::bigint END))
+        FROM new_rows n JOIN old_rows o ON o.id = n.id
+        WHERE n.is_deleted <> o.is_deleted;
+    END IF;
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE FUNCTION dbforum.count_thread_activity() RETURNS TRIGGER AS
+$$
+BEGIN
+    IF TG_OP = 'INSERT' THEN
+        PERFORM dbforum.add_activity(array_agg(author_nickname), array_agg(1::bigint)) FROM new_rows;
+    ELSE
+        PERFORM dbforum.add_activity(array_agg(author_nickname), array_agg(-1::bigint)) FROM old_rows;
+    END IF;
+    RETURN NULL;
+END
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER post_activity_insert
+    AFTER INSERT
+    ON dbforum.post
+    REFERENCING NEW TABLE AS new_rows
+    FOR EACH STATEMENT
+EXECUTE FUNCTION dbforum.count_post_activity();
+
+CREATE TRIGGER post_activity_update
+    AFTER UPDATE
+    ON dbforum.post
+    REFERENCING OLD TABLE AS old_rows NEW TABLE AS new_rows
+    FOR EACH STATEMENT
+EXECUTE FUNCTION dbforum.count_post_activity();
+
+CREATE TRIGGER post_activity_delete
+    AFTER DELETE
+    ON dbforum.post
+    REFERENCING OLD TABLE AS old_rows
+    FOR EACH STATEMENT
+EXECUTE FUNCTION dbforum.count_post_activity();
+
+CREATE TRIGGER thread_activity_insert
+    AFTER INSERT
+    ON dbforum.thread
+    REFERENCING NEW TABLE AS new_rows
+    FOR EACH STATEMENT
+EXECUTE FUNCTION dbforum.count_thread_activity();
+
+CREATE TRIGGER thread_activity_delete
+    AFTER DELETE
+    ON dbforum.thread
+    REFERENCING OLD TABLE AS old_rows
+    FOR EACH STATEMENT
+EXECUTE FUNCTION dbforum.count_thread_activity();
@@ -0,0 +1,8 @@
+// Package migrations embeds the numbered SQL migrations of the dbforum schema.
+// Files are named NNNN_description.sql and are applied in order, forward only.
+package migrations
+
+import "embed"
+
+//go:embed *.sql
+var FS embed.FS
@@ -12,7 +12,9 @@ require (
 	github.com/lib/pq v1.2.0
 	github.com/mailru/easyjson v0.7.7
 	github.com/pkg/errors v0.9.1
+	github.com/prometheus/client_golang v1.9.0
 	github.com/shopspring/decimal v1.2.0 // indirect
 	github.com/sirupsen/logrus v1.8.1
 	github.com/valyala/fasthttp v1.26.0
+	golang.org/x/crypto v0.0.0-20210513164829-c07d793c2f9a
 )
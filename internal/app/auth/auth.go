@@ -0,0 +1,132 @@
+// Package auth resolves bearer tokens to users and checks that writes are
+// made on behalf of the authenticated user.
+package auth
+
+import (
+	customErr "DBForum/internal/app/errors"
+	"DBForum/internal/app/models"
+	"github.com/valyala/fasthttp"
+	"golang.org/x/crypto/bcrypt"
+	"strings"
+)
+
+const (
+	actorKey    = "auth.actor"
+	requiredKey = "auth.required"
+)
+
+func HashPassword(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+func CheckPassword(hash string, password string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(password)) == nil
+}
+
+func SetActor(ctx *fasthttp.RequestCtx, actor models.Actor) {
+	ctx.SetUserValue(actorKey, actor)
+}
+
+// Actor is the user the request is authenticated as, or the zero Actor.
+func Actor(ctx *fasthttp.RequestCtx) models.Actor {
+	actor, _ := ctx.UserValue(actorKey).(models.Actor)
+	return actor
+}
+
+func SetRequired(ctx *fasthttp.RequestCtx) {
+	ctx.SetUserValue(requiredKey, true)
+}
+
+// Caller returns the request's actor. Anonymous callers get
+// ErrUnauthorized when authentication is required.
+func Caller(ctx *fasthttp.RequestCtx) (models.Actor, error) {
+	actor := Actor(ctx)
+	if actor.Nickname == "" {
+		if required, _ := ctx.UserValue(requiredKey).(bool); required {
+			return models.Actor{}, customErr.Unauthorized("Authentication required")
+		}
+	}
+	return actor, nil
+}
+
+// Authorize checks that the request may write on behalf of nickname. An
+// authenticated request may only act as its own user; an anonymous one is
+// let through unless authentication is required.
+func Authorize(ctx *fasthttp.RequestCtx, nickname string) error {
+	actor, err := Caller(ctx)
+	if err != nil {
+		return err
+	}
+	if actor.Nickname != "" && !strings.EqualFold(actor.Nickname, nickname) {
+		return customErr.Forbidden(nickname)
+	}
+	return nil
+}
+
+// AuthorizeSelf checks that the request is authenticated as nickname, for
+// changes only a user may make to their own data. Unlike Authorize it
+// refuses anonymous requests even when authentication is optional.
+func AuthorizeSelf(ctx *fasthttp.RequestCtx, nickname string) error {
+	actor := Actor(ctx)
+	if actor.Nickname == "" {
+		return customErr.Unauthorized("Authentication required")
+	}
+	if !strings.EqualFold(actor.Nickname, nickname) {
+		return customErr.Forbidden(nickname)
+	}
+	return nil
+}
+
+// RoleSource looks up a user's role in a forum.
+type RoleSource interface {
+	GetRole(forumSlug string, nickname string) (string, error)
+}
+
+// RoleIn returns actor's role in the forum, "" for anonymous actors.
+func RoleIn(roles RoleSource, actor models.Actor, forumSlug string) (string, error) {
+	if actor.Nickname == "" {
+		return "", nil
+	}
+	return roles.GetRole(forumSlug, actor.Nickname)
+}
+
+// CanEdit checks that actor, holding role in the forum, may change content
+// that author wrote there: authors edit their own posts and threads, the
+// forum's owner and moderators and admins edit anything in it, banned users
+// nothing. Ownership can't be checked without an identity, so anonymous
+// actors are refused even when authentication is optional.
+func CanEdit(actor models.Actor, action string, author string, role string) error {
+	if actor.Nickname == "" {
+		return customErr.Unauthorized("Authentication required")
+	}
+	if actor.Admin {
+		return nil
+	}
+	switch role {
+	case models.RoleBanned:
+		return customErr.NotAllowed(action)
+	case models.RoleOwner, models.RoleModerator:
+		return nil
+	}
+	if strings.EqualFold(actor.Nickname, author) {
+		return nil
+	}
+	return customErr.NotAllowed(action)
+}
+
+// CanModerate checks that actor is an admin or moderates the forum through
+// role. Moderation stays closed to anonymous callers even when
+// authentication is optional.
+func CanModerate(actor models.Actor, action string, role string) error {
+	if actor.Nickname == "" {
+		return customErr.Unauthorized("Authentication required")
+	}
+	if actor.Admin || role == models.RoleOwner || role == models.RoleModerator {
+		return nil
+	}
+	return customErr.NotAllowed(action)
+}
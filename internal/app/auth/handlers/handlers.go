@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"DBForum/internal/app/auth"
+	authUseCase "DBForum/internal/app/auth/usecase"
+	customErr "DBForum/internal/app/errors"
+	"DBForum/internal/app/httputils"
+	"DBForum/internal/app/logger"
+	"DBForum/internal/app/metrics"
+	"DBForum/internal/app/middleware"
+	"DBForum/internal/app/models"
+	"DBForum/internal/app/validation"
+	"bytes"
+	"errors"
+	"github.com/mailru/easyjson"
+	"github.com/valyala/fasthttp"
+	"net/http"
+	"time"
+)
+
+var bearerPrefix = []byte("Bearer ")
+
+type Handlers struct {
+	useCase authUseCase.UseCase
+}
+
+func NewHandler(useCase authUseCase.UseCase) *Handlers {
+	return &Handlers{
+		useCase: useCase,
+	}
+}
+
+func (h *Handlers) Login(ctx *fasthttp.RequestCtx) {
+	var credentials models.Credentials
+	if err := validation.Login.Object(ctx.PostBody()); err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
+	if err := easyjson.Unmarshal(ctx.PostBody(), &credentials); err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
+
+	session, err := h.useCase.Login(credentials)
+	if err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
+	httputils.Respond(ctx, http.StatusOK, session)
+}
+
+func (h *Handlers) Logout(ctx *fasthttp.RequestCtx) {
+	token, ok := bearerToken(ctx)
+	if !ok {
+		httputils.RespondError(ctx, customErr.Unauthorized("Authentication required"))
+		return
+	}
+	if err := h.useCase.Logout(token); err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
+	httputils.Respond(ctx, http.StatusNoContent, nil)
+}
+
+// SetPassword sets the password of the user in the path. Users change
+// their own; admins set anyone's, e.g. for accounts made without one.
+func (h *Handlers) SetPassword(ctx *fasthttp.RequestCtx) {
+	nickname := ctx.UserValue("nickname").(string)
+
+	if !auth.Actor(ctx).Admin {
+		if err := auth.AuthorizeSelf(ctx, nickname); err != nil {
+			httputils.RespondError(ctx, err)
+			return
+		}
+	}
+	if err := validation.SetPassword.Object(ctx.PostBody()); err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
+	var credentials models.Credentials
+	if err := easyjson.Unmarshal(ctx.PostBody(), &credentials); err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
+
+	if err := h.useCase.SetPassword(nickname, credentials.Password); err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
+	httputils.Respond(ctx, http.StatusNoContent, nil)
+}
+
+// Middleware authenticates requests that carry an Authorization header and
+// answers 401 when the token is malformed, unknown or expired. Requests the
+// handlers refuse as forbidden are written to the audit log; other 403s,
+// such as posting to a locked thread, are not authorization failures.
+func (h *Handlers) Middleware(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		if h.useCase.Required() {
+			auth.SetRequired(ctx)
+		}
+		if len(ctx.Request.Header.Peek("Authorization")) != 0 {
+			token, ok := bearerToken(ctx)
+			if !ok {
+				httputils.RespondError(ctx, customErr.Unauthorized("Expected a bearer token"))
+				return
+			}
+			actor, err := h.useCase.Authenticate(token)
+			if err != nil {
+				httputils.RespondError(ctx, err)
+				return
+			}
+			auth.SetActor(ctx, actor)
+		}
+
+		next(ctx)
+
+		if errors.Is(metrics.Error(ctx), customErr.ErrForbidden) {
+			h.audit(ctx)
+		}
+	}
+}
+
+func (h *Handlers) audit(ctx *fasthttp.RequestCtx) {
+	err := h.useCase.RecordDenied(models.AuditEntry{
+		Actor:     auth.Actor(ctx).Nickname,
+		Method:    string(ctx.Method()),
+		Route:     middleware.Route(ctx),
+		Path:      string(ctx.Path()),
+		RequestID: logger.RequestID(ctx),
+		Created:   time.Now(),
+	})
+	if err != nil {
+		logger.FromCtx(ctx).WithError(err).Error("writing audit log")
+	}
+}
+
+func bearerToken(ctx *fasthttp.RequestCtx) (string, bool) {
+	header := ctx.Request.Header.Peek("Authorization")
+	if !bytes.HasPrefix(header, bearerPrefix) || len(header) == len(bearerPrefix) {
+		return "", false
+	}
+	return string(header[len(bearerPrefix):]), true
+}
@@ -0,0 +1,15 @@
+package auth
+
+import "DBForum/internal/app/models"
+
+type Repository interface {
+	SetPassword(nickname string, passwordHash string) error
+	// FindCredentials returns the canonical nickname and, in Password, the
+	// stored bcrypt hash.
+	FindCredentials(nickname string) (models.Credentials, error)
+	CreateToken(tokenHash string, session models.Session) error
+	FindToken(tokenHash string) (models.Session, error)
+	DeleteToken(tokenHash string) error
+	SetAdmin(nickname string, admin bool) error
+	InsertAuditEntry(entry models.AuditEntry) error
+}
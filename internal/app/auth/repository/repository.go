@@ -0,0 +1,149 @@
+package repository
+
+import (
+	"DBForum/internal/app/auth"
+	customErr "DBForum/internal/app/errors"
+	"DBForum/internal/app/models"
+	"github.com/jackc/pgx"
+)
+
+const (
+	upsertCredentials = `INSERT INTO dbforum.credentials(nickname, password_hash)
+						SELECT nickname, $2 FROM dbforum.users WHERE nickname = $1
+						ON CONFLICT (nickname) DO UPDATE SET password_hash = EXCLUDED.password_hash`
+
+	selectCredentials = "SELECT nickname, password_hash FROM dbforum.credentials WHERE nickname = $1"
+
+	insertToken = "INSERT INTO dbforum.tokens(token_hash, nickname, created, expires) VALUES ($1, $2, now(), $3)"
+
+	selectToken = `SELECT t.nickname, t.expires, COALESCE(c.is_admin, false)
+					FROM dbforum.tokens t LEFT JOIN dbforum.credentials c ON c.nickname = t.nickname
+					WHERE t.token_hash = $1`
+
+	deleteToken = "DELETE FROM dbforum.tokens WHERE token_hash = $1"
+
+	updateAdmin = "UPDATE dbforum.credentials SET is_admin = $2 WHERE nickname = $1"
+
+	insertAuditEntry = `INSERT INTO dbforum.audit_log(actor, method, route, path, request_id, created)
+						VALUES ($1, $2, $3, $4, $5, $6)`
+)
+
+var _ auth.Repository = (*Repository)(nil)
+
+type Repository struct {
+	db *pgx.ConnPool
+}
+
+func NewRepo(db *pgx.ConnPool) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+func (r *Repository) SetPassword(nickname string, passwordHash string) error {
+	tag, err := r.db.Exec("upsertCredentials", nickname, passwordHash)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return customErr.UserNotFound(nickname)
+	}
+	return nil
+}
+
+func (r *Repository) FindCredentials(nickname string) (models.Credentials, error) {
+	var credentials models.Credentials
+	err := r.db.QueryRow("selectCredentials", nickname).Scan(&credentials.Nickname, &credentials.Password)
+	if err == pgx.ErrNoRows {
+		return models.Credentials{}, customErr.UserNotFound(nickname)
+	}
+	if err != nil {
+		return models.Credentials{}, err
+	}
+	return credentials, nil
+}
+
+func (r *Repository) CreateToken(tokenHash string, session models.Session) error {
+	_, err := r.db.Exec("insertToken", tokenHash, session.Nickname, session.Expires)
+	return err
+}
+
+func (r *Repository) FindToken(tokenHash string) (models.Session, error) {
+	var session models.Session
+	err := r.db.QueryRow("selectToken", tokenHash).Scan(&session.Nickname, &session.Expires, &session.Admin)
+	if err == pgx.ErrNoRows {
+		return models.Session{}, customErr.Unauthorized("Invalid or expired token")
+	}
+	if err != nil {
+		return models.Session{}, err
+	}
+	return session, nil
+}
+
+func (r *Repository) DeleteToken(tokenHash string) error {
+	_, err := r.db.Exec("deleteToken", tokenHash)
+	return err
+}
+
+// SetAdmin fails with ErrUserNotFound for users without credentials: an
+// admin has to be able to log in.
+func (r *Repository) SetAdmin(nickname string, admin bool) error {
+	tag, err := r.db.Exec("updateAdmin", nickname, admin)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return customErr.UserNotFound(nickname)
+	}
+	return nil
+}
+
+func (r *Repository) InsertAuditEntry(entry models.AuditEntry) error {
+	_, err := r.db.Exec("insertAuditEntry",
+		entry.Actor,
+		entry.Method,
+		entry.Route,
+		entry.Path,
+		entry.RequestID,
+		entry.Created)
+	return err
+}
+
+func (r *Repository) Prepare() error {
+	_, err := r.db.Prepare("upsertCredentials", upsertCredentials)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Prepare("selectCredentials", selectCredentials)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Prepare("insertToken", insertToken)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Prepare("selectToken", selectToken)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Prepare("deleteToken", deleteToken)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Prepare("updateAdmin", updateAdmin)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Prepare("insertAuditEntry", insertAuditEntry)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"DBForum/internal/app/auth"
+	customErr "DBForum/internal/app/errors"
+	"DBForum/internal/app/models"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+type UseCase struct {
+	repo     auth.Repository
+	tokenTTL time.Duration
+	required bool
+}
+
+func NewUseCase(repo auth.Repository, tokenTTL time.Duration, required bool) *UseCase {
+	return &UseCase{
+		repo:     repo,
+		tokenTTL: tokenTTL,
+		required: required,
+	}
+}
+
+// Required reports whether anonymous writes are rejected.
+func (u *UseCase) Required() bool {
+	return u.required
+}
+
+func (u *UseCase) SetPassword(nickname string, password string) error {
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return err
+	}
+	return u.repo.SetPassword(nickname, hash)
+}
+
+// Login checks the password and issues a new token. Unknown users and wrong
+// passwords get the same answer.
+func (u *UseCase) Login(credentials models.Credentials) (models.Session, error) {
+	stored, err := u.repo.FindCredentials(credentials.Nickname)
+	if errors.Is(err, customErr.ErrUserNotFound) {
+		return models.Session{}, customErr.Unauthorized("Wrong nickname or password")
+	}
+	if err != nil {
+		return models.Session{}, err
+	}
+	if !auth.CheckPassword(stored.Password, credentials.Password) {
+		return models.Session{}, customErr.Unauthorized("Wrong nickname or password")
+	}
+
+	token, err := newToken()
+	if err != nil {
+		return models.Session{}, err
+	}
+	session := models.Session{
+		Token:    token,
+		Nickname: stored.Nickname,
+		Expires:  time.Now().Add(u.tokenTTL).UTC(),
+	}
+	if err := u.repo.CreateToken(hashToken(token), session); err != nil {
+		return models.Session{}, err
+	}
+	return session, nil
+}
+
+func (u *UseCase) Logout(token string) error {
+	return u.repo.DeleteToken(hashToken(token))
+}
+
+// Authenticate returns the user a token was issued to.
+func (u *UseCase) Authenticate(token string) (models.Actor, error) {
+	session, err := u.repo.FindToken(hashToken(token))
+	if err != nil {
+		return models.Actor{}, err
+	}
+	if !time.Now().Before(session.Expires) {
+		return models.Actor{}, customErr.Unauthorized("Invalid or expired token")
+	}
+	return models.Actor{Nickname: session.Nickname, Admin: session.Admin}, nil
+}
+
+func (u *UseCase) SetAdmin(nickname string, admin bool) error {
+	return u.repo.SetAdmin(nickname, admin)
+}
+
+func (u *UseCase) RecordDenied(entry models.AuditEntry) error {
+	return u.repo.InsertAuditEntry(entry)
+}
+
+func newToken() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,78 @@
+package usecase
+
+import (
+	customErr "DBForum/internal/app/errors"
+	"DBForum/internal/app/memory"
+	"DBForum/internal/app/models"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newUseCase(t *testing.T, ttl time.Duration) *UseCase {
+	t.Helper()
+	store := memory.NewStore()
+	if err := memory.NewUserRepo(store).CreateUser(models.User{Nickname: "Alice", Fullname: "Alice", Email: "alice@example.com"}, ""); err != nil {
+		t.Fatal(err)
+	}
+	u := NewUseCase(memory.NewAuthRepo(store), ttl, false)
+	if err := u.SetPassword("alice", "correct horse"); err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestLoginAndLogout(t *testing.T) {
+	u := newUseCase(t, time.Hour)
+
+	session, err := u.Login(models.Credentials{Nickname: "ALICE", Password: "correct horse"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if session.Token == "" || session.Nickname != "Alice" || !session.Expires.After(time.Now()) {
+		t.Errorf("session = %+v", session)
+	}
+	actor, err := u.Authenticate(session.Token)
+	if err != nil || actor != (models.Actor{Nickname: "Alice"}) {
+		t.Errorf("Authenticate = %+v, %v, want Alice", actor, err)
+	}
+	if err := u.SetAdmin("alice", true); err != nil {
+		t.Fatal(err)
+	}
+	if actor, _ = u.Authenticate(session.Token); !actor.Admin {
+		t.Errorf("actor = %+v, want an admin", actor)
+	}
+
+	if err := u.Logout(session.Token); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := u.Authenticate(session.Token); !errors.Is(err, customErr.ErrUnauthorized) {
+		t.Errorf("after logout: err = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestLoginRejectsBadCredentials(t *testing.T) {
+	u := newUseCase(t, time.Hour)
+	for _, credentials := range []models.Credentials{
+		{Nickname: "alice", Password: "wrong horse"},
+		{Nickname: "mallory", Password: "correct horse"},
+	} {
+		if _, err := u.Login(credentials); !errors.Is(err, customErr.ErrUnauthorized) {
+			t.Errorf("%+v: err = %v, want ErrUnauthorized", credentials, err)
+		}
+	}
+	if err := u.SetPassword("mallory", "correct horse"); !errors.Is(err, customErr.ErrUserNotFound) {
+		t.Errorf("SetPassword for a missing user: err = %v, want ErrUserNotFound", err)
+	}
+}
+
+func TestExpiredToken(t *testing.T) {
+	u := newUseCase(t, -time.Second)
+	session, err := u.Login(models.Credentials{Nickname: "alice", Password: "correct horse"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := u.Authenticate(session.Token); !errors.Is(err, customErr.ErrUnauthorized) {
+		t.Errorf("err = %v, want ErrUnauthorized", err)
+	}
+}
@@ -0,0 +1,327 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/jackc/pgx"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	envFile = "DBFORUM_CONFIG"
+
+	envListen           = "DBFORUM_LISTEN"
+	envShutdownTimeout  = "DBFORUM_SHUTDOWN_TIMEOUT"
+	envDSN              = "DBFORUM_DB_DSN"
+	envHost             = "DBFORUM_DB_HOST"
+	envPort             = "DBFORUM_DB_PORT"
+	envUser             = "DBFORUM_DB_USER"
+	envPassword         = "DBFORUM_DB_PASSWORD"
+	envName             = "DBFORUM_DB_NAME"
+	envSSLMode          = "DBFORUM_DB_SSLMODE"
+	envMaxConnections   = "DBFORUM_DB_MAX_CONNS"
+	envAcquireTimeout   = "DBFORUM_DB_ACQUIRE_TIMEOUT"
+	envStatementTimeout = "DBFORUM_DB_STATEMENT_TIMEOUT"
+	envAuthRequired     = "DBFORUM_AUTH_REQUIRED"
+	envAuthTokenTTL     = "DBFORUM_AUTH_TOKEN_TTL"
+	envIdemRetention    = "DBFORUM_IDEMPOTENCY_RETENTION"
+)
+
+var sslModes = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+
+// Duration is a time.Duration that is written as "5s" or "1m30s" in config files.
+type Duration time.Duration
+
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("duration must be a string like \"5s\": %w", err)
+	}
+	parsed, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+type Config struct {
+	Listen string `json:"listen"`
+	// ShutdownTimeout bounds how long in-flight requests may run after
+	// SIGTERM before the database pool is closed anyway.
+	ShutdownTimeout Duration    `json:"shutdown_timeout"`
+	Database        Database    `json:"database"`
+	Auth            Auth        `json:"auth"`
+	Idempotency     Idempotency `json:"idempotency"`
+}
+
+// Database describes how to reach Postgres. Either DSN or the discrete
+// connection fields may be set, not both.
+type Database struct {
+	DSN              string   `json:"dsn"`
+	Host             string   `json:"host"`
+	Port             uint16   `json:"port"`
+	User             string   `json:"user"`
+	Password         string   `json:"password"`
+	Name             string   `json:"name"`
+	SSLMode          string   `json:"sslmode"`
+	MaxConnections   int      `json:"max_connections"`
+	AcquireTimeout   Duration `json:"acquire_timeout"`
+	StatementTimeout Duration `json:"statement_timeout"`
+}
+
+// Auth controls bearer-token authentication. Requests that carry a token
+// may only write as its user. Required rejects anonymous writes; turning it
+// off brings back the original open API for legacy clients.
+type Auth struct {
+	Required bool     `json:"required"`
+	TokenTTL Duration `json:"token_ttl"`
+}
+
+// Idempotency controls how long responses to requests made with an
+// Idempotency-Key are kept for replay.
+type Idempotency struct {
+	Retention Duration `json:"retention"`
+}
+
+func Default() Config {
+	return Config{
+		Listen:          ":5000",
+		ShutdownTimeout: Duration(10 * time.Second),
+		Database: Database{
+			MaxConnections: 100,
+		},
+		Auth: Auth{
+			Required: true,
+			TokenTTL: Duration(24 * time.Hour),
+		},
+		Idempotency: Idempotency{
+			Retention: Duration(24 * time.Hour),
+		},
+	}
+}
+
+// Load builds the config from defaults, then the JSON file at path (or at
+// $DBFORUM_CONFIG when path is empty), then DBFORUM_* environment variables.
+func Load(path string) (Config, error) {
+	conf := Default()
+
+	if path == "" {
+		path = os.Getenv(envFile)
+	}
+	if path != "" {
+		if err := conf.readFile(path); err != nil {
+			return Config{}, err
+		}
+	}
+	if err := conf.readEnv(); err != nil {
+		return Config{}, err
+	}
+	if err := conf.Validate(); err != nil {
+		return Config{}, err
+	}
+	return conf, nil
+}
+
+func (c *Config) readFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("config: %w", err)
+	}
+	defer f.Close()
+
+	decoder := json.NewDecoder(f)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(c); err != nil {
+		return fmt.Errorf("config: %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Config) readEnv() error {
+	lookupString(envListen, &c.Listen)
+	lookupString(envDSN, &c.Database.DSN)
+	lookupString(envHost, &c.Database.Host)
+	lookupString(envUser, &c.Database.User)
+	lookupString(envPassword, &c.Database.Password)
+	lookupString(envName, &c.Database.Name)
+	lookupString(envSSLMode, &c.Database.SSLMode)
+
+	if v, ok := os.LookupEnv(envPort); ok {
+		port, err := strconv.ParseUint(v, 10, 16)
+		if err != nil {
+			return fmt.Errorf("config: %s: %w", envPort, err)
+		}
+		c.Database.Port = uint16(port)
+	}
+	if v, ok := os.LookupEnv(envMaxConnections); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("config: %s: %w", envMaxConnections, err)
+		}
+		c.Database.MaxConnections = n
+	}
+	if v, ok := os.LookupEnv(envAuthRequired); ok {
+		required, err := strconv.ParseBool(v)
+		if err != nil {
+			return fmt.Errorf("config: %s: %w", envAuthRequired, err)
+		}
+		c.Auth.Required = required
+	}
+	if err := lookupDuration(envAuthTokenTTL, &c.Auth.TokenTTL); err != nil {
+		return err
+	}
+	if err := lookupDuration(envIdemRetention, &c.Idempotency.Retention); err != nil {
+		return err
+	}
+	if err := lookupDuration(envShutdownTimeout, &c.ShutdownTimeout); err != nil {
+		return err
+	}
+	if err := lookupDuration(envAcquireTimeout, &c.Database.AcquireTimeout); err != nil {
+		return err
+	}
+	if err := lookupDuration(envStatementTimeout, &c.Database.StatementTimeout); err != nil {
+		return err
+	}
+	return nil
+}
+
+func lookupString(key string, dst *string) {
+	if v, ok := os.LookupEnv(key); ok {
+		*dst = v
+	}
+}
+
+func lookupDuration(key string, dst *Duration) error {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		return fmt.Errorf("config: %s: %w", key, err)
+	}
+	*dst = Duration(d)
+	return nil
+}
+
+func (c Config) Validate() error {
+	if _, _, err := net.SplitHostPort(c.Listen); err != nil {
+		return fmt.Errorf("config: invalid listen address %q: %w", c.Listen, err)
+	}
+	if c.ShutdownTimeout <= 0 {
+		return fmt.Errorf("config: shutdown_timeout must be positive")
+	}
+	if c.Auth.TokenTTL <= 0 {
+		return fmt.Errorf("config: auth token_ttl must be positive")
+	}
+	if c.Idempotency.Retention <= 0 {
+		return fmt.Errorf("config: idempotency retention must be positive")
+	}
+	return c.Database.Validate()
+}
+
+func (d Database) Validate() error {
+	if d.DSN != "" {
+		if d.Host != "" || d.Port != 0 || d.User != "" || d.Password != "" || d.Name != "" || d.SSLMode != "" {
+			return fmt.Errorf("config: database dsn can't be combined with host, port, user, password, name or sslmode")
+		}
+	}
+	if d.SSLMode != "" && !contains(sslModes, d.SSLMode) {
+		return fmt.Errorf("config: invalid database sslmode %q, expected one of %s",
+			d.SSLMode, strings.Join(sslModes, ", "))
+	}
+	if d.MaxConnections < 1 {
+		return fmt.Errorf("config: database max_connections must be positive, got %d", d.MaxConnections)
+	}
+	if d.AcquireTimeout < 0 {
+		return fmt.Errorf("config: database acquire_timeout can't be negative")
+	}
+	if d.StatementTimeout < 0 {
+		return fmt.Errorf("config: database statement_timeout can't be negative")
+	}
+	if _, err := d.ConnConfig(); err != nil {
+		return fmt.Errorf("config: invalid database connection settings: %w", err)
+	}
+	return nil
+}
+
+// ConnConfig converts the settings into a pgx connection config.
+func (d Database) ConnConfig() (pgx.ConnConfig, error) {
+	dsn := d.DSN
+	if dsn == "" {
+		dsn = d.buildDSN()
+	}
+	conf, err := pgx.ParseConnectionString(dsn)
+	if err != nil {
+		return pgx.ConnConfig{}, err
+	}
+	if d.StatementTimeout > 0 {
+		if conf.RuntimeParams == nil {
+			conf.RuntimeParams = map[string]string{}
+		}
+		ms := time.Duration(d.StatementTimeout).Milliseconds()
+		conf.RuntimeParams["statement_timeout"] = strconv.FormatInt(ms, 10)
+	}
+	return conf, nil
+}
+
+func (d Database) PoolConfig() (pgx.ConnPoolConfig, error) {
+	conf, err := d.ConnConfig()
+	if err != nil {
+		return pgx.ConnPoolConfig{}, err
+	}
+	return pgx.ConnPoolConfig{
+		ConnConfig:     conf,
+		MaxConnections: d.MaxConnections,
+		AcquireTimeout: time.Duration(d.AcquireTimeout),
+	}, nil
+}
+
+func (d Database) buildDSN() string {
+	user, name, sslMode := d.User, d.Name, d.SSLMode
+	if user == "" {
+		user = "postgres"
+	}
+	if name == "" {
+		name = "postgres"
+	}
+	if sslMode == "" {
+		sslMode = "disable"
+	}
+
+	params := []string{
+		"user=" + quote(user),
+		"dbname=" + quote(name),
+		"sslmode=" + sslMode,
+	}
+	if d.Host != "" {
+		params = append(params, "host="+quote(d.Host))
+	}
+	if d.Port != 0 {
+		params = append(params, "port="+strconv.Itoa(int(d.Port)))
+	}
+	if d.Password != "" {
+		params = append(params, "password="+quote(d.Password))
+	}
+	return strings.Join(params, " ")
+}
+
+func quote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	s = strings.ReplaceAll(s, `'`, `\'`)
+	return "'" + s + "'"
+}
+
+func contains(slice []string, val string) bool {
+	for _, item := range slice {
+		if item == val {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,259 @@
+package database
+
+import (
+	"DBForum/db/migrations"
+	"context"
+	"fmt"
+	"github.com/jackc/pgx"
+	"github.com/pkg/errors"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	// migrationLockID is the pg_advisory_lock key that serializes concurrent
+	// `migrate up` runs against the same database.
+	migrationLockID = 7300261
+
+	createMigrationsTable = `CREATE TABLE IF NOT EXISTS public.schema_migrations
+							(
+							    version    INT PRIMARY KEY          NOT NULL,
+							    name       TEXT                     NOT NULL,
+							    applied_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT now()
+							)`
+
+	selectAppliedMigrations = "SELECT version, applied_at FROM public.schema_migrations ORDER BY version"
+
+	insertMigration = "INSERT INTO public.schema_migrations (version, name) VALUES ($1, $2)"
+
+	selectLegacySchema = "SELECT to_regclass('dbforum.users') IS NOT NULL"
+)
+
+var (
+	ErrSchemaOutdated = errors.New("database schema is outdated")
+
+	migrationName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.sql$`)
+)
+
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+type MigrationStatus struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+type Migrator struct {
+	db         *pgx.ConnPool
+	migrations []Migration
+}
+
+func NewMigrator(db *pgx.ConnPool) (*Migrator, error) {
+	list, err := loadMigrations(migrations.FS)
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		db:         db,
+		migrations: list,
+	}, nil
+}
+
+func loadMigrations(fsys fs.FS) ([]Migration, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+	var list []Migration
+	for _, file := range files {
+		match := migrationName.FindStringSubmatch(file)
+		if match == nil {
+			return nil, errors.Errorf("migration %s: name must look like 0001_description.sql", file)
+		}
+		version, _ := strconv.Atoi(match[1])
+		body, err := fs.ReadFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, Migration{
+			Version: version,
+			Name:    match[2],
+			SQL:     string(body),
+		})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Version < list[j].Version
+	})
+	for i, m := range list {
+		if m.Version != i+1 {
+			return nil, errors.Errorf("migration %04d_%s: versions must be consecutive starting at 1", m.Version, m.Name)
+		}
+	}
+	return list, nil
+}
+
+// Latest is the schema version the code expects.
+func (m *Migrator) Latest() int {
+	return len(m.migrations)
+}
+
+// Current is the highest applied schema version, 0 for an empty database.
+func (m *Migrator) Current() (int, error) {
+	applied, err := m.applied(m.db)
+	if err != nil {
+		return 0, err
+	}
+	current := 0
+	for version := range applied {
+		if version > current {
+			current = version
+		}
+	}
+	return current, nil
+}
+
+// Check refuses a database that hasn't been migrated up to Latest.
+func (m *Migrator) Check() error {
+	current, err := m.Current()
+	if err != nil {
+		return err
+	}
+	if current < m.Latest() {
+		return errors.Wrapf(ErrSchemaOutdated, "schema is at version %d, code expects %d; run `migrate up`",
+			current, m.Latest())
+	}
+	return nil
+}
+
+func (m *Migrator) Status() ([]MigrationStatus, error) {
+	applied, err := m.applied(m.db)
+	if err != nil {
+		return nil, err
+	}
+	statuses := make([]MigrationStatus, 0, len(m.migrations))
+	for _, migration := range m.migrations {
+		appliedAt, ok := applied[migration.Version]
+		statuses = append(statuses, MigrationStatus{
+			Migration: migration,
+			Applied:   ok,
+			AppliedAt: appliedAt,
+		})
+	}
+	return statuses, nil
+}
+
+// Up applies every pending migration, each in its own transaction, and
+// returns the ones it applied.
+func (m *Migrator) Up() ([]Migration, error) {
+	conn, err := m.db.Acquire()
+	if err != nil {
+		return nil, err
+	}
+	defer m.db.Release(conn)
+
+	if _, err := conn.Exec("SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return nil, err
+	}
+	defer func() {
+		_, _ = conn.Exec("SELECT pg_advisory_unlock($1)", migrationLockID)
+	}()
+
+	if _, err := conn.Exec(createMigrationsTable); err != nil {
+		return nil, err
+	}
+	if err := m.adoptLegacySchema(conn); err != nil {
+		return nil, err
+	}
+	applied, err := m.applied(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	var done []Migration
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+		if err := m.apply(conn, migration); err != nil {
+			return done, errors.Wrapf(err, "migration %04d_%s", migration.Version, migration.Name)
+		}
+		done = append(done, migration)
+	}
+	return done, nil
+}
+
+func (m *Migrator) apply(conn *pgx.Conn, migration Migration) error {
+	tx, err := conn.Begin()
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecEx(context.Background(), migration.SQL, &pgx.QueryExOptions{SimpleProtocol: true})
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	_, err = tx.Exec(insertMigration, migration.Version, migration.Name)
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// adoptLegacySchema marks 0001 as applied on databases that were created
+// from the old db/db.sql, so their data isn't touched.
+func (m *Migrator) adoptLegacySchema(conn *pgx.Conn) error {
+	applied, err := m.applied(conn)
+	if err != nil || len(applied) > 0 {
+		return err
+	}
+	var legacy bool
+	if err := conn.QueryRow(selectLegacySchema).Scan(&legacy); err != nil {
+		return err
+	}
+	if !legacy {
+		return nil
+	}
+	_, err = conn.Exec(insertMigration, m.migrations[0].Version, m.migrations[0].Name)
+	return err
+}
+
+type queryer interface {
+	Query(sql string, args ...interface{}) (*pgx.Rows, error)
+}
+
+func (m *Migrator) applied(db queryer) (map[int]time.Time, error) {
+	applied := map[int]time.Time{}
+	rows, err := db.Query(selectAppliedMigrations)
+	if driverErr, ok := err.(pgx.PgError); ok && driverErr.Code == "42P01" {
+		return applied, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var version int
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return nil, err
+		}
+		applied[version] = appliedAt
+	}
+	return applied, rows.Err()
+}
+
+func (s MigrationStatus) String() string {
+	state := "pending"
+	if s.Applied {
+		state = "applied " + s.AppliedAt.Format(time.RFC3339)
+	}
+	return fmt.Sprintf("%04d_%s\t%s", s.Version, s.Name, state)
+}
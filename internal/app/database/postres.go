@@ -1,6 +1,7 @@
 package database
 
 import (
+	"DBForum/internal/app/config"
 	"github.com/jackc/pgx"
 	_ "github.com/jackc/pgx/stdlib"
 )
@@ -9,19 +10,10 @@ type Postgres struct {
 	db *pgx.ConnPool
 }
 
-func NewPostgres() (*Postgres, error) {
-	conf := pgx.ConnConfig{
-		User:                 "postgres",
-		Database:             "postgres",
-		Password:             "admin",
-		PreferSimpleProtocol: false,
-	}
-
-	poolConf := pgx.ConnPoolConfig{
-		ConnConfig:     conf,
-		MaxConnections: 100,
-		AfterConnect:   nil,
-		AcquireTimeout: 0,
+func NewPostgres(conf config.Database) (*Postgres, error) {
+	poolConf, err := conf.PoolConfig()
+	if err != nil {
+		return nil, err
 	}
 	db, err := pgx.NewConnPool(poolConf)
 	if err != nil {
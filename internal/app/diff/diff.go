@@ -0,0 +1,116 @@
+// Package diff compares revisions of a post word by word.
+package diff
+
+import (
+	"DBForum/internal/app/models"
+	"unicode"
+)
+
+const (
+	Equal  = "equal"
+	Delete = "delete"
+	Insert = "insert"
+)
+
+// maxCells bounds the table of the longest common subsequence. Past it
+// the differing middle of the texts is reported as replaced as a whole.
+const maxCells = 1 << 20
+
+// Words returns the changes that turn a into b. Words and the whitespace
+// between them are separate tokens, and neighbouring tokens with the same
+// op are merged, so joining the equal and delete runs gives a back and
+// joining the equal and insert runs gives b.
+func Words(a string, b string) []models.Change {
+	x, y := tokens(a), tokens(b)
+	prefix := 0
+	for prefix < len(x) && prefix < len(y) && x[prefix] == y[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(x)-prefix && suffix < len(y)-prefix && x[len(x)-1-suffix] == y[len(y)-1-suffix] {
+		suffix++
+	}
+
+	changes := []models.Change{}
+	for _, t := range x[:prefix] {
+		changes = appendChange(changes, Equal, t)
+	}
+	changes = middle(changes, x[prefix:len(x)-suffix], y[prefix:len(y)-suffix])
+	for _, t := range x[len(x)-suffix:] {
+		changes = appendChange(changes, Equal, t)
+	}
+	return changes
+}
+
+// middle appends the changes between x and y, which differ at both ends.
+func middle(changes []models.Change, x []string, y []string) []models.Change {
+	if (len(x)+1)*(len(y)+1) > maxCells {
+		for _, t := range x {
+			changes = appendChange(changes, Delete, t)
+		}
+		for _, t := range y {
+			changes = appendChange(changes, Insert, t)
+		}
+		return changes
+	}
+	// lcs[i][j] is the length of the longest common subsequence of x[i:]
+	// and y[j:].
+	lcs := make([][]int, len(x)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(y)+1)
+	}
+	for i := len(x) - 1; i >= 0; i-- {
+		for j := len(y) - 1; j >= 0; j-- {
+			switch {
+			case x[i] == y[j]:
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			case lcs[i+1][j] >= lcs[i][j+1]:
+				lcs[i][j] = lcs[i+1][j]
+			default:
+				lcs[i][j] = lcs[i][j+1]
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(x) || j < len(y) {
+		switch {
+		case i < len(x) && j < len(y) && x[i] == y[j]:
+			changes = appendChange(changes, Equal, x[i])
+			i++
+			j++
+		case j == len(y) || i < len(x) && lcs[i+1][j] >= lcs[i][j+1]:
+			changes = appendChange(changes, Delete, x[i])
+			i++
+		default:
+			changes = appendChange(changes, Insert, y[j])
+			j++
+		}
+	}
+	return changes
+}
+
+func appendChange(changes []models.Change, op string, text string) []models.Change {
+	if n := len(changes); n > 0 && changes[n-1].Op == op {
+		changes[n-1].Text += text
+		return changes
+	}
+	return append(changes, models.Change{Op: op, Text: text})
+}
+
+// tokens splits s into runs of whitespace and runs of everything else.
+func tokens(s string) []string {
+	var result []string
+	start := 0
+	space := false
+	for i, r := range s {
+		if i > start && unicode.IsSpace(r) != space {
+			result = append(result, s[start:i])
+			start = i
+		}
+		space = unicode.IsSpace(r)
+	}
+	if start < len(s) {
+		result = append(result, s[start:])
+	}
+	return result
+}
@@ -0,0 +1,59 @@
+package diff
+
+import (
+	"DBForum/internal/app/models"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func eq(text string) models.Change  { return models.Change{Op: Equal, Text: text} }
+func del(text string) models.Change { return models.Change{Op: Delete, Text: text} }
+func ins(text string) models.Change { return models.Change{Op: Insert, Text: text} }
+
+func TestWords(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want []models.Change
+	}{
+		{"", "", []models.Change{}},
+		{"same text", "same text", []models.Change{eq("same text")}},
+		{"hello world", "hello there", []models.Change{eq("hello "), del("world"), ins("there")}},
+		{"a b c", "a c", []models.Change{eq("a "), del("b "), eq("c")}},
+		{"", "новый текст", []models.Change{ins("новый текст")}},
+		{"один  два", "один два три", []models.Change{eq("один"), del("  "), ins(" "), eq("два"), ins(" три")}},
+	}
+	for _, tt := range tests {
+		if got := Words(tt.a, tt.b); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Words(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// sides joins the runs of changes that make up each of the compared texts.
+func sides(changes []models.Change) (string, string) {
+	var a, b strings.Builder
+	for _, c := range changes {
+		if c.Op != Insert {
+			a.WriteString(c.Text)
+		}
+		if c.Op != Delete {
+			b.WriteString(c.Text)
+		}
+	}
+	return a.String(), b.String()
+}
+
+func TestWordsRebuildsBothTexts(t *testing.T) {
+	long := strings.Repeat("word ", 2000)
+	pairs := [][2]string{
+		{"the quick brown fox", "the slow brown dog jumps"},
+		{"line one\nline two\n", "line two\nline three\n"},
+		{long + "end", "start " + strings.Replace(long, "word", "term", -1)},
+	}
+	for _, p := range pairs {
+		if a, b := sides(Words(p[0], p[1])); a != p[0] || b != p[1] {
+			t.Errorf("changes rebuild %q and %q, want %q and %q", a, b, p[0], p[1])
+		}
+	}
+}
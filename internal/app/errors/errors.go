@@ -1,6 +1,11 @@
 package errors
 
-import "errors"
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+)
 
 var (
 	ErrDuplicate      = errors.New("duplicate")
@@ -10,4 +15,221 @@ var (
 	ErrForumNotFound  = errors.New("forum not found")
 	ErrThreadNotFound = errors.New("thread not found")
 	ErrPostNotFound   = errors.New("post not found")
+	ErrNoRevision     = errors.New("revision not found")
+	ErrInvalid        = errors.New("invalid request")
+	ErrThreadArchived = errors.New("thread is archived")
+	ErrThreadLocked   = errors.New("thread is locked")
+	ErrThreadClosed   = errors.New("thread is closed")
+	ErrUnauthorized   = errors.New("unauthorized")
+	ErrForbidden      = errors.New("forbidden")
+	ErrStale          = errors.New("stale version")
+	ErrKeyReused      = errors.New("idempotency key reused")
+	ErrKeyInUse       = errors.New("idempotency key in use")
 )
+
+type kind struct {
+	code   string
+	status int
+}
+
+var kinds = map[error]kind{
+	ErrDuplicate:      {"duplicate", http.StatusConflict},
+	ErrNoParent:       {"parent_not_in_thread", http.StatusConflict},
+	ErrConflict:       {"conflict", http.StatusConflict},
+	ErrUserNotFound:   {"user_not_found", http.StatusNotFound},
+	ErrForumNotFound:  {"forum_not_found", http.StatusNotFound},
+	ErrThreadNotFound: {"thread_not_found", http.StatusNotFound},
+	ErrPostNotFound:   {"post_not_found", http.StatusNotFound},
+	ErrNoRevision:     {"revision_not_found", http.StatusNotFound},
+	ErrInvalid:        {"invalid_request", http.StatusBadRequest},
+	ErrThreadArchived: {"thread_archived", http.StatusConflict},
+	ErrThreadLocked:   {"thread_locked", http.StatusForbidden},
+	ErrThreadClosed:   {"thread_closed", http.StatusForbidden},
+	ErrUnauthorized:   {"unauthorized", http.StatusUnauthorized},
+	ErrForbidden:      {"forbidden", http.StatusForbidden},
+	ErrStale:          {"precondition_failed", http.StatusPreconditionFailed},
+	ErrKeyReused:      {"idempotency_key_reused", http.StatusUnprocessableEntity},
+	ErrKeyInUse:       {"idempotency_key_in_use", http.StatusConflict},
+}
+
+const CodeInternal = "internal"
+
+// Error is a domain error with a stable code, the HTTP status it maps to
+// and the values it is about, e.g. the nickname of a missing user.
+// errors.Is matches it against the sentinel it was built from.
+type Error struct {
+	Code    string
+	Status  int
+	Message string
+	Fields  map[string]string
+	kind    error
+}
+
+func newError(sentinel error, message string, fields map[string]string) *Error {
+	k := kinds[sentinel]
+	return &Error{
+		Code:    k.code,
+		Status:  k.status,
+		Message: message,
+		Fields:  fields,
+		kind:    sentinel,
+	}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func (e *Error) Is(target error) bool {
+	return target == e.kind
+}
+
+func (e *Error) Unwrap() error {
+	return e.kind
+}
+
+// MarshalJSON renders the code and message next to the context fields:
+// {"code": "user_not_found", "message": "...", "nickname": "bob"}.
+func (e *Error) MarshalJSON() ([]byte, error) {
+	body := make(map[string]string, len(e.Fields)+2)
+	for k, v := range e.Fields {
+		body[k] = v
+	}
+	body["code"] = e.Code
+	body["message"] = e.Message
+	return json.Marshal(body)
+}
+
+// From returns err as an *Error, building one from a bare sentinel. It
+// returns nil for errors outside the domain model.
+func From(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+	for sentinel := range kinds {
+		if errors.Is(err, sentinel) {
+			return newError(sentinel, err.Error(), nil)
+		}
+	}
+	return nil
+}
+
+// Code is the code of err, or CodeInternal if err is not a domain error.
+func Code(err error) string {
+	if e := From(err); e != nil {
+		return e.Code
+	}
+	return CodeInternal
+}
+
+func Internal() *Error {
+	return &Error{
+		Code:    CodeInternal,
+		Status:  http.StatusInternalServerError,
+		Message: "Internal server error",
+	}
+}
+
+func UserNotFound(nickname string) *Error {
+	return newError(ErrUserNotFound, "Can't find user by nickname: "+nickname,
+		map[string]string{"nickname": nickname})
+}
+
+func ForumNotFound(slug string) *Error {
+	return newError(ErrForumNotFound, "Can't find forum with slug: "+slug,
+		map[string]string{"slug": slug})
+}
+
+func ThreadNotFound(slugOrID string) *Error {
+	return newError(ErrThreadNotFound, "Can't find thread by slug or id: "+slugOrID,
+		map[string]string{"slug_or_id": slugOrID})
+}
+
+func ThreadArchived(slugOrID string) *Error {
+	return newError(ErrThreadArchived, "Thread is archived and read-only: "+slugOrID,
+		map[string]string{"slug_or_id": slugOrID})
+}
+
+func ThreadLocked(slugOrID string) *Error {
+	return newError(ErrThreadLocked, "Thread is locked for new posts: "+slugOrID,
+		map[string]string{"slug_or_id": slugOrID})
+}
+
+func ThreadClosed(slugOrID string) *Error {
+	return newError(ErrThreadClosed, "Thread is closed for voting: "+slugOrID,
+		map[string]string{"slug_or_id": slugOrID})
+}
+
+func PostNotFound(id uint64) *Error {
+	return newError(ErrPostNotFound, "Can't find post with id: "+strconv.FormatUint(id, 10),
+		map[string]string{"id": strconv.FormatUint(id, 10)})
+}
+
+func RevisionNotFound(id uint64, revision int) *Error {
+	return newError(ErrNoRevision, "Can't find revision "+strconv.Itoa(revision)+" of post with id: "+strconv.FormatUint(id, 10),
+		map[string]string{"id": strconv.FormatUint(id, 10), "revision": strconv.Itoa(revision)})
+}
+
+func NoParent(parent uint64) *Error {
+	return newError(ErrNoParent, "Parent post was created in another thread",
+		map[string]string{"parent": strconv.FormatUint(parent, 10)})
+}
+
+func EmailConflict(email string, nickname string) *Error {
+	return newError(ErrConflict, "This email is already registered by user: "+nickname,
+		map[string]string{"email": email, "nickname": nickname})
+}
+
+func Duplicate(message string) *Error {
+	return newError(ErrDuplicate, message, nil)
+}
+
+func Invalid(field string, message string) *Error {
+	var fields map[string]string
+	if field != "" {
+		fields = map[string]string{"field": field}
+	}
+	return newError(ErrInvalid, message, fields)
+}
+
+func Unauthorized(message string) *Error {
+	return newError(ErrUnauthorized, message, nil)
+}
+
+func Forbidden(nickname string) *Error {
+	return newError(ErrForbidden, "Not allowed to act on behalf of user: "+nickname,
+		map[string]string{"nickname": nickname})
+}
+
+func NotAllowed(action string) *Error {
+	return newError(ErrForbidden, "Not allowed to "+action,
+		map[string]string{"action": action})
+}
+
+func Banned(nickname string, forum string) *Error {
+	return newError(ErrForbidden, "User is banned from forum: "+forum,
+		map[string]string{"nickname": nickname, "forum": forum})
+}
+
+func Stale(version uint64) *Error {
+	return newError(ErrStale, "Resource has changed since version: "+strconv.FormatUint(version, 10),
+		map[string]string{"version": strconv.FormatUint(version, 10)})
+}
+
+// NoMatch refuses an If-Match tag that can never match, such as a weak or
+// malformed one.
+func NoMatch(tag string) *Error {
+	return newError(ErrStale, "Resource does not match: "+tag,
+		map[string]string{"if_match": tag})
+}
+
+func KeyReused(key string) *Error {
+	return newError(ErrKeyReused, "Idempotency key was already used for a different request: "+key,
+		map[string]string{"key": key})
+}
+
+func KeyInUse(key string) *Error {
+	return newError(ErrKeyInUse, "A request with this idempotency key is still in progress: "+key,
+		map[string]string{"key": key})
+}
@@ -0,0 +1,57 @@
+package errors
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+)
+
+func TestErrorMatchesSentinel(t *testing.T) {
+	err := fmt.Errorf("creating posts: %w", UserNotFound("bob"))
+	if !errors.Is(err, ErrUserNotFound) {
+		t.Error("UserNotFound does not match ErrUserNotFound")
+	}
+	if errors.Is(err, ErrForumNotFound) {
+		t.Error("UserNotFound matches ErrForumNotFound")
+	}
+
+	e := From(err)
+	if e == nil || e.Status != http.StatusNotFound || e.Code != "user_not_found" || e.Fields["nickname"] != "bob" {
+		t.Errorf("From = %+v", e)
+	}
+}
+
+func TestFromSentinel(t *testing.T) {
+	e := From(fmt.Errorf("voting: %w", ErrNoParent))
+	if e == nil || e.Status != http.StatusConflict || e.Code != "parent_not_in_thread" {
+		t.Errorf("From = %+v", e)
+	}
+	if From(errors.New("connection reset")) != nil {
+		t.Error("From built a domain error from an unknown error")
+	}
+	if got := Code(errors.New("connection reset")); got != CodeInternal {
+		t.Errorf("Code = %q, want %q", got, CodeInternal)
+	}
+}
+
+func TestMarshalJSON(t *testing.T) {
+	data, err := json.Marshal(ThreadNotFound("42"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got map[string]string
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"code":       "thread_not_found",
+		"message":    "Can't find thread by slug or id: 42",
+		"slug_or_id": "42",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("body = %v, want %v", got, want)
+	}
+}
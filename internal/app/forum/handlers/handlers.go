@@ -1,14 +1,16 @@
 package handlers
 
 import (
+	"DBForum/internal/app/auth"
 	customErr "DBForum/internal/app/errors"
 	forumUseCase "DBForum/internal/app/forum/usecase"
 	"DBForum/internal/app/httputils"
+	"DBForum/internal/app/metrics"
 	"DBForum/internal/app/models"
+	"DBForum/internal/app/validation"
 	"errors"
 	"github.com/mailru/easyjson"
 	"github.com/valyala/fasthttp"
-	"log"
 	"net/http"
 )
 
@@ -25,29 +27,27 @@ func NewHandler(useCase forumUseCase.UseCase) *Handlers {
 func (h *Handlers) Create(ctx *fasthttp.RequestCtx) {
 	forum := &models.Forum{}
 
+	if err := validation.ForumCreate.Object(ctx.PostBody()); err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
 	if err := easyjson.Unmarshal(ctx.PostBody(), forum); err != nil {
-		log.Println(err)
-		httputils.Respond(ctx, http.StatusInternalServerError, nil)
+		httputils.RespondError(ctx, err)
 		return
 	}
-
-	var err error
-	nickname := forum.User
-	forum, err = h.useCase.CreateForum(forum)
-	if errors.Is(err, customErr.ErrUserNotFound) {
-		resp := map[string]string{
-			"message": "Can't find user with nickname: " + nickname,
-		}
-		httputils.RespondErr(ctx, http.StatusNotFound, resp)
+	if err := auth.Authorize(ctx, forum.User); err != nil {
+		httputils.RespondError(ctx, err)
 		return
 	}
+
+	forum, err := h.useCase.CreateForum(forum)
 	if errors.Is(err, customErr.ErrDuplicate) {
+		metrics.ObserveError(ctx, err)
 		httputils.Respond(ctx, http.StatusConflict, forum)
 		return
 	}
 	if err != nil {
-		log.Println(err)
-		httputils.Respond(ctx, http.StatusInternalServerError, nil)
+		httputils.RespondError(ctx, err)
 		return
 	}
 	httputils.Respond(ctx, http.StatusCreated, forum)
@@ -56,56 +56,55 @@ func (h *Handlers) Create(ctx *fasthttp.RequestCtx) {
 func (h *Handlers) Details(ctx *fasthttp.RequestCtx) {
 	slug := ctx.UserValue("slug").(string)
 	forum, err := h.useCase.GetInfoBySlug(slug)
-	if errors.Is(err, customErr.ErrForumNotFound) {
-		resp := map[string]string{
-			"message": "Can't find forum with slug: " + slug,
-		}
-		httputils.RespondErr(ctx, http.StatusNotFound, resp)
+	if err != nil {
+		httputils.RespondError(ctx, err)
 		return
 	}
+	httputils.Respond(ctx, http.StatusOK, forum)
+}
+
+func (h *Handlers) List(ctx *fasthttp.RequestCtx) {
+	owner := string(ctx.QueryArgs().Peek("user"))
+	sortBy := string(ctx.QueryArgs().Peek("sort"))
+	limit := ctx.QueryArgs().GetUintOrZero("limit")
+	since := string(ctx.QueryArgs().Peek("since"))
+	cursor := string(ctx.QueryArgs().Peek("cursor"))
+	desc := ctx.QueryArgs().GetBool("desc")
+
+	forums, cursors, err := h.useCase.GetForums(owner, sortBy, limit, since, cursor, desc)
 	if err != nil {
-		httputils.Respond(ctx, http.StatusInternalServerError, nil)
-		log.Println(err)
+		httputils.RespondError(ctx, err)
 		return
 	}
-	httputils.Respond(ctx, http.StatusOK, forum)
+	httputils.SetLinks(ctx, cursors)
+	httputils.Respond(ctx, http.StatusOK, models.ForumList(forums))
 }
 
 func (h *Handlers) CreateThread(ctx *fasthttp.RequestCtx) {
 	thread := &models.Thread{}
-	if err := easyjson.Unmarshal(ctx.PostBody(), thread); err != nil {
-		httputils.Respond(ctx, http.StatusInternalServerError, nil)
-		log.Println(err)
+	if err := validation.ThreadCreate.Object(ctx.PostBody()); err != nil {
+		httputils.RespondError(ctx, err)
 		return
 	}
-
-	forumSlug := ctx.UserValue("slug").(string)
-	nickname := thread.Author
-	thread.Forum = forumSlug
-
-	var err error
-	thread, err = h.useCase.CreateThread(thread)
-	if errors.Is(err, customErr.ErrUserNotFound) {
-		resp := map[string]string{
-			"message": "Can't find thread author by nickname: " + nickname,
-		}
-		httputils.RespondErr(ctx, http.StatusNotFound, resp)
+	if err := easyjson.Unmarshal(ctx.PostBody(), thread); err != nil {
+		httputils.RespondError(ctx, err)
 		return
 	}
-	if errors.Is(err, customErr.ErrForumNotFound) {
-		resp := map[string]string{
-			"message": "Can't find thread forum by slug: " + forumSlug,
-		}
-		httputils.RespondErr(ctx, http.StatusNotFound, resp)
+	if err := auth.Authorize(ctx, thread.Author); err != nil {
+		httputils.RespondError(ctx, err)
 		return
 	}
+
+	thread.Forum = ctx.UserValue("slug").(string)
+
+	thread, err := h.useCase.CreateThread(thread)
 	if errors.Is(err, customErr.ErrDuplicate) {
+		metrics.ObserveError(ctx, err)
 		httputils.Respond(ctx, http.StatusConflict, thread)
 		return
 	}
 	if err != nil {
-		httputils.Respond(ctx, http.StatusInternalServerError, nil)
-		log.Println(err)
+		httputils.RespondError(ctx, err)
 		return
 	}
 	httputils.Respond(ctx, http.StatusCreated, thread)
@@ -116,23 +115,16 @@ func (h *Handlers) GetUsers(ctx *fasthttp.RequestCtx) {
 	limit := ctx.QueryArgs().GetUintOrZero("limit")
 
 	since := string(ctx.QueryArgs().Peek("since"))
+	cursor := string(ctx.QueryArgs().Peek("cursor"))
 	desc := ctx.QueryArgs().GetBool("desc")
 
 	var users models.UserList
-	var err error
-	users, err = h.useCase.GetForumUsers(forumSlug, limit, since, desc)
-	if errors.Is(err, customErr.ErrForumNotFound) {
-		resp := map[string]string{
-			"message": "Can't find forum by slug: " + forumSlug,
-		}
-		httputils.RespondErr(ctx, http.StatusNotFound, resp)
-		return
-	}
+	users, cursors, err := h.useCase.GetForumUsers(forumSlug, limit, since, cursor, desc)
 	if err != nil {
-		httputils.Respond(ctx, http.StatusInternalServerError, nil)
-		log.Println(err)
+		httputils.RespondError(ctx, err)
 		return
 	}
+	httputils.SetLinks(ctx, cursors)
 
 	httputils.Respond(ctx, http.StatusOK, users)
 }
@@ -141,23 +133,67 @@ func (h *Handlers) GetThreads(ctx *fasthttp.RequestCtx) {
 	forumSlug := ctx.UserValue("slug").(string)
 	var threads models.ThreadList
 
-	limit, _ := ctx.QueryArgs().GetUint("limit")
+	limit := ctx.QueryArgs().GetUintOrZero("limit")
 	since := string(ctx.QueryArgs().Peek("since"))
+	cursor := string(ctx.QueryArgs().Peek("cursor"))
 	desc := ctx.QueryArgs().GetBool("desc")
 
-	var err error
-	threads, err = h.useCase.GetForumThreads(forumSlug, limit, since, desc)
-	if errors.Is(err, customErr.ErrForumNotFound) {
-		resp := map[string]string{
-			"message": "Can't find forum by slug: " + forumSlug,
-		}
-		httputils.RespondErr(ctx, http.StatusNotFound, resp)
+	threads, cursors, err := h.useCase.GetForumThreads(forumSlug, limit, since, cursor, desc)
+	if err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
+	httputils.SetLinks(ctx, cursors)
+	httputils.Respond(ctx, http.StatusOK, threads)
+}
+
+func (h *Handlers) GetRoles(ctx *fasthttp.RequestCtx) {
+	forumSlug := ctx.UserValue("slug").(string)
+	roles, err := h.useCase.GetRoles(forumSlug)
+	if err != nil {
+		httputils.RespondError(ctx, err)
 		return
 	}
+	httputils.Respond(ctx, http.StatusOK, models.ForumRoleList(roles))
+}
+
+func (h *Handlers) GrantRole(ctx *fasthttp.RequestCtx) {
+	var role models.ForumRole
+	actor, err := auth.Caller(ctx)
 	if err != nil {
-		httputils.Respond(ctx, http.StatusInternalServerError, nil)
-		log.Println(err)
+		httputils.RespondError(ctx, err)
 		return
 	}
-	httputils.Respond(ctx, http.StatusOK, threads)
+	if err := validation.ForumRole.Object(ctx.PostBody()); err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
+	if err := easyjson.Unmarshal(ctx.PostBody(), &role); err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
+	role.Forum = ctx.UserValue("slug").(string)
+	role.Nickname = ctx.UserValue("nickname").(string)
+
+	role, err = h.useCase.GrantRole(actor, role)
+	if err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
+	httputils.Respond(ctx, http.StatusOK, role)
+}
+
+func (h *Handlers) RevokeRole(ctx *fasthttp.RequestCtx) {
+	actor, err := auth.Caller(ctx)
+	if err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
+	forumSlug := ctx.UserValue("slug").(string)
+	nickname := ctx.UserValue("nickname").(string)
+	if err := h.useCase.RevokeRole(actor, forumSlug, nickname); err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
+	httputils.Respond(ctx, http.StatusNoContent, nil)
 }
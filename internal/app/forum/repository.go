@@ -0,0 +1,20 @@
+package forum
+
+import "DBForum/internal/app/models"
+
+type Repository interface {
+	CreateForum(forum *models.Forum) error
+	FindBySlug(slug string) (*models.Forum, error)
+	// GetForums lists forums in sortBy order, ties broken by id. since is
+	// the slug of the last forum of the previous page and fails with
+	// ErrForumNotFound for unknown forums; from is a Forum.Position.
+	GetForums(owner string, sortBy string, limit int, since string, from *models.Position, desc bool) ([]models.Forum, error)
+	GetRoles(forumSlug string) ([]models.ForumRole, error)
+	// GetRole returns "" for users without a role in the forum.
+	GetRole(forumSlug string, nickname string) (string, error)
+	SetRole(role models.ForumRole) (models.ForumRole, error)
+	DeleteRole(forumSlug string, nickname string) error
+	// FindBanned returns the first of nicknames that is banned in the
+	// forum, or "".
+	FindBanned(forumSlug string, nicknames []string) (string, error)
+}
@@ -2,7 +2,10 @@ package repository
 
 import (
 	customErr "DBForum/internal/app/errors"
+	"DBForum/internal/app/forum"
 	"DBForum/internal/app/models"
+	"DBForum/internal/app/pagination"
+	"fmt"
 	"github.com/jackc/pgx"
 )
 
@@ -20,8 +23,51 @@ const (
 	selectForumBySlug = "SELECT user_nickname, title, slug, posts, threads FROM dbforum.forum WHERE slug = $1"
 
 	selectNicknameByNickname = "SELECT nickname FROM dbforum.users WHERE nickname = $1"
+
+	insertForumOwner = "INSERT INTO dbforum.forum_roles(forum_slug, nickname, role) VALUES ($1, $2, 'owner')"
+
+	selectForumRoles = "SELECT forum_slug, nickname, role FROM dbforum.forum_roles WHERE forum_slug = $1 ORDER BY nickname"
+
+	selectForumRole = "SELECT role FROM dbforum.forum_roles WHERE forum_slug = $1 AND nickname = $2"
+
+	upsertForumRole = `INSERT INTO dbforum.forum_roles(forum_slug, nickname, role)
+						SELECT f.slug, u.nickname, $3 FROM dbforum.forum f, dbforum.users u
+						WHERE f.slug = $1 AND u.nickname = $2
+						ON CONFLICT (forum_slug, nickname) DO UPDATE SET role = EXCLUDED.role
+						RETURNING forum_slug, nickname, role`
+
+	deleteForumRole = "DELETE FROM dbforum.forum_roles WHERE forum_slug = $1 AND nickname = $2"
+
+	selectForumPosition = "SELECT id, title, posts, threads FROM dbforum.forum WHERE slug = $1"
+
+	// selectForums is completed with the sort column, the type of its
+	// values, the comparison and the direction.
+	selectForums = `SELECT f.id, f.user_nickname, f.title, f.slug, f.posts, f.threads FROM dbforum.forum f
+					WHERE ($1 = '' OR f.user_nickname = $1::citext)
+					AND (NOT $2::boolean OR (f.%[1]s, f.id) %[3]s ($3::%[2]s, $4::bigint))
+					ORDER BY f.%[1]s %[4]s, f.id %[4]s
+					LIMIT $5`
+
+	selectBanned = `SELECT nickname FROM dbforum.forum_roles
+					WHERE forum_slug = $1 AND role = 'banned' AND nickname = ANY($2::text[]::citext[])
+					LIMIT 1`
 )
 
+type forumSort struct {
+	column  string
+	keyType string
+}
+
+// forumSorts are the only values spliced into selectForums for a sort.
+var forumSorts = map[string]forumSort{
+	models.ForumSortCreated: {"id", "bigint"},
+	models.ForumSortPosts:   {"posts", "bigint"},
+	models.ForumSortThreads: {"threads", "bigint"},
+	models.ForumSortTitle:   {"title", "text"},
+}
+
+var _ forum.Repository = (*Repository)(nil)
+
 type Repository struct {
 	db *pgx.ConnPool
 }
@@ -67,7 +113,7 @@ func (r *Repository) CreateForum(forum *models.Forum) error {
 	}
 	if !rows.Next() {
 		_ = tx.Rollback()
-		return customErr.ErrUserNotFound
+		return customErr.UserNotFound(forum.User)
 	}
 	err = rows.Scan(&nickname)
 	rows.Close()
@@ -92,6 +138,10 @@ func (r *Repository) CreateForum(forum *models.Forum) error {
 		_ = tx.Rollback()
 		return err
 	}
+	if _, err = tx.Exec("insertForumOwner", forum.Slug, forum.User); err != nil {
+		_ = tx.Rollback()
+		return err
+	}
 	if err := tx.Commit(); err != nil {
 		_ = tx.Rollback()
 		return err
@@ -106,7 +156,7 @@ func (r *Repository) FindBySlug(slug string) (*models.Forum, error) {
 		return nil, err
 	}
 	if !rows.Next() {
-		return nil, customErr.ErrForumNotFound
+		return nil, customErr.ForumNotFound(slug)
 	}
 	err = rows.Scan(
 		&forum.User,
@@ -121,6 +171,124 @@ func (r *Repository) FindBySlug(slug string) (*models.Forum, error) {
 	return &forum, nil
 }
 
+func (r *Repository) GetForums(owner string, sortBy string, limit int, since string, from *models.Position, desc bool) ([]models.Forum, error) {
+	order, ok := forumSorts[sortBy]
+	if !ok {
+		return nil, customErr.Invalid("sort", "must be one of created, posts, threads, title")
+	}
+	if from == nil && since != "" {
+		var f models.Forum
+		err := r.db.QueryRow("selectForumPosition", since).Scan(&f.ID, &f.Title, &f.Posts, &f.Threads)
+		if err == pgx.ErrNoRows {
+			return nil, customErr.ForumNotFound(since)
+		}
+		if err != nil {
+			return nil, err
+		}
+		position := f.Position(sortBy)
+		from = &position
+	}
+	var position models.Position
+	if from != nil {
+		position = *from
+	}
+	op, dir := pagination.Walk(desc, position.Before)
+	query := fmt.Sprintf(selectForums, order.column, order.keyType, op, dir)
+	rows, err := r.db.Query(query, owner, from != nil, position.Key, position.ID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var forums []models.Forum
+	for rows.Next() {
+		var f models.Forum
+		err := rows.Scan(
+			&f.ID,
+			&f.User,
+			&f.Title,
+			&f.Slug,
+			&f.Posts,
+			&f.Threads)
+		if err != nil {
+			return nil, err
+		}
+		forums = append(forums, f)
+	}
+	if position.Before {
+		for i, j := 0, len(forums)-1; i < j; i, j = i+1, j-1 {
+			forums[i], forums[j] = forums[j], forums[i]
+		}
+	}
+	return forums, rows.Err()
+}
+
+func (r *Repository) GetRoles(forumSlug string) ([]models.ForumRole, error) {
+	if _, err := r.FindBySlug(forumSlug); err != nil {
+		return nil, err
+	}
+	rows, err := r.db.Query("selectForumRoles", forumSlug)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var roles []models.ForumRole
+	for rows.Next() {
+		var role models.ForumRole
+		if err := rows.Scan(&role.Forum, &role.Nickname, &role.Role); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	return roles, rows.Err()
+}
+
+func (r *Repository) GetRole(forumSlug string, nickname string) (string, error) {
+	var role string
+	err := r.db.QueryRow("selectForumRole", forumSlug, nickname).Scan(&role)
+	if err == pgx.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return role, nil
+}
+
+func (r *Repository) SetRole(role models.ForumRole) (models.ForumRole, error) {
+	var stored models.ForumRole
+	err := r.db.QueryRow("upsertForumRole", role.Forum, role.Nickname, role.Role).Scan(
+		&stored.Forum,
+		&stored.Nickname,
+		&stored.Role)
+	if err == pgx.ErrNoRows {
+		if _, err := r.FindBySlug(role.Forum); err != nil {
+			return models.ForumRole{}, err
+		}
+		return models.ForumRole{}, customErr.UserNotFound(role.Nickname)
+	}
+	if err != nil {
+		return models.ForumRole{}, err
+	}
+	return stored, nil
+}
+
+func (r *Repository) DeleteRole(forumSlug string, nickname string) error {
+	_, err := r.db.Exec("deleteForumRole", forumSlug, nickname)
+	return err
+}
+
+func (r *Repository) FindBanned(forumSlug string, nicknames []string) (string, error) {
+	var banned string
+	err := r.db.QueryRow("selectBanned", forumSlug, nicknames).Scan(&banned)
+	if err == pgx.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return banned, nil
+}
+
 func (r *Repository) Prepare() error {
 	_, err := r.db.Prepare("insertForum", insertForum)
 	if err != nil {
@@ -134,5 +302,33 @@ func (r *Repository) Prepare() error {
 	if err != nil {
 		return err
 	}
+	_, err = r.db.Prepare("insertForumOwner", insertForumOwner)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Prepare("selectForumRoles", selectForumRoles)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Prepare("selectForumRole", selectForumRole)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Prepare("upsertForumRole", upsertForumRole)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Prepare("deleteForumRole", deleteForumRole)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Prepare("selectForumPosition", selectForumPosition)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Prepare("selectBanned", selectBanned)
+	if err != nil {
+		return err
+	}
 	return nil
 }
@@ -1,19 +1,23 @@
 package usecase
 
 import (
-	forumRepo "DBForum/internal/app/forum/repository"
+	"DBForum/internal/app/auth"
+	customErr "DBForum/internal/app/errors"
+	"DBForum/internal/app/forum"
 	"DBForum/internal/app/models"
-	threadRepo "DBForum/internal/app/thread/repository"
-	userRepo "DBForum/internal/app/user/repository"
+	"DBForum/internal/app/pagination"
+	"DBForum/internal/app/thread"
+	"DBForum/internal/app/user"
+	"strconv"
 )
 
 type UseCase struct {
-	forumRepo  forumRepo.Repository
-	userRepo   userRepo.Repository
-	threadRepo threadRepo.Repository
+	forumRepo  forum.Repository
+	userRepo   user.Repository
+	threadRepo thread.Repository
 }
 
-func NewUseCase(forumRepo forumRepo.Repository, userRepo userRepo.Repository, threadRepo threadRepo.Repository) *UseCase {
+func NewUseCase(forumRepo forum.Repository, userRepo user.Repository, threadRepo thread.Repository) *UseCase {
 	return &UseCase{
 		forumRepo:  forumRepo,
 		userRepo:   userRepo,
@@ -37,35 +41,157 @@ func (u *UseCase) GetInfoBySlug(slug string) (*models.Forum, error) {
 	return forum, nil
 }
 
+func (u *UseCase) GetForums(owner string, sortBy string, limit int, since string, cursor string, desc bool) ([]models.Forum, models.Cursors, error) {
+	switch sortBy {
+	case "":
+		sortBy = models.ForumSortCreated
+	case models.ForumSortCreated, models.ForumSortPosts, models.ForumSortThreads, models.ForumSortTitle:
+	default:
+		return nil, models.Cursors{}, customErr.Invalid("sort", "must be one of created, posts, threads, title")
+	}
+	if limit == 0 {
+		limit = 100
+	}
+	from, desc, err := pagination.Parse(cursor, desc)
+	if err != nil {
+		return nil, models.Cursors{}, err
+	}
+	if from != nil && from.Sort != sortBy {
+		return nil, models.Cursors{}, customErr.Invalid("cursor", "was returned for another sort")
+	}
+	if from != nil && sortBy != models.ForumSortTitle {
+		if _, err := strconv.ParseUint(from.Key, 10, 64); err != nil {
+			return nil, models.Cursors{}, customErr.Invalid("cursor", "is not a cursor returned by a previous page")
+		}
+	}
+	forums, err := u.forumRepo.GetForums(owner, sortBy, limit+1, since, from, desc)
+	if err != nil {
+		return nil, models.Cursors{}, err
+	}
+	if len(forums) == 0 {
+		return []models.Forum{}, models.Cursors{}, nil
+	}
+	first, last, more := pagination.Trim(len(forums), limit, from != nil && from.Before)
+	forums = forums[first:last]
+	cursors := pagination.Links(from, more, since != "", desc, forums[0].Position(sortBy), forums[len(forums)-1].Position(sortBy))
+	return forums, cursors, nil
+}
+
 func (u *UseCase) CreateThread(thread *models.Thread) (*models.Thread, error) {
-	thread, err := u.threadRepo.CreateThread(thread)
+	banned, err := u.forumRepo.FindBanned(thread.Forum, []string{thread.Author})
+	if err != nil {
+		return thread, err
+	}
+	if banned != "" {
+		return thread, customErr.Banned(banned, thread.Forum)
+	}
+	thread, err = u.threadRepo.CreateThread(thread)
 	if err != nil {
 		return thread, err
 	}
 	return thread, nil
 }
 
-func (u *UseCase) GetForumUsers(forumSlug string, limit int, since string, desc bool) ([]models.User, error) {
+func (u *UseCase) GetForumUsers(forumSlug string, limit int, since string, cursor string, desc bool) ([]models.User, models.Cursors, error) {
 	if limit == 0 {
 		limit = 100
 	}
-	users, err := u.userRepo.GetForumUsers(forumSlug, limit, since, desc)
+	from, desc, err := pagination.Parse(cursor, desc)
 	if err != nil {
-		return nil, err
+		return nil, models.Cursors{}, err
+	}
+	users, err := u.userRepo.GetForumUsers(forumSlug, limit+1, since, from, desc)
+	if err != nil {
+		return nil, models.Cursors{}, err
+	}
+	if len(users) == 0 {
+		return []models.User{}, models.Cursors{}, nil
+	}
+	first, last, more := pagination.Trim(len(users), limit, from != nil && from.Before)
+	users = users[first:last]
+	cursors := pagination.Links(from, more, since != "", desc, users[0].Position(), users[len(users)-1].Position())
+	return users, cursors, nil
+}
+
+func (u *UseCase) GetForumThreads(forumSlug string, limit int, since string, cursor string, desc bool) ([]models.Thread, models.Cursors, error) {
+	if limit == 0 {
+		limit = 100
+	}
+	from, desc, err := pagination.Parse(cursor, desc)
+	if err != nil {
+		return nil, models.Cursors{}, err
 	}
-	if users == nil {
-		return []models.User{}, nil
+	threads, err := u.threadRepo.GetForumThreads(forumSlug, limit+1, since, from, desc)
+	if err != nil {
+		return nil, models.Cursors{}, err
 	}
-	return users, nil
+	if len(threads) == 0 {
+		return []models.Thread{}, models.Cursors{}, nil
+	}
+	first, last, more := pagination.Trim(len(threads), limit, from != nil && from.Before)
+	threads = threads[first:last]
+	cursors := pagination.Links(from, more, since != "", desc, threads[0].Position(), threads[len(threads)-1].Position())
+	return threads, cursors, nil
 }
 
-func (u *UseCase) GetForumThreads(forumSlug string, limit int, since string, desc bool) ([]models.Thread, error) {
-	threads, err := u.threadRepo.GetForumThreads(forumSlug, limit, since, desc)
+func (u *UseCase) GetRoles(forumSlug string) ([]models.ForumRole, error) {
+	roles, err := u.forumRepo.GetRoles(forumSlug)
 	if err != nil {
 		return nil, err
 	}
-	if threads == nil {
-		return []models.Thread{}, nil
+	if roles == nil {
+		return []models.ForumRole{}, nil
+	}
+	return roles, nil
+}
+
+// checkRoleChange checks that actor may move nickname's role in the forum
+// away from its current value, to role if it is not "". Admins and the owner
+// manage every role but the owner's own; moderators only hand out member and
+// banned, and only to users who are not moderators themselves.
+func (u *UseCase) checkRoleChange(actor models.Actor, forumSlug string, nickname string, role string) error {
+	f, err := u.forumRepo.FindBySlug(forumSlug)
+	if err != nil {
+		return err
+	}
+	action := "change role of " + nickname + " in forum " + f.Slug
+	actorRole, err := auth.RoleIn(u.forumRepo, actor, f.Slug)
+	if err != nil {
+		return err
+	}
+	if err := auth.CanModerate(actor, action, actorRole); err != nil {
+		return err
+	}
+	current, err := u.forumRepo.GetRole(f.Slug, nickname)
+	if err != nil {
+		return err
+	}
+	if current == models.RoleOwner {
+		return customErr.NotAllowed(action)
+	}
+	if actor.Admin || actorRole == models.RoleOwner {
+		return nil
+	}
+	if current == models.RoleModerator || role == models.RoleModerator {
+		return customErr.NotAllowed(action)
+	}
+	return nil
+}
+
+func (u *UseCase) GrantRole(actor models.Actor, role models.ForumRole) (models.ForumRole, error) {
+	if err := u.checkRoleChange(actor, role.Forum, role.Nickname, role.Role); err != nil {
+		return models.ForumRole{}, err
+	}
+	role, err := u.forumRepo.SetRole(role)
+	if err != nil {
+		return models.ForumRole{}, err
+	}
+	return role, nil
+}
+
+func (u *UseCase) RevokeRole(actor models.Actor, forumSlug string, nickname string) error {
+	if err := u.checkRoleChange(actor, forumSlug, nickname, ""); err != nil {
+		return err
 	}
-	return threads, nil
+	return u.forumRepo.DeleteRole(forumSlug, nickname)
 }
@@ -0,0 +1,350 @@
+package usecase
+
+import (
+	customErr "DBForum/internal/app/errors"
+	"DBForum/internal/app/memory"
+	"DBForum/internal/app/models"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newUseCase(t *testing.T) *UseCase {
+	t.Helper()
+	store := memory.NewStore()
+	users := memory.NewUserRepo(store)
+	for _, nick := range []string{"Zed", "alice", "Bob"} {
+		if err := users.CreateUser(models.User{Nickname: nick, Fullname: nick, Email: nick + "@example.com"}, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return NewUseCase(memory.NewForumRepo(store), users, memory.NewThreadRepo(store))
+}
+
+func TestCreateForum(t *testing.T) {
+	u := newUseCase(t)
+
+	forum, err := u.CreateForum(&models.Forum{Slug: "golang", Title: "Go", User: "ALICE"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if forum.User != "alice" {
+		t.Errorf("owner = %q, want the stored nickname alice", forum.User)
+	}
+
+	forum, err = u.CreateForum(&models.Forum{Slug: "GOLANG", Title: "Other", User: "bob"})
+	if !errors.Is(err, customErr.ErrDuplicate) {
+		t.Fatalf("err = %v, want ErrDuplicate", err)
+	}
+	if forum.Title != "Go" {
+		t.Errorf("conflict returned %q, want the existing forum", forum.Title)
+	}
+
+	_, err = u.CreateForum(&models.Forum{Slug: "rust", Title: "Rust", User: "mallory"})
+	if !errors.Is(err, customErr.ErrUserNotFound) {
+		t.Errorf("err = %v, want ErrUserNotFound", err)
+	}
+}
+
+func TestCreateThread(t *testing.T) {
+	u := newUseCase(t)
+	if _, err := u.CreateForum(&models.Forum{Slug: "golang", Title: "Go", User: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+
+	thread := &models.Thread{Forum: "GoLang", Author: "bob", Title: "t", Message: "m", Slug: "first"}
+	thread, err := u.CreateThread(thread)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if thread.Forum != "golang" || thread.Author != "Bob" {
+		t.Errorf("thread forum/author = %q/%q, want golang/Bob", thread.Forum, thread.Author)
+	}
+
+	_, err = u.CreateThread(&models.Thread{Forum: "golang", Author: "alice", Title: "t", Slug: "FIRST"})
+	if !errors.Is(err, customErr.ErrDuplicate) {
+		t.Errorf("err = %v, want ErrDuplicate", err)
+	}
+	_, err = u.CreateThread(&models.Thread{Forum: "rust", Author: "alice", Title: "t"})
+	if !errors.Is(err, customErr.ErrForumNotFound) {
+		t.Errorf("err = %v, want ErrForumNotFound", err)
+	}
+
+	forum, err := u.GetInfoBySlug("golang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if forum.Threads != 1 {
+		t.Errorf("forum threads = %d, want 1", forum.Threads)
+	}
+}
+
+func TestGetForumUsersAndThreads(t *testing.T) {
+	u := newUseCase(t)
+	if _, err := u.CreateForum(&models.Forum{Slug: "golang", Title: "Go", User: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, nick := range []string{"zed", "alice", "bob"} {
+		_, err := u.CreateThread(&models.Thread{
+			Forum: "golang", Author: nick, Title: "t", Message: "m",
+			Created: start.Add(time.Duration(i) * time.Hour),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	users, _, err := u.GetForumUsers("golang", 0, "Bob", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0].Nickname != "Zed" {
+		t.Errorf("users since Bob = %v, want [Zed]", users)
+	}
+
+	threads, _, err := u.GetForumThreads("golang", 2, start.Add(time.Hour).Format(time.RFC3339), "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(threads) != 2 || threads[0].Author != "alice" || threads[1].Author != "Zed" {
+		t.Errorf("threads = %v, want alice's then Zed's", threads)
+	}
+
+	_, _, err = u.GetForumUsers("rust", 0, "", "", false)
+	if !errors.Is(err, customErr.ErrForumNotFound) {
+		t.Errorf("err = %v, want ErrForumNotFound", err)
+	}
+}
+
+func TestForumThreadsCursors(t *testing.T) {
+	u := newUseCase(t)
+	if _, err := u.CreateForum(&models.Forum{Slug: "golang", Title: "Go", User: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	created := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	var ids []uint64
+	for i := 0; i < 5; i++ {
+		thread, err := u.CreateThread(&models.Thread{Forum: "golang", Author: "alice", Title: "t", Message: "m", Created: created})
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, thread.ID)
+	}
+	threadIDs := func(threads []models.Thread) []uint64 {
+		result := []uint64{}
+		for _, th := range threads {
+			result = append(result, th.ID)
+		}
+		return result
+	}
+
+	// All threads share a timestamp, which since cannot page through.
+	threads, cursors, err := u.GetForumThreads("golang", 2, created.Format(time.RFC3339), "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := threadIDs(threads)
+	for cursors.Next != "" && len(got) < 10 {
+		if threads, cursors, err = u.GetForumThreads("golang", 2, "", cursors.Next, false); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, threadIDs(threads)...)
+	}
+	if !reflect.DeepEqual(got, ids) {
+		t.Errorf("paged threads = %v, want %v", got, ids)
+	}
+	if !reflect.DeepEqual(threadIDs(threads), ids[4:]) || cursors.Prev == "" {
+		t.Fatalf("last page = %v, %+v", threadIDs(threads), cursors)
+	}
+	threads, cursors, _ = u.GetForumThreads("golang", 2, "", cursors.Prev, false)
+	if !reflect.DeepEqual(threadIDs(threads), ids[2:4]) || cursors.Next == "" || cursors.Prev == "" {
+		t.Errorf("previous page = %v, %+v, want %v", threadIDs(threads), cursors, ids[2:4])
+	}
+
+	// A cursor keeps the direction of the list it came from.
+	threads, cursors, _ = u.GetForumThreads("golang", 3, "", "", true)
+	if !reflect.DeepEqual(threadIDs(threads), []uint64{ids[4], ids[3], ids[2]}) {
+		t.Errorf("desc threads = %v", threadIDs(threads))
+	}
+	threads, _, _ = u.GetForumThreads("golang", 3, "", cursors.Next, false)
+	if !reflect.DeepEqual(threadIDs(threads), []uint64{ids[1], ids[0]}) {
+		t.Errorf("next desc page = %v", threadIDs(threads))
+	}
+
+	if _, _, err := u.GetForumThreads("golang", 2, "", "bogus", false); !errors.Is(err, customErr.ErrInvalid) {
+		t.Errorf("bad cursor: err = %v, want ErrInvalid", err)
+	}
+}
+
+func TestRoles(t *testing.T) {
+	u := newUseCase(t)
+	if _, err := u.CreateForum(&models.Forum{Slug: "golang", Title: "Go", User: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	alice := models.Actor{Nickname: "alice"}
+	bob := models.Actor{Nickname: "bob"}
+
+	grant := func(actor models.Actor, nickname string, role string) error {
+		_, err := u.GrantRole(actor, models.ForumRole{Forum: "golang", Nickname: nickname, Role: role})
+		return err
+	}
+	if err := grant(bob, "zed", models.RoleMember); !errors.Is(err, customErr.ErrForbidden) {
+		t.Errorf("grant by a member: err = %v, want ErrForbidden", err)
+	}
+	if err := grant(models.Actor{}, "zed", models.RoleMember); !errors.Is(err, customErr.ErrUnauthorized) {
+		t.Errorf("anonymous grant: err = %v, want ErrUnauthorized", err)
+	}
+	role, err := u.GrantRole(alice, models.ForumRole{Forum: "GOLANG", Nickname: "BOB", Role: models.RoleModerator})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if role.Forum != "golang" || role.Nickname != "Bob" {
+		t.Errorf("granted role = %+v, want stored names", role)
+	}
+
+	if err := grant(bob, "zed", models.RoleBanned); err != nil {
+		t.Errorf("ban by a moderator: %v", err)
+	}
+	if err := grant(bob, "zed", models.RoleModerator); !errors.Is(err, customErr.ErrForbidden) {
+		t.Errorf("moderator granting moderator: err = %v, want ErrForbidden", err)
+	}
+	if err := grant(bob, "alice", models.RoleBanned); !errors.Is(err, customErr.ErrForbidden) {
+		t.Errorf("moderator banning the owner: err = %v, want ErrForbidden", err)
+	}
+	if err := grant(bob, "mallory", models.RoleMember); !errors.Is(err, customErr.ErrUserNotFound) {
+		t.Errorf("err = %v, want ErrUserNotFound", err)
+	}
+	if err := grant(alice, "alice", models.RoleMember); !errors.Is(err, customErr.ErrForbidden) {
+		t.Errorf("owner demoting themselves: err = %v, want ErrForbidden", err)
+	}
+
+	_, err = u.CreateThread(&models.Thread{Forum: "golang", Author: "zed", Title: "t", Message: "m"})
+	if !errors.Is(err, customErr.ErrForbidden) {
+		t.Errorf("thread by a banned user: err = %v, want ErrForbidden", err)
+	}
+
+	roles, err := u.GetRoles("golang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []models.ForumRole{
+		{Forum: "golang", Nickname: "alice", Role: models.RoleOwner},
+		{Forum: "golang", Nickname: "Bob", Role: models.RoleModerator},
+		{Forum: "golang", Nickname: "Zed", Role: models.RoleBanned},
+	}
+	if !reflect.DeepEqual(roles, want) {
+		t.Errorf("roles = %+v, want %+v", roles, want)
+	}
+
+	if err := u.RevokeRole(bob, "golang", "bob"); !errors.Is(err, customErr.ErrForbidden) {
+		t.Errorf("moderator revoking a moderator: err = %v, want ErrForbidden", err)
+	}
+	if err := u.RevokeRole(bob, "golang", "zed"); err != nil {
+		t.Errorf("unban by a moderator: %v", err)
+	}
+	if err := u.RevokeRole(alice, "golang", "bob"); err != nil {
+		t.Errorf("revoke by the owner: %v", err)
+	}
+	if err := u.RevokeRole(alice, "rust", "bob"); !errors.Is(err, customErr.ErrForumNotFound) {
+		t.Errorf("err = %v, want ErrForumNotFound", err)
+	}
+	if roles, _ = u.GetRoles("golang"); len(roles) != 1 {
+		t.Errorf("roles after revoke = %+v, want only the owner", roles)
+	}
+}
+
+func TestGetForums(t *testing.T) {
+	u := newUseCase(t)
+	for _, f := range []models.Forum{
+		{Slug: "golang", Title: "Go", User: "alice"},
+		{Slug: "rust", Title: "Rust", User: "bob"},
+		{Slug: "c", Title: "C", User: "alice"},
+	} {
+		f := f
+		if _, err := u.CreateForum(&f); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, slug := range []string{"rust", "rust", "golang"} {
+		if _, err := u.CreateThread(&models.Thread{Forum: slug, Author: "zed", Title: "t", Message: "m"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	slugs := func(forums []models.Forum) []string {
+		result := []string{}
+		for _, f := range forums {
+			result = append(result, f.Slug)
+		}
+		return result
+	}
+	tests := []struct {
+		owner string
+		sort  string
+		limit int
+		since string
+		desc  bool
+		want  []string
+	}{
+		{"", "", 0, "", false, []string{"golang", "rust", "c"}},
+		{"", "threads", 0, "", true, []string{"rust", "golang", "c"}},
+		{"", "title", 0, "", false, []string{"c", "golang", "rust"}},
+		{"", "posts", 0, "", false, []string{"golang", "rust", "c"}},
+		{"", "threads", 1, "golang", true, []string{"c"}},
+		{"", "", 0, "golang", false, []string{"rust", "c"}},
+		{"ALICE", "", 0, "", true, []string{"c", "golang"}},
+	}
+	for _, tt := range tests {
+		forums, _, err := u.GetForums(tt.owner, tt.sort, tt.limit, tt.since, "", tt.desc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := slugs(forums); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GetForums(%q, %q, %d, %q, %v) = %v, want %v", tt.owner, tt.sort, tt.limit, tt.since, tt.desc, got, tt.want)
+		}
+	}
+
+	if _, _, err := u.GetForums("", "votes", 0, "", "", false); !errors.Is(err, customErr.ErrInvalid) {
+		t.Errorf("err = %v, want ErrInvalid", err)
+	}
+	if _, _, err := u.GetForums("", "", 0, "nope", "", false); !errors.Is(err, customErr.ErrForumNotFound) {
+		t.Errorf("err = %v, want ErrForumNotFound", err)
+	}
+
+	// The cursor keeps the counter the page ended at, so threads created
+	// between pages do not make the next page skip forums.
+	forums, cursors, err := u.GetForums("", "threads", 2, "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := slugs(forums); !reflect.DeepEqual(got, []string{"c", "golang"}) {
+		t.Fatalf("first page = %v, want [c golang]", got)
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := u.CreateThread(&models.Thread{Forum: "golang", Author: "zed", Title: "t", Message: "m"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, sort := range []string{"posts", "created"} {
+		if _, _, err := u.GetForums("", sort, 2, "", cursors.Next, false); !errors.Is(err, customErr.ErrInvalid) {
+			t.Errorf("threads cursor with sort=%s: err = %v, want ErrInvalid", sort, err)
+		}
+	}
+	forums, _, err = u.GetForums("", "threads", 2, "", cursors.Next, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := slugs(forums); !reflect.DeepEqual(got, []string{"rust", "golang"}) {
+		t.Errorf("next page = %v, want [rust golang]", got)
+	}
+
+	_, cursors, err = u.GetForums("", "title", 1, "", "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := u.GetForums("", "posts", 1, "", cursors.Next, false); !errors.Is(err, customErr.ErrInvalid) {
+		t.Errorf("title cursor with sort=posts: err = %v, want ErrInvalid", err)
+	}
+}
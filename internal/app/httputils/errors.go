@@ -0,0 +1,21 @@
+package httputils
+
+import (
+	customErr "DBForum/internal/app/errors"
+	"DBForum/internal/app/logger"
+	"DBForum/internal/app/metrics"
+	"github.com/valyala/fasthttp"
+)
+
+// RespondError renders err as {"code": ..., "message": ..., <fields>} with
+// the status of its kind. Errors outside internal/app/errors are logged and
+// answered with a generic 500.
+func RespondError(ctx *fasthttp.RequestCtx, err error) {
+	metrics.ObserveError(ctx, err)
+	e := customErr.From(err)
+	if e == nil {
+		logger.FromCtx(ctx).Error(err)
+		e = customErr.Internal()
+	}
+	RespondErr(ctx, e.Status, e)
+}
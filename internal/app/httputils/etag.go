@@ -0,0 +1,52 @@
+package httputils
+
+import (
+	customErr "DBForum/internal/app/errors"
+	"github.com/valyala/fasthttp"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ETag is the entity tag of a row at version.
+func ETag(version uint64) string {
+	return `"` + strconv.FormatUint(version, 10) + `"`
+}
+
+func SetETag(ctx *fasthttp.RequestCtx, version uint64) {
+	ctx.Response.Header.Set("ETag", ETag(version))
+}
+
+// NotModified answers 304 if If-None-Match names version and reports
+// whether it did. Weak tags match too, as If-None-Match compares weakly.
+func NotModified(ctx *fasthttp.RequestCtx, version uint64) bool {
+	header := string(ctx.Request.Header.Peek("If-None-Match"))
+	if header == "" {
+		return false
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == ETag(version) {
+			SetETag(ctx, version)
+			ctx.SetStatusCode(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// IfMatch returns the version an update is made against, or 0 when the
+// request has no If-Match or If-Match is "*". If-Match compares strongly,
+// so weak and malformed tags never match and fail as stale.
+func IfMatch(ctx *fasthttp.RequestCtx) (uint64, error) {
+	tag := strings.TrimSpace(string(ctx.Request.Header.Peek("If-Match")))
+	if tag == "" || tag == "*" {
+		return 0, nil
+	}
+	if len(tag) > 2 && tag[0] == '"' && tag[len(tag)-1] == '"' {
+		if version, err := strconv.ParseUint(tag[1:len(tag)-1], 10, 64); err == nil && version > 0 {
+			return version, nil
+		}
+	}
+	return 0, customErr.NoMatch(tag)
+}
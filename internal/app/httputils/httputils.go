@@ -1,10 +1,10 @@
 package httputils
 
 import (
+	"DBForum/internal/app/logger"
 	"encoding/json"
 	"github.com/mailru/easyjson"
 	"github.com/valyala/fasthttp"
-	"log"
 )
 
 func Respond(ctx *fasthttp.RequestCtx, code int, data easyjson.Marshaler) {
@@ -13,7 +13,7 @@ func Respond(ctx *fasthttp.RequestCtx, code int, data easyjson.Marshaler) {
 	if data != nil {
 		_, err := easyjson.MarshalToWriter(data, ctx)
 		if err != nil {
-			log.Print(err, data)
+			logger.FromCtx(ctx).WithField("data", data).Error(err)
 			return
 		}
 	}
@@ -25,7 +25,7 @@ func RespondErr(ctx *fasthttp.RequestCtx, code int, data interface{}) {
 	if data != nil {
 		err := json.NewEncoder(ctx).Encode(data)
 		if err != nil {
-			log.Print(err, data)
+			logger.FromCtx(ctx).WithField("data", data).Error(err)
 			return
 		}
 	}
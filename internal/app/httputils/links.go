@@ -0,0 +1,46 @@
+package httputils
+
+import (
+	"DBForum/internal/app/models"
+	"github.com/valyala/fasthttp"
+	"strings"
+)
+
+// SetLinks points the Link header at the pages around a list page. The
+// links repeat the request with the cursor replaced; since and desc are
+// dropped as the cursor carries both.
+func SetLinks(ctx *fasthttp.RequestCtx, cursors models.Cursors) {
+	setLinks(ctx, cursors, "since", "desc")
+}
+
+// SetFilteredLinks is SetLinks for lists such as search where since
+// filters the rows instead of naming a position, so the links keep it.
+func SetFilteredLinks(ctx *fasthttp.RequestCtx, cursors models.Cursors) {
+	setLinks(ctx, cursors, "desc")
+}
+
+func setLinks(ctx *fasthttp.RequestCtx, cursors models.Cursors, dropped ...string) {
+	var links []string
+	for _, link := range []struct {
+		cursor string
+		rel    string
+	}{
+		{cursors.Next, "next"},
+		{cursors.Prev, "prev"},
+	} {
+		if link.cursor == "" {
+			continue
+		}
+		args := fasthttp.AcquireArgs()
+		ctx.QueryArgs().CopyTo(args)
+		for _, name := range dropped {
+			args.Del(name)
+		}
+		args.Set("cursor", link.cursor)
+		links = append(links, "<"+string(ctx.URI().PathOriginal())+"?"+args.String()+`>; rel="`+link.rel+`"`)
+		fasthttp.ReleaseArgs(args)
+	}
+	if len(links) > 0 {
+		ctx.Response.Header.Set("Link", strings.Join(links, ", "))
+	}
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"DBForum/internal/app/auth"
+	customErr "DBForum/internal/app/errors"
+	"DBForum/internal/app/httputils"
+	idempotencyUseCase "DBForum/internal/app/idempotency/usecase"
+	"DBForum/internal/app/logger"
+	"DBForum/internal/app/models"
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/valyala/fasthttp"
+)
+
+const (
+	KeyHeader      = "Idempotency-Key"
+	ReplayedHeader = "Idempotent-Replayed"
+
+	maxKeyLength = 255
+)
+
+type Handlers struct {
+	useCase idempotencyUseCase.UseCase
+}
+
+func NewHandler(useCase idempotencyUseCase.UseCase) *Handlers {
+	return &Handlers{
+		useCase: useCase,
+	}
+}
+
+// Wrap makes next idempotent for requests that carry an Idempotency-Key
+// header. Keys are scoped to the authenticated user, or for anonymous
+// requests such as signups to the client address and the path, which names
+// the user, forum or thread written to. A retry with the same key and body gets the first
+// response again, a different request with the same key gets 422. Requests
+// without the header are passed through.
+func (h *Handlers) Wrap(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		key := string(ctx.Request.Header.Peek(KeyHeader))
+		if key == "" {
+			next(ctx)
+			return
+		}
+		if !validKey(key) {
+			httputils.RespondError(ctx, customErr.Invalid(KeyHeader,
+				"must be 1 to 255 printable ASCII characters"))
+			return
+		}
+
+		scope := auth.Actor(ctx).Nickname
+		if scope == "" {
+			scope = anonymousScope(ctx)
+		}
+		hash := requestHash(ctx)
+		stored, err := h.useCase.Begin(scope, key, hash)
+		if err != nil {
+			httputils.RespondError(ctx, err)
+			return
+		}
+		if stored != nil {
+			ctx.SetStatusCode(stored.Status)
+			ctx.Response.Header.Set("Content-Type", stored.ContentType)
+			ctx.Response.Header.Set(ReplayedHeader, "true")
+			ctx.SetBody(stored.Body)
+			return
+		}
+
+		next(ctx)
+
+		err = h.useCase.Finish(models.IdempotencyKey{
+			Scope:       scope,
+			Key:         key,
+			RequestHash: hash,
+			Status:      ctx.Response.StatusCode(),
+			ContentType: string(ctx.Response.Header.ContentType()),
+			Body:        append([]byte(nil), ctx.Response.Body()...),
+		})
+		if err != nil {
+			logger.FromCtx(ctx).WithError(err).Error("storing idempotent response")
+		}
+	}
+}
+
+// anonymousScope holds a space, which nicknames can't, so anonymous keys
+// never share a scope with a user's.
+func anonymousScope(ctx *fasthttp.RequestCtx) string {
+	return ctx.RemoteIP().String() + " " + string(ctx.Path())
+}
+
+func validKey(key string) bool {
+	if len(key) > maxKeyLength {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < 0x21 || key[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// requestHash identifies a request by its method, path and body, so that a
+// key can't be reused for another endpoint either.
+func requestHash(ctx *fasthttp.RequestCtx) string {
+	h := sha256.New()
+	h.Write(ctx.Method())
+	h.Write([]byte{' '})
+	h.Write(ctx.Path())
+	h.Write([]byte{'\n'})
+	h.Write(ctx.PostBody())
+	return hex.EncodeToString(h.Sum(nil))
+}
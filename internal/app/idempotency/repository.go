@@ -0,0 +1,19 @@
+package idempotency
+
+import (
+	"DBForum/internal/app/models"
+	"time"
+)
+
+type Repository interface {
+	// Claim stores key as a running request. If the key is taken it returns
+	// the stored row instead, unless that row was created before expired,
+	// or is still running and was created before stuck, in which case the
+	// key is claimed over it.
+	Claim(key models.IdempotencyKey, expired time.Time, stuck time.Time) (*models.IdempotencyKey, error)
+	// Complete stores the response of a claimed key.
+	Complete(key models.IdempotencyKey) error
+	// Release drops a claimed key that has no response yet.
+	Release(scope string, key string) error
+	Prune(before time.Time) (int64, error)
+}
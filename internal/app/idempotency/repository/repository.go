@@ -0,0 +1,108 @@
+package repository
+
+import (
+	customErr "DBForum/internal/app/errors"
+	"DBForum/internal/app/idempotency"
+	"DBForum/internal/app/models"
+	"github.com/jackc/pgx"
+	"time"
+)
+
+const (
+	claimKey = `INSERT INTO dbforum.idempotency_key AS k (scope, key, request_hash, created)
+				VALUES ($1, $2, $3, $4)
+				ON CONFLICT (scope, key) DO UPDATE
+				SET request_hash = EXCLUDED.request_hash, status = 0, content_type = '', body = NULL,
+					created = EXCLUDED.created
+				WHERE k.created < $5 OR (k.status = 0 AND k.created < $6)`
+
+	selectKey = `SELECT scope, key, request_hash, status, content_type, body, created
+				FROM dbforum.idempotency_key WHERE scope = $1 AND key = $2`
+
+	completeKey = `UPDATE dbforum.idempotency_key SET status = $4, content_type = $5, body = $6
+					WHERE scope = $1 AND key = $2 AND request_hash = $3 AND status = 0`
+
+	releaseKey = "DELETE FROM dbforum.idempotency_key WHERE scope = $1 AND key = $2 AND status = 0"
+
+	pruneKeys = "DELETE FROM dbforum.idempotency_key WHERE created < $1"
+)
+
+var _ idempotency.Repository = (*Repository)(nil)
+
+type Repository struct {
+	db *pgx.ConnPool
+}
+
+func NewRepo(db *pgx.ConnPool) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+func (r *Repository) Claim(key models.IdempotencyKey, expired time.Time, stuck time.Time) (*models.IdempotencyKey, error) {
+	tag, err := r.db.Exec("claimKey", key.Scope, key.Key, key.RequestHash, key.Created, expired, stuck)
+	if err != nil {
+		return nil, err
+	}
+	if tag.RowsAffected() == 1 {
+		return nil, nil
+	}
+
+	stored := &models.IdempotencyKey{}
+	err = r.db.QueryRow("selectKey", key.Scope, key.Key).Scan(&stored.Scope, &stored.Key, &stored.RequestHash,
+		&stored.Status, &stored.ContentType, &stored.Body, &stored.Created)
+	if err == pgx.ErrNoRows {
+		// The request holding the key failed and released it in between.
+		return nil, customErr.KeyInUse(key.Key)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return stored, nil
+}
+
+func (r *Repository) Complete(key models.IdempotencyKey) error {
+	_, err := r.db.Exec("completeKey", key.Scope, key.Key, key.RequestHash, key.Status, key.ContentType, key.Body)
+	return err
+}
+
+func (r *Repository) Release(scope string, key string) error {
+	_, err := r.db.Exec("releaseKey", scope, key)
+	return err
+}
+
+func (r *Repository) Prune(before time.Time) (int64, error) {
+	tag, err := r.db.Exec("pruneKeys", before)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r *Repository) Prepare() error {
+	_, err := r.db.Prepare("claimKey", claimKey)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Prepare("selectKey", selectKey)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Prepare("completeKey", completeKey)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Prepare("releaseKey", releaseKey)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Prepare("pruneKeys", pruneKeys)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package usecase
+
+import (
+	customErr "DBForum/internal/app/errors"
+	"DBForum/internal/app/idempotency"
+	"DBForum/internal/app/models"
+	"net/http"
+	"time"
+)
+
+// claimTimeout is how long a running request may hold its key before a
+// retry is let through in its place, e.g. after the server was killed
+// mid-request. Creations run in a single transaction, so a request that
+// died leaves nothing behind; one that is still running after claimTimeout
+// is not stopped, though, and its retry runs alongside it.
+const claimTimeout = time.Minute
+
+type UseCase struct {
+	repo      idempotency.Repository
+	retention time.Duration
+}
+
+func NewUseCase(repo idempotency.Repository, retention time.Duration) *UseCase {
+	return &UseCase{
+		repo:      repo,
+		retention: retention,
+	}
+}
+
+// Begin claims key for a request whose method, path and body hash to
+// requestHash. It returns nil when the request should run, and the stored
+// response when it is a retry of a finished one.
+func (u *UseCase) Begin(scope string, key string, requestHash string) (*models.IdempotencyKey, error) {
+	now := time.Now()
+	stored, err := u.repo.Claim(models.IdempotencyKey{
+		Scope:       scope,
+		Key:         key,
+		RequestHash: requestHash,
+		Created:     now,
+	}, now.Add(-u.retention), now.Add(-claimTimeout))
+	if err != nil || stored == nil {
+		return nil, err
+	}
+	if stored.RequestHash != requestHash {
+		return nil, customErr.KeyReused(key)
+	}
+	if stored.Status == 0 {
+		return nil, customErr.KeyInUse(key)
+	}
+	return stored, nil
+}
+
+// Finish stores the response to a request claimed by Begin. Server errors
+// aren't stored, so that the client can retry with the same key.
+func (u *UseCase) Finish(response models.IdempotencyKey) error {
+	if response.Status >= http.StatusInternalServerError {
+		return u.repo.Release(response.Scope, response.Key)
+	}
+	return u.repo.Complete(response)
+}
+
+// Prune drops the keys that are past the retention window.
+func (u *UseCase) Prune() (int64, error) {
+	return u.repo.Prune(time.Now().Add(-u.retention))
+}
@@ -0,0 +1,83 @@
+package usecase
+
+import (
+	customErr "DBForum/internal/app/errors"
+	"DBForum/internal/app/memory"
+	"DBForum/internal/app/models"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestReplay(t *testing.T) {
+	u := NewUseCase(memory.NewIdempotencyRepo(memory.NewStore()), time.Hour)
+
+	if stored, err := u.Begin("alice", "k1", "hash"); err != nil || stored != nil {
+		t.Fatalf("first request: %+v, %v, want it to run", stored, err)
+	}
+	if _, err := u.Begin("ALICE", "k1", "hash"); !errors.Is(err, customErr.ErrKeyInUse) {
+		t.Errorf("retry while running: err = %v, want ErrKeyInUse", err)
+	}
+
+	err := u.Finish(models.IdempotencyKey{Scope: "alice", Key: "k1", RequestHash: "hash",
+		Status: http.StatusCreated, ContentType: "application/json", Body: []byte(`[{"id":1}]`)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err := u.Begin("alice", "k1", "hash")
+	if err != nil || stored == nil {
+		t.Fatalf("retry: %+v, %v, want the stored response", stored, err)
+	}
+	if stored.Status != http.StatusCreated || string(stored.Body) != `[{"id":1}]` {
+		t.Errorf("replayed %d %s", stored.Status, stored.Body)
+	}
+
+	if _, err := u.Begin("alice", "k1", "other"); !errors.Is(err, customErr.ErrKeyReused) {
+		t.Errorf("different body: err = %v, want ErrKeyReused", err)
+	}
+	if stored, err := u.Begin("bob", "k1", "other"); err != nil || stored != nil {
+		t.Errorf("another user's key: %+v, %v, want it to run", stored, err)
+	}
+}
+
+func TestServerErrorReleasesKey(t *testing.T) {
+	u := NewUseCase(memory.NewIdempotencyRepo(memory.NewStore()), time.Hour)
+
+	if _, err := u.Begin("", "k1", "hash"); err != nil {
+		t.Fatal(err)
+	}
+	if err := u.Finish(models.IdempotencyKey{Key: "k1", RequestHash: "hash", Status: http.StatusInternalServerError}); err != nil {
+		t.Fatal(err)
+	}
+	if stored, err := u.Begin("", "k1", "hash"); err != nil || stored != nil {
+		t.Errorf("retry after a 500: %+v, %v, want it to run again", stored, err)
+	}
+}
+
+func TestRetention(t *testing.T) {
+	repo := memory.NewIdempotencyRepo(memory.NewStore())
+	u := NewUseCase(repo, time.Hour)
+
+	old := models.IdempotencyKey{Key: "old", RequestHash: "hash", Created: time.Now().Add(-2 * time.Hour)}
+	stuck := models.IdempotencyKey{Key: "stuck", RequestHash: "hash", Created: time.Now().Add(-2 * claimTimeout)}
+	for _, k := range []models.IdempotencyKey{old, stuck} {
+		if _, err := repo.Claim(k, k.Created.Add(-time.Hour), k.Created.Add(-time.Hour)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	old.Status = http.StatusCreated
+	if err := repo.Complete(old); err != nil {
+		t.Fatal(err)
+	}
+
+	if pruned, err := u.Prune(); err != nil || pruned != 1 {
+		t.Errorf("Prune = %d, %v, want 1", pruned, err)
+	}
+	if stored, err := u.Begin("", "old", "other"); err != nil || stored != nil {
+		t.Errorf("expired key: %+v, %v, want it to run", stored, err)
+	}
+	if stored, err := u.Begin("", "stuck", "other"); err != nil || stored != nil {
+		t.Errorf("stuck key: %+v, %v, want it to run", stored, err)
+	}
+}
@@ -0,0 +1,28 @@
+// Package logger ties logrus entries to the request being served, so every
+// line logged while handling a request carries its request ID.
+package logger
+
+import (
+	"github.com/sirupsen/logrus"
+	"github.com/valyala/fasthttp"
+)
+
+const requestIDKey = "logger.requestID"
+
+func SetRequestID(ctx *fasthttp.RequestCtx, id string) {
+	ctx.SetUserValue(requestIDKey, id)
+}
+
+func RequestID(ctx *fasthttp.RequestCtx) string {
+	id, _ := ctx.UserValue(requestIDKey).(string)
+	return id
+}
+
+// FromCtx returns the standard logger annotated with the request ID of ctx.
+func FromCtx(ctx *fasthttp.RequestCtx) *logrus.Entry {
+	entry := logrus.NewEntry(logrus.StandardLogger())
+	if id := RequestID(ctx); id != "" {
+		entry = entry.WithField("request_id", id)
+	}
+	return entry
+}
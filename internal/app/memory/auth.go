@@ -0,0 +1,106 @@
+package memory
+
+import (
+	"DBForum/internal/app/auth"
+	customErr "DBForum/internal/app/errors"
+	"DBForum/internal/app/models"
+)
+
+var _ auth.Repository = (*AuthRepo)(nil)
+
+type AuthRepo struct {
+	store *Store
+}
+
+func NewAuthRepo(store *Store) *AuthRepo {
+	return &AuthRepo{
+		store: store,
+	}
+}
+
+func (r *AuthRepo) SetPassword(nickname string, passwordHash string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.userByNick(nickname); !ok {
+		return customErr.UserNotFound(nickname)
+	}
+	s.passwords[key(nickname)] = passwordHash
+	return nil
+}
+
+func (r *AuthRepo) FindCredentials(nickname string) (models.Credentials, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	hash, ok := s.passwords[key(nickname)]
+	if !ok {
+		return models.Credentials{}, customErr.UserNotFound(nickname)
+	}
+	u, _ := s.userByNick(nickname)
+	return models.Credentials{Nickname: u.Nickname, Password: hash}, nil
+}
+
+func (r *AuthRepo) CreateToken(tokenHash string, session models.Session) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	session.Token = ""
+	s.tokens[tokenHash] = session
+	return nil
+}
+
+func (r *AuthRepo) FindToken(tokenHash string) (models.Session, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	session, ok := s.tokens[tokenHash]
+	if !ok {
+		return models.Session{}, customErr.Unauthorized("Invalid or expired token")
+	}
+	session.Admin = s.admins[key(session.Nickname)]
+	return session, nil
+}
+
+func (r *AuthRepo) DeleteToken(tokenHash string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.tokens, tokenHash)
+	return nil
+}
+
+func (r *AuthRepo) SetAdmin(nickname string, admin bool) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.passwords[key(nickname)]; !ok {
+		return customErr.UserNotFound(nickname)
+	}
+	s.admins[key(nickname)] = admin
+	return nil
+}
+
+func (r *AuthRepo) InsertAuditEntry(entry models.AuditEntry) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.auditLog = append(s.auditLog, entry)
+	return nil
+}
+
+// AuditLog returns the entries recorded so far.
+func (r *AuthRepo) AuditLog() []models.AuditEntry {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]models.AuditEntry(nil), s.auditLog...)
+}
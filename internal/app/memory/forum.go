@@ -0,0 +1,199 @@
+package memory
+
+import (
+	customErr "DBForum/internal/app/errors"
+	"DBForum/internal/app/forum"
+	"DBForum/internal/app/models"
+	"sort"
+	"strconv"
+)
+
+var _ forum.Repository = (*ForumRepo)(nil)
+
+type ForumRepo struct {
+	store *Store
+}
+
+func NewForumRepo(store *Store) *ForumRepo {
+	return &ForumRepo{
+		store: store,
+	}
+}
+
+func (r *ForumRepo) CreateForum(forum *models.Forum) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.forums[key(forum.Slug)]; ok {
+		*forum = *existing
+		return customErr.ErrDuplicate
+	}
+	u, ok := s.userByNick(forum.User)
+	if !ok {
+		return customErr.UserNotFound(forum.User)
+	}
+	forum.User = u.Nickname
+
+	stored := *forum
+	stored.Posts = 0
+	stored.Threads = 0
+	s.forums[key(forum.Slug)] = &stored
+	s.forumOrder = append(s.forumOrder, key(forum.Slug))
+	s.forumRoles[key(forum.Slug)] = map[string]models.ForumRole{
+		key(u.Nickname): {Forum: forum.Slug, Nickname: u.Nickname, Role: models.RoleOwner},
+	}
+	return nil
+}
+
+func (r *ForumRepo) FindBySlug(slug string) (*models.Forum, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	f, ok := s.forums[key(slug)]
+	if !ok {
+		return nil, customErr.ForumNotFound(slug)
+	}
+	found := *f
+	return &found, nil
+}
+
+func (r *ForumRepo) GetForums(owner string, sortBy string, limit int, since string, from *models.Position, desc bool) ([]models.Forum, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	switch sortBy {
+	case models.ForumSortCreated, models.ForumSortPosts, models.ForumSortThreads, models.ForumSortTitle:
+	default:
+		return nil, customErr.Invalid("sort", "must be one of created, posts, threads, title")
+	}
+	ids := map[string]uint64{}
+	for i, k := range s.forumOrder {
+		ids[k] = uint64(i + 1)
+	}
+	less := func(a *models.Forum, b *models.Forum) bool {
+		switch sortBy {
+		case models.ForumSortPosts:
+			if a.Posts != b.Posts {
+				return a.Posts < b.Posts
+			}
+		case models.ForumSortThreads:
+			if a.Threads != b.Threads {
+				return a.Threads < b.Threads
+			}
+		case models.ForumSortTitle:
+			if a.Title != b.Title {
+				return a.Title < b.Title
+			}
+		}
+		return a.ID < b.ID
+	}
+	if desc {
+		asc := less
+		less = func(a *models.Forum, b *models.Forum) bool {
+			return asc(b, a)
+		}
+	}
+
+	var cursor *models.Forum
+	before := false
+	if from != nil {
+		cursor = &models.Forum{ID: from.ID, Title: from.Key}
+		cursor.Posts, _ = strconv.ParseUint(from.Key, 10, 64)
+		cursor.Threads = cursor.Posts
+		before = from.Before
+	} else if since != "" {
+		f, ok := s.forums[key(since)]
+		if !ok {
+			return nil, customErr.ForumNotFound(since)
+		}
+		found := *f
+		found.ID = ids[key(since)]
+		cursor = &found
+	}
+	var forums []models.Forum
+	for k, f := range s.forums {
+		if owner != "" && key(f.User) != key(owner) {
+			continue
+		}
+		listed := *f
+		listed.ID = ids[k]
+		if cursor != nil && (!before && !less(cursor, &listed) || before && !less(&listed, cursor)) {
+			continue
+		}
+		forums = append(forums, listed)
+	}
+	sort.Slice(forums, func(i, j int) bool {
+		return less(&forums[i], &forums[j])
+	})
+	first, last := bounds(len(forums), limit, before)
+	return forums[first:last], nil
+}
+
+func (r *ForumRepo) GetRoles(forumSlug string) ([]models.ForumRole, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.forums[key(forumSlug)]; !ok {
+		return nil, customErr.ForumNotFound(forumSlug)
+	}
+	var roles []models.ForumRole
+	for _, role := range s.forumRoles[key(forumSlug)] {
+		roles = append(roles, role)
+	}
+	sort.Slice(roles, func(i, j int) bool {
+		return key(roles[i].Nickname) < key(roles[j].Nickname)
+	})
+	return roles, nil
+}
+
+func (r *ForumRepo) GetRole(forumSlug string, nickname string) (string, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.forumRoles[key(forumSlug)][key(nickname)].Role, nil
+}
+
+func (r *ForumRepo) SetRole(role models.ForumRole) (models.ForumRole, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	f, ok := s.forums[key(role.Forum)]
+	if !ok {
+		return models.ForumRole{}, customErr.ForumNotFound(role.Forum)
+	}
+	u, ok := s.userByNick(role.Nickname)
+	if !ok {
+		return models.ForumRole{}, customErr.UserNotFound(role.Nickname)
+	}
+	stored := models.ForumRole{Forum: f.Slug, Nickname: u.Nickname, Role: role.Role}
+	s.forumRoles[key(f.Slug)][key(u.Nickname)] = stored
+	return stored, nil
+}
+
+func (r *ForumRepo) DeleteRole(forumSlug string, nickname string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.forumRoles[key(forumSlug)], key(nickname))
+	return nil
+}
+
+func (r *ForumRepo) FindBanned(forumSlug string, nicknames []string) (string, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, nickname := range nicknames {
+		if role, ok := s.forumRoles[key(forumSlug)][key(nickname)]; ok && role.Role == models.RoleBanned {
+			return role.Nickname, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,79 @@
+package memory
+
+import (
+	"DBForum/internal/app/idempotency"
+	"DBForum/internal/app/models"
+	"time"
+)
+
+var _ idempotency.Repository = (*IdempotencyRepo)(nil)
+
+type IdempotencyRepo struct {
+	store *Store
+}
+
+func NewIdempotencyRepo(store *Store) *IdempotencyRepo {
+	return &IdempotencyRepo{
+		store: store,
+	}
+}
+
+func (r *IdempotencyRepo) Claim(k models.IdempotencyKey, expired time.Time, stuck time.Time) (*models.IdempotencyKey, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := idempotencyKey{key(k.Scope), k.Key}
+	if stored, ok := s.idemKeys[id]; ok {
+		if !stored.Created.Before(expired) && !(stored.Status == 0 && stored.Created.Before(stuck)) {
+			return &stored, nil
+		}
+	}
+	k.Status, k.ContentType, k.Body = 0, "", nil
+	s.idemKeys[id] = k
+	return nil, nil
+}
+
+func (r *IdempotencyRepo) Complete(k models.IdempotencyKey) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := idempotencyKey{key(k.Scope), k.Key}
+	stored, ok := s.idemKeys[id]
+	if !ok || stored.RequestHash != k.RequestHash || stored.Status != 0 {
+		return nil
+	}
+	stored.Status = k.Status
+	stored.ContentType = k.ContentType
+	stored.Body = append([]byte(nil), k.Body...)
+	s.idemKeys[id] = stored
+	return nil
+}
+
+func (r *IdempotencyRepo) Release(scope string, k string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := idempotencyKey{key(scope), k}
+	if stored, ok := s.idemKeys[id]; ok && stored.Status == 0 {
+		delete(s.idemKeys, id)
+	}
+	return nil
+}
+
+func (r *IdempotencyRepo) Prune(before time.Time) (int64, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var pruned int64
+	for id, stored := range s.idemKeys {
+		if stored.Created.Before(before) {
+			delete(s.idemKeys, id)
+			pruned++
+		}
+	}
+	return pruned, nil
+}
@@ -0,0 +1,440 @@
+package memory
+
+import (
+	customErr "DBForum/internal/app/errors"
+	"DBForum/internal/app/models"
+	"DBForum/internal/app/post"
+	"github.com/go-openapi/strfmt"
+	"github.com/lib/pq"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var _ post.Repository = (*PostRepo)(nil)
+
+type PostRepo struct {
+	store *Store
+}
+
+func NewPostRepo(store *Store) *PostRepo {
+	return &PostRepo{
+		store: store,
+	}
+}
+
+func (s *Store) threadByIDOrSlug(idOrSlug string) (*models.Thread, bool) {
+	if id, err := strconv.ParseUint(idOrSlug, 10, 64); err == nil {
+		t, ok := s.threads[id]
+		return t, ok
+	}
+	return s.threadBySlug(idOrSlug)
+}
+
+func (r *PostRepo) CreatePosts(idOrSlug string, posts []models.Post) ([]models.Post, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.threadByIDOrSlug(idOrSlug)
+	if !ok {
+		return nil, customErr.ThreadNotFound(idOrSlug)
+	}
+	if t.Archived {
+		return nil, customErr.ThreadArchived(idOrSlug)
+	}
+	if t.Locked {
+		return nil, customErr.ThreadLocked(idOrSlug)
+	}
+	if len(posts) == 0 {
+		return nil, nil
+	}
+	// Like the SQL repository, only the first post's parent is checked.
+	if posts[0].Parent != 0 {
+		parent, ok := s.posts[uint64(posts[0].Parent)]
+		if !ok || parent.Thread != t.ID {
+			return nil, customErr.NoParent(uint64(posts[0].Parent))
+		}
+	}
+	for _, p := range posts {
+		if p.Author == "" {
+			return nil, nil
+		}
+		if _, ok := s.userByNick(p.Author); !ok {
+			return nil, customErr.UserNotFound(p.Author)
+		}
+	}
+
+	created := strfmt.DateTime(time.Now())
+	f := s.forums[key(t.Forum)]
+	for i := range posts {
+		s.nextPostID++
+		posts[i].ID = s.nextPostID
+		posts[i].Created = created
+		posts[i].Thread = t.ID
+		posts[i].Forum = t.Forum
+		posts[i].Version = 1
+
+		var tree pq.Int64Array
+		if parent, ok := s.posts[uint64(posts[i].Parent)]; ok {
+			tree = append(tree, parent.Tree...)
+		}
+		posts[i].Tree = append(tree, int64(posts[i].ID))
+
+		stored := posts[i]
+		s.posts[stored.ID] = &stored
+		f.Posts++
+		s.addForumUser(t.Forum, posts[i].Author)
+	}
+	return posts, nil
+}
+
+func (r *PostRepo) GetPosts(idOrSlug string, limit int64, since int64, from *models.Position, desc bool, sort string) ([]models.Post, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.threadByIDOrSlug(idOrSlug)
+	if !ok {
+		return nil, customErr.ThreadNotFound(idOrSlug)
+	}
+	var posts []models.Post
+	for _, p := range s.posts {
+		if p.Thread == t.ID {
+			posts = append(posts, *p)
+		}
+	}
+
+	before := false
+	if from != nil {
+		since = int64(from.ID)
+		before = from.Before
+	}
+	var sincePost *models.Post
+	if since > 0 {
+		sincePost = s.posts[uint64(since)]
+	}
+
+	switch sort {
+	case "tree":
+		return treeSort(posts, sincePost, since > 0, limit, desc, before), nil
+	case "parent_tree":
+		return parentTreeSort(posts, sincePost, since > 0, limit, desc, before), nil
+	default:
+		return flatSort(posts, uint64(since), limit, desc, before), nil
+	}
+}
+
+func (r *PostRepo) GetUserPosts(nickname string, forumSlug string, limit int64, since int64, from *models.Position, desc bool) ([]models.Post, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var posts []models.Post
+	for _, p := range s.posts {
+		if key(p.Author) != key(nickname) {
+			continue
+		}
+		if forumSlug != "" && key(p.Forum) != key(forumSlug) {
+			continue
+		}
+		posts = append(posts, *p)
+	}
+	before := false
+	if from != nil {
+		since = int64(from.ID)
+		before = from.Before
+	}
+	return flatSort(posts, uint64(since), limit, desc, before), nil
+}
+
+func flatSort(posts []models.Post, since uint64, limit int64, desc bool, before bool) []models.Post {
+	var result []models.Post
+	for _, p := range posts {
+		if since > 0 && (p.ID == since || (desc != (p.ID > since)) == before) {
+			continue
+		}
+		result = append(result, p)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if desc {
+			return result[i].ID > result[j].ID
+		}
+		return result[i].ID < result[j].ID
+	})
+	return truncate(result, limit, before)
+}
+
+func treeSort(posts []models.Post, since *models.Post, hasSince bool, limit int64, desc bool, before bool) []models.Post {
+	var result []models.Post
+	for _, p := range posts {
+		if hasSince {
+			// A missing since post compares as NULL and filters everything out.
+			if since == nil {
+				continue
+			}
+			c := compareTree(p.Tree, since.Tree)
+			if desc {
+				c = -c
+			}
+			if c == 0 || (c > 0) == before {
+				continue
+			}
+		}
+		result = append(result, p)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if desc {
+			return compareTree(result[i].Tree, result[j].Tree) > 0
+		}
+		return compareTree(result[i].Tree, result[j].Tree) < 0
+	})
+	return truncate(result, limit, before)
+}
+
+func parentTreeSort(posts []models.Post, since *models.Post, hasSince bool, limit int64, desc bool, before bool) []models.Post {
+	var roots []models.Post
+	for _, p := range posts {
+		if p.Parent != 0 {
+			continue
+		}
+		if hasSince {
+			if since == nil || len(since.Tree) == 0 {
+				continue
+			}
+			if p.Tree[0] == since.Tree[0] || (desc != (p.Tree[0] > since.Tree[0])) == before {
+				continue
+			}
+		}
+		roots = append(roots, p)
+	}
+	sort.Slice(roots, func(i, j int) bool {
+		if desc {
+			return roots[i].ID > roots[j].ID
+		}
+		return roots[i].ID < roots[j].ID
+	})
+	roots = truncate(roots, limit, before)
+
+	selected := map[int64]bool{}
+	for _, root := range roots {
+		selected[int64(root.ID)] = true
+	}
+	var result []models.Post
+	for _, p := range posts {
+		if len(p.Tree) > 0 && selected[p.Tree[0]] {
+			result = append(result, p)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if desc && result[i].Tree[0] != result[j].Tree[0] {
+			return result[i].Tree[0] > result[j].Tree[0]
+		}
+		if c := compareTree(result[i].Tree, result[j].Tree); c != 0 {
+			return c < 0
+		}
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
+
+// compareTree orders materialized paths the way Postgres compares arrays.
+func compareTree(a, b pq.Int64Array) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case len(a) < len(b):
+		return -1
+	case len(a) > len(b):
+		return 1
+	}
+	return 0
+}
+
+func truncate(posts []models.Post, limit int64, before bool) []models.Post {
+	first, last := bounds(len(posts), int(limit), before)
+	return posts[first:last]
+}
+
+func (r *PostRepo) GetReplies(id uint64, maxDepth int, limit int64, from *models.Position) ([]models.PostNode, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.posts[id]
+	if !ok {
+		return []models.PostNode{}, nil
+	}
+	var replies []models.Post
+	for _, reply := range s.posts {
+		if reply.ID == id || !inTree(reply.Tree, id) {
+			continue
+		}
+		if maxDepth > 0 && len(reply.Tree) > len(p.Tree)+maxDepth {
+			continue
+		}
+		replies = append(replies, *reply)
+	}
+	var since *models.Post
+	before := false
+	if from != nil {
+		since = s.posts[from.ID]
+		before = from.Before
+	}
+	return s.nodes(treeSort(replies, since, from != nil, limit, false, before)), nil
+}
+
+func (r *PostRepo) GetAncestors(id uint64) ([]models.PostNode, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var ancestors []models.Post
+	if p, ok := s.posts[id]; ok {
+		for _, ancestor := range p.Tree {
+			if a, ok := s.posts[uint64(ancestor)]; ok && a.ID != id {
+				ancestors = append(ancestors, *a)
+			}
+		}
+	}
+	return s.nodes(ancestors), nil
+}
+
+func inTree(tree pq.Int64Array, id uint64) bool {
+	for _, ancestor := range tree {
+		if uint64(ancestor) == id {
+			return true
+		}
+	}
+	return false
+}
+
+// nodes adds the depth and the number of direct replies to posts.
+func (s *Store) nodes(posts []models.Post) []models.PostNode {
+	nodes := make([]models.PostNode, 0, len(posts))
+	for _, p := range posts {
+		children := 0
+		for _, reply := range s.posts {
+			if reply.Thread == p.Thread && uint64(reply.Parent) == p.ID {
+				children++
+			}
+		}
+		nodes = append(nodes, models.PostNode{Post: p, Depth: len(p.Tree) - 1, Children: children})
+	}
+	return nodes
+}
+
+func (r *PostRepo) GetPostInfoByID(id uint64, related []string) (*models.PostInfo, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	p, ok := s.posts[id]
+	if !ok {
+		return nil, customErr.PostNotFound(id)
+	}
+	found := *p
+	postInfo := models.PostInfo{
+		Post: &found,
+	}
+	for _, item := range related {
+		switch item {
+		case "user":
+			if u, ok := s.userByNick(p.Author); ok {
+				author := *u
+				postInfo.Author = &author
+			}
+		case "thread":
+			if t, ok := s.threads[p.Thread]; ok {
+				th := *t
+				postInfo.Thread = &th
+			}
+		case "forum":
+			if f, ok := s.forums[key(p.Forum)]; ok {
+				forum := *f
+				postInfo.Forum = &forum
+			}
+		}
+	}
+	return &postInfo, nil
+}
+
+func (r *PostRepo) ChangePost(post *models.Post, editor string) (models.Post, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.posts[post.ID]
+	if !ok || p.Deleted {
+		return models.Post{}, customErr.PostNotFound(post.ID)
+	}
+	if post.Version != 0 && post.Version != p.Version {
+		return models.Post{}, customErr.Stale(post.Version)
+	}
+	if post.Message != "" && post.Message != p.Message {
+		revisions := s.revisions[p.ID]
+		if len(revisions) == 0 {
+			revisions = append(revisions, models.PostRevision{Post: p.ID, Revision: 1, Message: p.Message, Editor: p.Author, Created: p.Created})
+		}
+		revisions = append(revisions, models.PostRevision{
+			Post:     p.ID,
+			Revision: len(revisions) + 1,
+			Message:  post.Message,
+			Editor:   editor,
+			Created:  strfmt.DateTime(time.Now()),
+		})
+		s.revisions[p.ID] = revisions
+		p.Message = post.Message
+		p.IsEdited = true
+		p.Version++
+	}
+	tree := post.Tree
+	*post = *p
+	post.Tree = tree
+	return *post, nil
+}
+
+func (r *PostRepo) GetRevisions(id uint64) ([]models.PostRevision, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return append([]models.PostRevision{}, s.revisions[id]...), nil
+}
+
+func (r *PostRepo) DeletePost(id uint64) (models.Post, error) {
+	return r.setDeleted(id, true)
+}
+
+func (r *PostRepo) RestorePost(id uint64) (models.Post, error) {
+	return r.setDeleted(id, false)
+}
+
+func (r *PostRepo) setDeleted(id uint64, deleted bool) (models.Post, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	p, ok := s.posts[id]
+	if !ok {
+		return models.Post{}, customErr.PostNotFound(id)
+	}
+	if p.Deleted != deleted {
+		p.Deleted = deleted
+		p.Version++
+		if f, ok := s.forums[key(p.Forum)]; ok {
+			if deleted {
+				f.Posts--
+			} else {
+				f.Posts++
+			}
+		}
+	}
+	return *p, nil
+}
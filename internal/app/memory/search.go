@@ -0,0 +1,168 @@
+package memory
+
+import (
+	"DBForum/internal/app/models"
+	"DBForum/internal/app/search"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+)
+
+var _ search.Repository = (*SearchRepo)(nil)
+
+// SearchRepo approximates the tsvector search: a document matches when it
+// contains every word of the query, and ranks by how often they occur,
+// thread titles counting more than messages.
+type SearchRepo struct {
+	store *Store
+}
+
+func NewSearchRepo(store *Store) *SearchRepo {
+	return &SearchRepo{
+		store: store,
+	}
+}
+
+func (r *SearchRepo) Search(query models.SearchQuery) ([]models.SearchResult, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	terms := words(query.Query)
+	matches := func(forum string, author string, created time.Time) bool {
+		if query.Forum != "" && key(forum) != key(query.Forum) {
+			return false
+		}
+		if query.Author != "" && key(author) != key(query.Author) {
+			return false
+		}
+		return query.Since == nil || !created.Before(*query.Since)
+	}
+
+	var results []models.SearchResult
+	for _, p := range s.posts {
+		if p.Deleted || !matches(p.Forum, p.Author, time.Time(p.Created)) {
+			continue
+		}
+		if rank := rankText(terms, p.Message, 0.1); rank > 0 {
+			results = append(results, models.SearchResult{
+				Kind: models.SearchKindPost, ID: p.ID, Thread: p.Thread, Forum: p.Forum, Author: p.Author,
+				Snippet: highlight(terms, p.Message), Created: time.Time(p.Created), Rank: rank,
+			})
+		}
+	}
+	for _, t := range s.threads {
+		if !matches(t.Forum, t.Author, t.Created) {
+			continue
+		}
+		if !containsAll(terms, t.Title+" "+t.Message) {
+			continue
+		}
+		results = append(results, models.SearchResult{
+			Kind: models.SearchKindThread, ID: t.ID, Thread: t.ID, Forum: t.Forum, Author: t.Author, Title: t.Title,
+			Snippet: highlight(terms, t.Message), Created: t.Created,
+			Rank: countTerms(terms, t.Title)*1.0 + countTerms(terms, t.Message)*0.4,
+		})
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return before(results[i], results[j])
+	})
+	if query.After != nil && query.Before {
+		i := sort.Search(len(results), func(i int) bool {
+			return !before(results[i], *query.After)
+		})
+		results = results[:i]
+	} else if query.After != nil {
+		i := sort.Search(len(results), func(i int) bool {
+			return before(*query.After, results[i])
+		})
+		results = results[i:]
+	}
+	first, last := bounds(len(results), query.Limit, query.After != nil && query.Before)
+	return results[first:last], nil
+}
+
+// before orders results like the SQL query: rank descending, then kind and id.
+func before(a models.SearchResult, b models.SearchResult) bool {
+	if a.Rank != b.Rank {
+		return a.Rank > b.Rank
+	}
+	if a.Kind != b.Kind {
+		return a.Kind < b.Kind
+	}
+	return a.ID < b.ID
+}
+
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+func countTerms(terms []string, text string) float32 {
+	var n float32
+	for _, word := range words(text) {
+		for _, term := range terms {
+			if word == term {
+				n++
+			}
+		}
+	}
+	return n
+}
+
+func containsAll(terms []string, text string) bool {
+	present := map[string]bool{}
+	for _, word := range words(text) {
+		present[word] = true
+	}
+	for _, term := range terms {
+		if !present[term] {
+			return false
+		}
+	}
+	return len(terms) != 0
+}
+
+func rankText(terms []string, text string, weight float32) float32 {
+	if !containsAll(terms, text) {
+		return 0
+	}
+	return countTerms(terms, text) * weight
+}
+
+// highlight wraps the words of text that match terms in <b></b>, like
+// ts_headline.
+func highlight(terms []string, text string) string {
+	var b strings.Builder
+	start := -1
+	flush := func(end int) {
+		word := text[start:end]
+		for _, term := range terms {
+			if strings.ToLower(word) == term {
+				b.WriteString("<b>" + word + "</b>")
+				return
+			}
+		}
+		b.WriteString(word)
+	}
+	for i, r := range text {
+		inWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if inWord && start < 0 {
+			start = i
+		}
+		if !inWord {
+			if start >= 0 {
+				flush(i)
+				start = -1
+			}
+			b.WriteRune(r)
+		}
+	}
+	if start >= 0 {
+		flush(len(text))
+	}
+	return b.String()
+}
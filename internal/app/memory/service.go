@@ -0,0 +1,46 @@
+package memory
+
+import (
+	"DBForum/internal/app/models"
+	"DBForum/internal/app/service"
+)
+
+var _ service.Repository = (*ServiceRepo)(nil)
+
+type ServiceRepo struct {
+	store *Store
+}
+
+func NewServiceRepo(store *Store) *ServiceRepo {
+	return &ServiceRepo{
+		store: store,
+	}
+}
+
+func (r *ServiceRepo) ClearDB() error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.reset()
+	return nil
+}
+
+func (r *ServiceRepo) Status() (models.NumRecords, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var posts uint64
+	for _, p := range s.posts {
+		if !p.Deleted {
+			posts++
+		}
+	}
+	return models.NumRecords{
+		User:   uint64(len(s.users)),
+		Forum:  uint64(len(s.forums)),
+		Thread: uint64(len(s.threads)),
+		Post:   posts,
+	}, nil
+}
@@ -0,0 +1,121 @@
+// Package memory implements the forum repositories on top of an in-process
+// store, for tests that shouldn't need Postgres. It mirrors the behaviour of
+// the SQL repositories and of the triggers in db/migrations: citext matching
+// of nicknames and slugs, the materialized post tree and the forum counters.
+package memory
+
+import (
+	"DBForum/internal/app/models"
+	"strings"
+	"sync"
+)
+
+type voteKey struct {
+	threadID uint64
+	nickname string
+}
+
+type idempotencyKey struct {
+	scope string
+	key   string
+}
+
+type Store struct {
+	mu sync.RWMutex
+
+	users      map[string]*models.User
+	userOrder  []string
+	forums     map[string]*models.Forum
+	forumOrder []string
+	threads    map[uint64]*models.Thread
+	posts      map[uint64]*models.Post
+	revisions  map[uint64][]models.PostRevision
+	votes      map[voteKey]int
+	forumUsers map[string]map[string]models.User
+	passwords  map[string]string
+	tokens     map[string]models.Session
+	admins     map[string]bool
+	forumRoles map[string]map[string]models.ForumRole
+	auditLog   []models.AuditEntry
+	idemKeys   map[idempotencyKey]models.IdempotencyKey
+
+	nextThreadID uint64
+	nextPostID   uint64
+}
+
+func NewStore() *Store {
+	s := &Store{}
+	s.reset()
+	return s
+}
+
+// reset drops all rows but, like TRUNCATE without RESTART IDENTITY, keeps
+// the id sequences going.
+func (s *Store) reset() {
+	s.users = map[string]*models.User{}
+	s.userOrder = nil
+	s.forums = map[string]*models.Forum{}
+	s.forumOrder = nil
+	s.threads = map[uint64]*models.Thread{}
+	s.posts = map[uint64]*models.Post{}
+	s.revisions = map[uint64][]models.PostRevision{}
+	s.votes = map[voteKey]int{}
+	s.forumUsers = map[string]map[string]models.User{}
+	s.passwords = map[string]string{}
+	s.tokens = map[string]models.Session{}
+	s.admins = map[string]bool{}
+	s.forumRoles = map[string]map[string]models.ForumRole{}
+	s.idemKeys = map[idempotencyKey]models.IdempotencyKey{}
+}
+
+// key folds a citext value the way Postgres compares it.
+func key(s string) string {
+	return strings.ToLower(s)
+}
+
+func (s *Store) userByNick(nickname string) (*models.User, bool) {
+	u, ok := s.users[key(nickname)]
+	return u, ok
+}
+
+func (s *Store) threadBySlug(slug string) (*models.Thread, bool) {
+	if slug == "" {
+		return nil, false
+	}
+	for _, t := range s.threads {
+		if key(t.Slug) == key(slug) {
+			return t, true
+		}
+	}
+	return nil, false
+}
+
+// addForumUser is the insert_forum_user trigger: it copies the author's
+// profile into the forum's user list unless it's already there.
+func (s *Store) addForumUser(forumSlug string, nickname string) {
+	u, ok := s.userByNick(nickname)
+	if !ok {
+		return
+	}
+	fk := key(forumSlug)
+	if s.forumUsers[fk] == nil {
+		s.forumUsers[fk] = map[string]models.User{}
+	}
+	if _, ok := s.forumUsers[fk][key(nickname)]; ok {
+		return
+	}
+	s.forumUsers[fk][key(nickname)] = *u
+}
+
+// bounds returns the range of a page of limit rows out of n rows sorted in
+// list order: the first rows, or the last ones for a page read backwards
+// from a position.
+func bounds(n int, limit int, before bool) (int, int) {
+	if n <= limit {
+		return 0, n
+	}
+	if before {
+		return n - limit, n
+	}
+	return 0, limit
+}
@@ -0,0 +1,324 @@
+package memory
+
+import (
+	customErr "DBForum/internal/app/errors"
+	"DBForum/internal/app/models"
+	"DBForum/internal/app/thread"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var _ thread.Repository = (*ThreadRepo)(nil)
+
+type ThreadRepo struct {
+	store *Store
+}
+
+func NewThreadRepo(store *Store) *ThreadRepo {
+	return &ThreadRepo{
+		store: store,
+	}
+}
+
+func (r *ThreadRepo) CreateThread(thread *models.Thread) (*models.Thread, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.threadBySlug(thread.Slug); ok {
+		*thread = *existing
+		return thread, customErr.ErrDuplicate
+	}
+	f, ok := s.forums[key(thread.Forum)]
+	if !ok {
+		return nil, customErr.ForumNotFound(thread.Forum)
+	}
+	thread.Forum = f.Slug
+	u, ok := s.userByNick(thread.Author)
+	if !ok {
+		return nil, customErr.UserNotFound(thread.Author)
+	}
+	thread.Author = u.Nickname
+
+	s.nextThreadID++
+	thread.ID = s.nextThreadID
+	thread.Version = 1
+	stored := *thread
+	stored.Votes = 0
+	s.threads[thread.ID] = &stored
+
+	f.Threads++
+	s.addForumUser(f.Slug, u.Nickname)
+	return thread, nil
+}
+
+func (r *ThreadRepo) FindThreadBySlug(threadSlug string) (*models.Thread, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.threadBySlug(threadSlug)
+	if !ok {
+		return nil, customErr.ThreadNotFound(threadSlug)
+	}
+	found := *t
+	return &found, nil
+}
+
+func (r *ThreadRepo) FindThreadByID(id uint64) (*models.Thread, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	t, ok := s.threads[id]
+	if !ok {
+		return nil, customErr.ThreadNotFound(strconv.FormatUint(id, 10))
+	}
+	found := *t
+	return &found, nil
+}
+
+func (r *ThreadRepo) GetForumThreads(forumSlug string, limit int, since string, from *models.Position, desc bool) ([]models.Thread, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.forums[key(forumSlug)]; !ok {
+		return nil, customErr.ForumNotFound(forumSlug)
+	}
+	threads, err := s.listThreads(func(t *models.Thread) bool {
+		return key(t.Forum) == key(forumSlug)
+	}, true, limit, since, from, desc)
+	if len(threads) == 0 {
+		return nil, err
+	}
+	return threads, err
+}
+
+func (r *ThreadRepo) GetUserThreads(nickname string, forumSlug string, limit int, since string, from *models.Position, desc bool) ([]models.Thread, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.listThreads(func(t *models.Thread) bool {
+		return key(t.Author) == key(nickname) && (forumSlug == "" || key(t.Forum) == key(forumSlug))
+	}, false, limit, since, from, desc)
+}
+
+// listThreads pages the threads that match like the SQL lists: by created
+// and id, pinned threads first when pinned is set. since keeps the threads
+// created since then, and all pinned ones; from reads the page from a cursor
+// position.
+func (s *Store) listThreads(match func(t *models.Thread) bool, pinned bool, limit int, since string, from *models.Position, desc bool) ([]models.Thread, error) {
+	less := func(a *models.Thread, b *models.Thread) bool {
+		if pinned && a.Pinned != b.Pinned {
+			return a.Pinned
+		}
+		if !a.Created.Equal(b.Created) {
+			if desc {
+				return a.Created.After(b.Created)
+			}
+			return a.Created.Before(b.Created)
+		}
+		if desc {
+			return a.ID > b.ID
+		}
+		return a.ID < b.ID
+	}
+
+	var sinceTime time.Time
+	var position *models.Thread
+	if from != nil {
+		created, err := time.Parse(time.RFC3339Nano, from.Key)
+		if err != nil {
+			return nil, err
+		}
+		position = &models.Thread{ID: from.ID, Created: created, Pinned: from.Pinned}
+	} else if since != "" {
+		var err error
+		if sinceTime, err = time.Parse(time.RFC3339Nano, since); err != nil {
+			return nil, err
+		}
+	}
+
+	threads := make([]models.Thread, 0)
+	for _, t := range s.threads {
+		if !match(t) {
+			continue
+		}
+		if position != nil {
+			if !from.Before && !less(position, t) || from.Before && !less(t, position) {
+				continue
+			}
+		} else if since != "" && !(pinned && t.Pinned) {
+			if desc && t.Created.After(sinceTime) {
+				continue
+			}
+			if !desc && t.Created.Before(sinceTime) {
+				continue
+			}
+		}
+		threads = append(threads, *t)
+	}
+	sort.Slice(threads, func(i, j int) bool {
+		return less(&threads[i], &threads[j])
+	})
+	first, last := bounds(len(threads), limit, from != nil && from.Before)
+	return threads[first:last], nil
+}
+
+func (r *ThreadRepo) UpdateThreadBySlug(threadSlug string, thread models.Thread, status models.ThreadStatus) (models.Thread, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.threadBySlug(threadSlug)
+	if !ok {
+		return models.Thread{}, customErr.ThreadNotFound(threadSlug)
+	}
+	return updateThread(t, thread, status)
+}
+
+func (r *ThreadRepo) UpdateThreadByID(threadID uint64, thread models.Thread, status models.ThreadStatus) (models.Thread, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.threads[threadID]
+	if !ok {
+		return models.Thread{}, customErr.ThreadNotFound(strconv.FormatUint(threadID, 10))
+	}
+	return updateThread(t, thread, status)
+}
+
+// updateThread applies an update made against thread.Version, if set, and
+// bumps the version like the trigger does when the row changes.
+func updateThread(stored *models.Thread, thread models.Thread, status models.ThreadStatus) (models.Thread, error) {
+	if thread.Version != 0 && thread.Version != stored.Version {
+		return models.Thread{}, customErr.Stale(thread.Version)
+	}
+	before := *stored
+	if thread.Title != "" {
+		stored.Title = thread.Title
+	}
+	if thread.Message != "" {
+		stored.Message = thread.Message
+	}
+	if status.Locked != nil {
+		stored.Locked = *status.Locked
+	}
+	if status.Pinned != nil {
+		stored.Pinned = *status.Pinned
+	}
+	if status.Closed != nil {
+		stored.Closed = *status.Closed
+	}
+	if *stored != before {
+		stored.Version++
+	}
+	return *stored, nil
+}
+
+func (r *ThreadRepo) VoteThreadByID(idOrSlug string, vote models.Vote) (models.Thread, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.threadByIDOrSlug(idOrSlug)
+	if !ok {
+		return models.Thread{}, customErr.ThreadNotFound(idOrSlug)
+	}
+	if t.Archived {
+		return models.Thread{}, customErr.ThreadArchived(idOrSlug)
+	}
+	if t.Closed {
+		return models.Thread{}, customErr.ThreadClosed(idOrSlug)
+	}
+
+	vk := voteKey{threadID: t.ID, nickname: key(vote.Nickname)}
+	current, voted := s.votes[vk]
+	if !voted {
+		if _, ok := s.userByNick(vote.Nickname); !ok {
+			return models.Thread{}, customErr.UserNotFound(vote.Nickname)
+		}
+	}
+	if voted && current == vote.Voice {
+		return *t, nil
+	}
+	t.Votes += vote.Voice - current
+	s.votes[vk] = vote.Voice
+	return *t, nil
+}
+
+func (r *ThreadRepo) DeleteThread(idOrSlug string) (models.Thread, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.threadByIDOrSlug(idOrSlug)
+	if !ok {
+		return models.Thread{}, customErr.ThreadNotFound(idOrSlug)
+	}
+	for vk := range s.votes {
+		if vk.threadID == t.ID {
+			delete(s.votes, vk)
+		}
+	}
+	var livePosts uint64
+	authors := []string{t.Author}
+	for id, p := range s.posts {
+		if p.Thread != t.ID {
+			continue
+		}
+		if !p.Deleted {
+			livePosts++
+		}
+		authors = append(authors, p.Author)
+		delete(s.posts, id)
+		delete(s.revisions, id)
+	}
+	delete(s.threads, t.ID)
+
+	f := s.forums[key(t.Forum)]
+	f.Threads--
+	f.Posts -= livePosts
+	for _, author := range authors {
+		if !s.hasActivity(t.Forum, author) {
+			delete(s.forumUsers[key(t.Forum)], key(author))
+		}
+	}
+	return *t, nil
+}
+
+// hasActivity reports whether nickname still has a thread or a post in the
+// forum, i.e. whether it still belongs in the forum's user list.
+func (s *Store) hasActivity(forumSlug string, nickname string) bool {
+	for _, t := range s.threads {
+		if key(t.Forum) == key(forumSlug) && key(t.Author) == key(nickname) {
+			return true
+		}
+	}
+	for _, p := range s.posts {
+		if key(p.Forum) == key(forumSlug) && key(p.Author) == key(nickname) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *ThreadRepo) SetThreadArchived(idOrSlug string, archived bool) (models.Thread, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	t, ok := s.threadByIDOrSlug(idOrSlug)
+	if !ok {
+		return models.Thread{}, customErr.ThreadNotFound(idOrSlug)
+	}
+	if t.Archived != archived {
+		t.Archived = archived
+		t.Version++
+	}
+	return *t, nil
+}
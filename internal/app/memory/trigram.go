@@ -0,0 +1,35 @@
+package memory
+
+// similarityThreshold is pg_trgm.similarity_threshold's default.
+const similarityThreshold = 0.3
+
+// trigrams splits s into words and returns their trigrams the way pg_trgm
+// does: lower-cased, each word padded with two spaces in front and one
+// behind.
+func trigrams(s string) map[string]bool {
+	set := map[string]bool{}
+	for _, word := range words(s) {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			set[string(padded[i:i+3])] = true
+		}
+	}
+	return set
+}
+
+// similarity mirrors pg_trgm's similarity(): shared trigrams over all
+// distinct trigrams of both strings.
+func similarity(a string, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	shared := 0
+	for t := range ta {
+		if tb[t] {
+			shared++
+		}
+	}
+	total := len(ta) + len(tb) - shared
+	if total == 0 {
+		return 0
+	}
+	return float64(shared) / float64(total)
+}
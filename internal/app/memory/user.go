@@ -0,0 +1,229 @@
+package memory
+
+import (
+	customErr "DBForum/internal/app/errors"
+	"DBForum/internal/app/models"
+	"DBForum/internal/app/user"
+	"sort"
+	"strings"
+)
+
+var _ user.Repository = (*UserRepo)(nil)
+
+type UserRepo struct {
+	store *Store
+}
+
+func NewUserRepo(store *Store) *UserRepo {
+	return &UserRepo{
+		store: store,
+	}
+}
+
+func (r *UserRepo) GetForumUsers(forumSlug string, limit int, since string, from *models.Position, desc bool) ([]models.User, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if _, ok := s.forums[key(forumSlug)]; !ok {
+		return nil, customErr.ForumNotFound(forumSlug)
+	}
+	before := false
+	if from != nil {
+		since = from.Key
+		before = from.Before
+	}
+	var users []models.User
+	for _, u := range s.forumUsers[key(forumSlug)] {
+		if since != "" {
+			after := key(u.Nickname) > key(since)
+			if desc {
+				after = key(u.Nickname) < key(since)
+			}
+			if key(u.Nickname) == key(since) || after == before {
+				continue
+			}
+		}
+		users = append(users, u)
+	}
+	sort.Slice(users, func(i, j int) bool {
+		if desc {
+			return key(users[i].Nickname) > key(users[j].Nickname)
+		}
+		return key(users[i].Nickname) < key(users[j].Nickname)
+	})
+	first, last := bounds(len(users), limit, before)
+	return users[first:last], nil
+}
+
+func (r *UserRepo) CreateUser(user models.User, passwordHash string) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.userByNick(user.Nickname); ok {
+		return customErr.ErrDuplicate
+	}
+	for _, u := range s.users {
+		if key(u.Email) == key(user.Email) {
+			return customErr.ErrDuplicate
+		}
+	}
+	user.Version = 1
+	s.users[key(user.Nickname)] = &user
+	s.userOrder = append(s.userOrder, key(user.Nickname))
+	if passwordHash != "" {
+		s.passwords[key(user.Nickname)] = passwordHash
+	}
+	return nil
+}
+
+func (r *UserRepo) GetUsersByNickAndEmail(nickname string, email string) ([]models.User, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var users []models.User
+	for _, k := range s.userOrder {
+		u := s.users[k]
+		if key(u.Nickname) == key(nickname) || key(u.Email) == key(email) {
+			users = append(users, *u)
+		}
+	}
+	return users, nil
+}
+
+func (r *UserRepo) GetUserByNick(nickname string) (*models.User, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	u, ok := s.userByNick(nickname)
+	if !ok {
+		return nil, customErr.UserNotFound(nickname)
+	}
+	found := *u
+	return &found, nil
+}
+
+func (r *UserRepo) ChangeUser(user *models.User) error {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, ok := s.userByNick(user.Nickname)
+	if !ok {
+		return customErr.UserNotFound(user.Nickname)
+	}
+	if user.Version != 0 && user.Version != u.Version {
+		return customErr.Stale(user.Version)
+	}
+	if user.Email != "" {
+		for _, other := range s.users {
+			if other != u && key(other.Email) == key(user.Email) {
+				return customErr.ErrConflict
+			}
+		}
+	}
+	before := *u
+	if user.Fullname != "" {
+		u.Fullname = user.Fullname
+	}
+	if user.About != "" {
+		u.About = user.About
+	}
+	if user.Email != "" {
+		u.Email = user.Email
+	}
+	if *u != before {
+		u.Version++
+	}
+	for _, users := range s.forumUsers {
+		if _, ok := users[key(u.Nickname)]; ok {
+			users[key(u.Nickname)] = *u
+		}
+	}
+	*user = *u
+	return nil
+}
+
+func (r *UserRepo) RepairForumUsers() (int64, error) {
+	s := r.store
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var repaired int64
+	for _, users := range s.forumUsers {
+		for nick, copied := range users {
+			u, ok := s.users[nick]
+			if ok && copied != *u {
+				users[nick] = *u
+				repaired++
+			}
+		}
+	}
+	return repaired, nil
+}
+
+func (r *UserRepo) GetUserNickByEmail(email string) (string, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, u := range s.users {
+		if key(u.Email) == key(email) {
+			return u.Nickname, nil
+		}
+	}
+	return "", customErr.ErrUserNotFound
+}
+
+func (r *UserRepo) SearchUsers(prefix string, fuzzy bool, limit int) ([]models.User, error) {
+	s := r.store
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	activity := map[string]int{}
+	for _, p := range s.posts {
+		if !p.Deleted {
+			activity[key(p.Author)]++
+		}
+	}
+	for _, t := range s.threads {
+		activity[key(t.Author)]++
+	}
+
+	var users []models.User
+	similar := map[string]float64{}
+	for _, u := range s.users {
+		prefixed := strings.HasPrefix(key(u.Nickname), key(prefix))
+		if !fuzzy {
+			if prefixed {
+				users = append(users, *u)
+			}
+			continue
+		}
+		score := similarity(u.Nickname, prefix)
+		if fullname := similarity(u.Fullname, prefix); fullname > score {
+			score = fullname
+		}
+		if prefixed || score >= similarityThreshold {
+			similar[key(u.Nickname)] = score
+			users = append(users, *u)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool {
+		a, b := key(users[i].Nickname), key(users[j].Nickname)
+		if similar[a] != similar[b] {
+			return similar[a] > similar[b]
+		}
+		if activity[a] != activity[b] {
+			return activity[a] > activity[b]
+		}
+		return a < b
+	})
+	if len(users) > limit {
+		users = users[:limit]
+	}
+	return users, nil
+}
@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	customErr "DBForum/internal/app/errors"
+	"DBForum/internal/app/middleware"
+	"github.com/jackc/pgx"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttpadaptor"
+	"strconv"
+	"time"
+)
+
+const (
+	namespace = "dbforum"
+	errorKey  = "metrics.error"
+)
+
+type Metrics struct {
+	registry *prometheus.Registry
+	requests *prometheus.CounterVec
+	latency  *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+}
+
+// New creates a registry with the HTTP metrics, the Go runtime metrics and
+// the stats of db.
+func New(db *pgx.ConnPool) *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "HTTP requests by route pattern, method and status.",
+		}, []string{"route", "method", "status"}),
+		latency: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "HTTP request latency by route pattern and method.",
+			Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"route", "method"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "handler_errors_total",
+			Help:      "Errors returned to handlers by the use cases, by route pattern and kind.",
+		}, []string{"route", "error"}),
+	}
+	m.registry.MustRegister(
+		m.requests,
+		m.latency,
+		m.errors,
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		newPoolCollector(db),
+	)
+	return m
+}
+
+// Handler serves the registry in the Prometheus text format.
+func (m *Metrics) Handler() fasthttp.RequestHandler {
+	return fasthttpadaptor.NewFastHTTPHandler(promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+}
+
+// Middleware records the request count and latency under the matched route
+// pattern, and the error passed to ObserveError, if any.
+func (m *Metrics) Middleware(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		start := time.Now()
+		next(ctx)
+
+		route := middleware.Route(ctx)
+		method := string(ctx.Method())
+		m.requests.WithLabelValues(route, method, strconv.Itoa(ctx.Response.StatusCode())).Inc()
+		m.latency.WithLabelValues(route, method).Observe(time.Since(start).Seconds())
+		if err := Error(ctx); err != nil {
+			m.errors.WithLabelValues(route, customErr.Code(err)).Inc()
+		}
+	}
+}
+
+// ObserveError remembers the error a request was answered with so the
+// middleware can count it by code. Nil errors are ignored.
+func ObserveError(ctx *fasthttp.RequestCtx, err error) {
+	if err != nil {
+		ctx.SetUserValue(errorKey, err)
+	}
+}
+
+// Error is the error passed to ObserveError for the request, or nil.
+func Error(ctx *fasthttp.RequestCtx) error {
+	err, _ := ctx.UserValue(errorKey).(error)
+	return err
+}
@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	customErr "DBForum/internal/app/errors"
+	"github.com/fasthttp/router"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/valyala/fasthttp"
+	"testing"
+)
+
+func TestMiddleware(t *testing.T) {
+	m := New(nil)
+	r := router.New()
+	r.SaveMatchedRoutePath = true
+	r.GET("/api/post/{id}/details", func(ctx *fasthttp.RequestCtx) {
+		ObserveError(ctx, errors.Wrap(customErr.ErrPostNotFound, "42"))
+		ctx.SetStatusCode(fasthttp.StatusNotFound)
+	})
+	h := m.Middleware(r.Handler)
+
+	for _, path := range []string{"/api/post/1/details", "/api/post/2/details", "/nowhere"} {
+		ctx := &fasthttp.RequestCtx{}
+		ctx.Request.SetRequestURI(path)
+		h(ctx)
+	}
+
+	route := "/api/post/{id}/details"
+	if got := testutil.ToFloat64(m.requests.WithLabelValues(route, "GET", "404")); got != 2 {
+		t.Errorf("requests for %s = %v, want 2", route, got)
+	}
+	if got := testutil.ToFloat64(m.requests.WithLabelValues("unmatched", "GET", "404")); got != 1 {
+		t.Errorf("unmatched requests = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(m.errors.WithLabelValues(route, "post_not_found")); got != 2 {
+		t.Errorf("post_not_found errors = %v, want 2", got)
+	}
+	if got := testutil.CollectAndCount(m.latency); got != 2 {
+		t.Errorf("latency series = %d, want 2", got)
+	}
+}
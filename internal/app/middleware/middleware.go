@@ -0,0 +1,97 @@
+package middleware
+
+import (
+	"DBForum/internal/app/logger"
+	"crypto/rand"
+	"encoding/hex"
+	"github.com/fasthttp/router"
+	"github.com/sirupsen/logrus"
+	"github.com/valyala/fasthttp"
+	"time"
+)
+
+const (
+	RequestIDHeader = "X-Request-ID"
+
+	maxRequestIDLength = 128
+)
+
+type Middleware func(next fasthttp.RequestHandler) fasthttp.RequestHandler
+
+// Chain wraps h so that the first middleware is the outermost one.
+func Chain(h fasthttp.RequestHandler, middlewares ...Middleware) fasthttp.RequestHandler {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		h = middlewares[i](h)
+	}
+	return h
+}
+
+// RequestID keeps the caller's X-Request-ID if it looks sane and generates
+// one otherwise. The ID is echoed in the response.
+func RequestID(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		id := string(ctx.Request.Header.Peek(RequestIDHeader))
+		if !validRequestID(id) {
+			id = newRequestID()
+		}
+		logger.SetRequestID(ctx, id)
+		ctx.Response.Header.Set(RequestIDHeader, id)
+		next(ctx)
+	}
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		ok := c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == ':'
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// Route is the router pattern that matched ctx, e.g. /api/thread/{slug_or_id}/posts.
+// It needs Router.SaveMatchedRoutePath.
+func Route(ctx *fasthttp.RequestCtx) string {
+	if route, ok := ctx.UserValue(router.MatchedRoutePathParam).(string); ok {
+		return route
+	}
+	return "unmatched"
+}
+
+// AccessLog writes one structured line per request.
+func AccessLog(next fasthttp.RequestHandler) fasthttp.RequestHandler {
+	return func(ctx *fasthttp.RequestCtx) {
+		start := time.Now()
+		next(ctx)
+
+		status := ctx.Response.StatusCode()
+		entry := logger.FromCtx(ctx).WithFields(logrus.Fields{
+			"method":     string(ctx.Method()),
+			"route":      Route(ctx),
+			"path":       string(ctx.Path()),
+			"status":     status,
+			"latency_ms": float64(time.Since(start).Microseconds()) / 1000,
+			"size":       len(ctx.Response.Body()),
+			"remote":     ctx.RemoteIP().String(),
+		})
+		switch {
+		case status >= fasthttp.StatusInternalServerError:
+			entry.Error("request")
+		case status >= fasthttp.StatusBadRequest:
+			entry.Warn("request")
+		default:
+			entry.Info("request")
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"DBForum/internal/app/logger"
+	"bytes"
+	"encoding/json"
+	"github.com/fasthttp/router"
+	"github.com/sirupsen/logrus"
+	"github.com/valyala/fasthttp"
+	"testing"
+)
+
+func serve(t *testing.T, h fasthttp.RequestHandler, requestID string) *fasthttp.RequestCtx {
+	t.Helper()
+	ctx := &fasthttp.RequestCtx{}
+	ctx.Request.SetRequestURI("/api/thread/42/details")
+	ctx.Request.Header.SetMethod(fasthttp.MethodGet)
+	if requestID != "" {
+		ctx.Request.Header.Set(RequestIDHeader, requestID)
+	}
+	h(ctx)
+	return ctx
+}
+
+func TestRequestID(t *testing.T) {
+	var seen string
+	h := RequestID(func(ctx *fasthttp.RequestCtx) {
+		seen = logger.RequestID(ctx)
+	})
+
+	ctx := serve(t, h, "abc-123")
+	if seen != "abc-123" || string(ctx.Response.Header.Peek(RequestIDHeader)) != "abc-123" {
+		t.Errorf("request ID = %q, header %q, want the caller's abc-123", seen, ctx.Response.Header.Peek(RequestIDHeader))
+	}
+
+	for _, bad := range []string{"", "has space", string(bytes.Repeat([]byte("a"), maxRequestIDLength+1))} {
+		ctx = serve(t, h, bad)
+		if seen == "" || seen == bad || len(seen) != 32 {
+			t.Errorf("for %q got request ID %q, want a generated one", bad, seen)
+		}
+		if got := string(ctx.Response.Header.Peek(RequestIDHeader)); got != seen {
+			t.Errorf("response header = %q, want %q", got, seen)
+		}
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+	std := logrus.StandardLogger()
+	out, formatter := std.Out, std.Formatter
+	std.SetOutput(&buf)
+	std.SetFormatter(&logrus.JSONFormatter{})
+	defer func() {
+		std.SetOutput(out)
+		std.SetFormatter(formatter)
+	}()
+
+	r := router.New()
+	r.SaveMatchedRoutePath = true
+	r.GET("/api/thread/{slug_or_id}/details", func(ctx *fasthttp.RequestCtx) {
+		ctx.SetStatusCode(fasthttp.StatusNotFound)
+		ctx.SetBodyString(`{"message":"no"}`)
+	})
+	serve(t, Chain(r.Handler, RequestID, AccessLog), "req-1")
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("access log %q is not JSON: %v", buf.String(), err)
+	}
+	want := map[string]interface{}{
+		"request_id": "req-1",
+		"method":     "GET",
+		"route":      "/api/thread/{slug_or_id}/details",
+		"path":       "/api/thread/42/details",
+		"status":     float64(404),
+		"size":       float64(16),
+		"level":      "warning",
+	}
+	for k, v := range want {
+		if line[k] != v {
+			t.Errorf("%s = %v, want %v", k, line[k], v)
+		}
+	}
+	if _, ok := line["latency_ms"]; !ok {
+		t.Error("latency_ms missing")
+	}
+}
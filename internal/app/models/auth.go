@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+)
+
+//easyjson:json
+type Credentials struct {
+	Nickname string `json:"nickname,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+//easyjson:json
+type Session struct {
+	Token    string    `json:"token,omitempty"`
+	Nickname string    `json:"nickname,omitempty" db:"nickname"`
+	Expires  time.Time `json:"expires,omitempty" db:"expires"`
+	Admin    bool      `json:"-" db:"is_admin"`
+}
+
+// Actor is the user a request is made by. The zero Actor is an anonymous
+// caller.
+type Actor struct {
+	Nickname string
+	Admin    bool
+}
+
+// AuditEntry records a request that was refused with 403.
+type AuditEntry struct {
+	Actor     string    `db:"actor"`
+	Method    string    `db:"method"`
+	Route     string    `db:"route"`
+	Path      string    `db:"path"`
+	RequestID string    `db:"request_id"`
+	Created   time.Time `db:"created"`
+}
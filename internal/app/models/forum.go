@@ -1,5 +1,8 @@
 package models
 
+//easyjson:json
+type ForumList []Forum
+
 //easyjson:json
 type Forum struct {
 	ID      uint64 `json:"id,omitempty"`
@@ -9,3 +12,28 @@ type Forum struct {
 	Posts   uint64 `json:"posts" db:"posts"`
 	Threads uint64 `json:"threads" db:"threads"`
 }
+
+// Orders of the forum list; ForumSortCreated is creation order.
+const (
+	ForumSortCreated = "created"
+	ForumSortPosts   = "posts"
+	ForumSortThreads = "threads"
+	ForumSortTitle   = "title"
+)
+
+const (
+	RoleOwner     = "owner"
+	RoleModerator = "moderator"
+	RoleMember    = "member"
+	RoleBanned    = "banned"
+)
+
+//easyjson:json
+type ForumRoleList []ForumRole
+
+//easyjson:json
+type ForumRole struct {
+	Forum    string `json:"forum,omitempty" db:"forum_slug"`
+	Nickname string `json:"nickname,omitempty" db:"nickname"`
+	Role     string `json:"role,omitempty" db:"role"`
+}
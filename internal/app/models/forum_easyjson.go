@@ -17,7 +17,230 @@ var (
 	_ easyjson.Marshaler
 )
 
-func easyjsonC8d74561DecodeDBForumInternalAppModels(in *jlexer.Lexer, out *Forum) {
+func easyjsonC8d74561DecodeDBForumInternalAppModels(in *jlexer.Lexer, out *ForumRoleList) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		in.Skip()
+		*out = nil
+	} else {
+		in.Delim('[')
+		if *out == nil {
+			if !in.IsDelim(']') {
+				*out = make(ForumRoleList, 0, 1)
+			} else {
+				*out = ForumRoleList{}
+			}
+		} else {
+			*out = (*out)[:0]
+		}
+		for !in.IsDelim(']') {
+			var v1 ForumRole
+			(v1).UnmarshalEasyJSON(in)
+			*out = append(*out, v1)
+			in.WantComma()
+		}
+		in.Delim(']')
+	}
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonC8d74561EncodeDBForumInternalAppModels(out *jwriter.Writer, in ForumRoleList) {
+	if in == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+		out.RawString("null")
+	} else {
+		out.RawByte('[')
+		for v2, v3 := range in {
+			if v2 > 0 {
+				out.RawByte(',')
+			}
+			(v3).MarshalEasyJSON(out)
+		}
+		out.RawByte(']')
+	}
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v ForumRoleList) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonC8d74561EncodeDBForumInternalAppModels(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v ForumRoleList) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonC8d74561EncodeDBForumInternalAppModels(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *ForumRoleList) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonC8d74561DecodeDBForumInternalAppModels(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *ForumRoleList) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonC8d74561DecodeDBForumInternalAppModels(l, v)
+}
+func easyjsonC8d74561DecodeDBForumInternalAppModels1(in *jlexer.Lexer, out *ForumRole) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "forum":
+			out.Forum = string(in.String())
+		case "nickname":
+			out.Nickname = string(in.String())
+		case "role":
+			out.Role = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonC8d74561EncodeDBForumInternalAppModels1(out *jwriter.Writer, in ForumRole) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	if in.Forum != "" {
+		const prefix string = ",\"forum\":"
+		first = false
+		out.RawString(prefix[1:])
+		out.String(string(in.Forum))
+	}
+	if in.Nickname != "" {
+		const prefix string = ",\"nickname\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Nickname))
+	}
+	if in.Role != "" {
+		const prefix string = ",\"role\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Role))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v ForumRole) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonC8d74561EncodeDBForumInternalAppModels1(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v ForumRole) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonC8d74561EncodeDBForumInternalAppModels1(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *ForumRole) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonC8d74561DecodeDBForumInternalAppModels1(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *ForumRole) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonC8d74561DecodeDBForumInternalAppModels1(l, v)
+}
+func easyjsonC8d74561DecodeDBForumInternalAppModels2(in *jlexer.Lexer, out *ForumList) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		in.Skip()
+		*out = nil
+	} else {
+		in.Delim('[')
+		if *out == nil {
+			if !in.IsDelim(']') {
+				*out = make(ForumList, 0, 0)
+			} else {
+				*out = ForumList{}
+			}
+		} else {
+			*out = (*out)[:0]
+		}
+		for !in.IsDelim(']') {
+			var v4 Forum
+			(v4).UnmarshalEasyJSON(in)
+			*out = append(*out, v4)
+			in.WantComma()
+		}
+		in.Delim(']')
+	}
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjsonC8d74561EncodeDBForumInternalAppModels2(out *jwriter.Writer, in ForumList) {
+	if in == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+		out.RawString("null")
+	} else {
+		out.RawByte('[')
+		for v5, v6 := range in {
+			if v5 > 0 {
+				out.RawByte(',')
+			}
+			(v6).MarshalEasyJSON(out)
+		}
+		out.RawByte(']')
+	}
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v ForumList) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjsonC8d74561EncodeDBForumInternalAppModels2(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v ForumList) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjsonC8d74561EncodeDBForumInternalAppModels2(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *ForumList) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjsonC8d74561DecodeDBForumInternalAppModels2(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *ForumList) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjsonC8d74561DecodeDBForumInternalAppModels2(l, v)
+}
+func easyjsonC8d74561DecodeDBForumInternalAppModels3(in *jlexer.Lexer, out *Forum) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -58,7 +281,7 @@ func easyjsonC8d74561DecodeDBForumInternalAppModels(in *jlexer.Lexer, out *Forum
 		in.Consumed()
 	}
 }
-func easyjsonC8d74561EncodeDBForumInternalAppModels(out *jwriter.Writer, in Forum) {
+func easyjsonC8d74561EncodeDBForumInternalAppModels3(out *jwriter.Writer, in Forum) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -119,23 +342,23 @@ func easyjsonC8d74561EncodeDBForumInternalAppModels(out *jwriter.Writer, in Foru
 // MarshalJSON supports json.Marshaler interface
 func (v Forum) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjsonC8d74561EncodeDBForumInternalAppModels(&w, v)
+	easyjsonC8d74561EncodeDBForumInternalAppModels3(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v Forum) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjsonC8d74561EncodeDBForumInternalAppModels(w, v)
+	easyjsonC8d74561EncodeDBForumInternalAppModels3(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *Forum) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjsonC8d74561DecodeDBForumInternalAppModels(&r, v)
+	easyjsonC8d74561DecodeDBForumInternalAppModels3(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *Forum) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjsonC8d74561DecodeDBForumInternalAppModels(l, v)
+	easyjsonC8d74561DecodeDBForumInternalAppModels3(l, v)
 }
@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// IdempotencyKey is a creation request made with an Idempotency-Key header
+// and, once it has finished, the response to replay on a retry. Status is 0
+// while the request is running.
+type IdempotencyKey struct {
+	Scope       string    `db:"scope"`
+	Key         string    `db:"key"`
+	RequestHash string    `db:"request_hash"`
+	Status      int       `db:"status"`
+	ContentType string    `db:"content_type"`
+	Body        []byte    `db:"body"`
+	Created     time.Time `db:"created"`
+}
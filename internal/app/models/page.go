@@ -0,0 +1,60 @@
+package models
+
+import (
+	"strconv"
+	"time"
+)
+
+// Position is the row a page of a list starts from. Key is the sort value
+// of the row and ID breaks ties between rows with equal keys; Sort names
+// the order Key belongs to in lists with several. Pinned is only used by
+// forum thread lists, where pinned threads come first. A page holds the
+// rows strictly after the position, or strictly before it when Before is
+// set.
+type Position struct {
+	Key    string
+	ID     uint64
+	Sort   string
+	Pinned bool
+	Before bool
+}
+
+// Cursors are the opaque cursors of the pages around a page of a list,
+// empty when there is no such page.
+type Cursors struct {
+	Next string
+	Prev string
+}
+
+func (t Thread) Position() Position {
+	return Position{Key: t.Created.Format(time.RFC3339Nano), ID: t.ID, Pinned: t.Pinned}
+}
+
+// Position of a post is its id in every sort; tree sorts look the path up.
+func (p Post) Position() Position {
+	return Position{ID: p.ID}
+}
+
+func (u User) Position() Position {
+	return Position{Key: u.Nickname}
+}
+
+// Position of a forum holds its value of the sortBy column, ids of forums
+// being their creation order.
+func (f Forum) Position(sortBy string) Position {
+	switch sortBy {
+	case ForumSortPosts:
+		return Position{Key: strconv.FormatUint(f.Posts, 10), ID: f.ID, Sort: sortBy}
+	case ForumSortThreads:
+		return Position{Key: strconv.FormatUint(f.Threads, 10), ID: f.ID, Sort: sortBy}
+	case ForumSortTitle:
+		return Position{Key: f.Title, ID: f.ID, Sort: sortBy}
+	}
+	return Position{Key: strconv.FormatUint(f.ID, 10), ID: f.ID, Sort: ForumSortCreated}
+}
+
+// Position of a search result holds its rank and kind, ID breaking ties
+// between results of a kind.
+func (r SearchResult) Position() Position {
+	return Position{Key: strconv.FormatFloat(float64(r.Rank), 'g', -1, 32) + " " + r.Kind, ID: r.ID}
+}
@@ -19,8 +19,31 @@ type Post struct {
 	Thread   uint64          `json:"thread,omitempty" db:"thread_id"`
 	Tree     pq.Int64Array   `json:"-" db:"tree"`
 	Created  strfmt.DateTime `json:"created,omitempty" db:"created"`
+	Deleted  bool            `json:"deleted,omitempty" db:"is_deleted"`
+	Version  uint64          `json:"-" db:"version"`
+}
+
+// Tombstone hides the message of a deleted post. The post keeps its place
+// in the thread so its replies still have a parent.
+func (p *Post) Tombstone() {
+	if p.Deleted {
+		p.Message = ""
+	}
+}
+
+// PostNode is a post with its place in the thread tree: Depth is 0 for a
+// root post and Children counts its direct replies.
+//
+//easyjson:json
+type PostNode struct {
+	Post
+	Depth    int `json:"depth"`
+	Children int `json:"children"`
 }
 
+//easyjson:json
+type PostNodeList []PostNode
+
 //easyjson:json
 type PostInfo struct {
 	Post   *Post   `json:"post,omitempty"`
@@ -28,3 +51,33 @@ type PostInfo struct {
 	Thread *Thread `json:"thread,omitempty"`
 	Forum  *Forum  `json:"forum,omitempty"`
 }
+
+// PostRevision is a message a post had after an edit. Revision 1 is the
+// message the post was created with.
+//
+//easyjson:json
+type PostRevision struct {
+	Post     uint64          `json:"post"`
+	Revision int             `json:"revision"`
+	Message  string          `json:"message"`
+	Editor   string          `json:"editor,omitempty"`
+	Created  strfmt.DateTime `json:"created"`
+}
+
+//easyjson:json
+type PostRevisionList []PostRevision
+
+// Change is a run of text that two revisions share ("equal"), or that only
+// the older ("delete") or the newer one ("insert") has.
+type Change struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+//easyjson:json
+type PostDiff struct {
+	Post    uint64   `json:"post"`
+	From    int      `json:"from"`
+	To      int      `json:"to"`
+	Changes []Change `json:"changes"`
+}
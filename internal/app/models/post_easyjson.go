@@ -17,7 +17,373 @@ var (
 	_ easyjson.Marshaler
 )
 
-func easyjson5a72dc82DecodeDBForumInternalAppModels(in *jlexer.Lexer, out *PostList) {
+func easyjson5a72dc82DecodeDBForumInternalAppModels(in *jlexer.Lexer, out *PostRevisionList) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		in.Skip()
+		*out = nil
+	} else {
+		in.Delim('[')
+		if *out == nil {
+			if !in.IsDelim(']') {
+				*out = make(PostRevisionList, 0, 0)
+			} else {
+				*out = PostRevisionList{}
+			}
+		} else {
+			*out = (*out)[:0]
+		}
+		for !in.IsDelim(']') {
+			var v1 PostRevision
+			(v1).UnmarshalEasyJSON(in)
+			*out = append(*out, v1)
+			in.WantComma()
+		}
+		in.Delim(']')
+	}
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson5a72dc82EncodeDBForumInternalAppModels(out *jwriter.Writer, in PostRevisionList) {
+	if in == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+		out.RawString("null")
+	} else {
+		out.RawByte('[')
+		for v2, v3 := range in {
+			if v2 > 0 {
+				out.RawByte(',')
+			}
+			(v3).MarshalEasyJSON(out)
+		}
+		out.RawByte(']')
+	}
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v PostRevisionList) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson5a72dc82EncodeDBForumInternalAppModels(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v PostRevisionList) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson5a72dc82EncodeDBForumInternalAppModels(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *PostRevisionList) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson5a72dc82DecodeDBForumInternalAppModels(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *PostRevisionList) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson5a72dc82DecodeDBForumInternalAppModels(l, v)
+}
+func easyjson5a72dc82DecodeDBForumInternalAppModels1(in *jlexer.Lexer, out *PostRevision) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "post":
+			out.Post = uint64(in.Uint64())
+		case "revision":
+			out.Revision = int(in.Int())
+		case "message":
+			out.Message = string(in.String())
+		case "editor":
+			out.Editor = string(in.String())
+		case "created":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.Created).UnmarshalJSON(data))
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson5a72dc82EncodeDBForumInternalAppModels1(out *jwriter.Writer, in PostRevision) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"post\":"
+		out.RawString(prefix[1:])
+		out.Uint64(uint64(in.Post))
+	}
+	{
+		const prefix string = ",\"revision\":"
+		out.RawString(prefix)
+		out.Int(int(in.Revision))
+	}
+	{
+		const prefix string = ",\"message\":"
+		out.RawString(prefix)
+		out.String(string(in.Message))
+	}
+	if in.Editor != "" {
+		const prefix string = ",\"editor\":"
+		out.RawString(prefix)
+		out.String(string(in.Editor))
+	}
+	{
+		const prefix string = ",\"created\":"
+		out.RawString(prefix)
+		out.Raw((in.Created).MarshalJSON())
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v PostRevision) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson5a72dc82EncodeDBForumInternalAppModels1(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v PostRevision) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson5a72dc82EncodeDBForumInternalAppModels1(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *PostRevision) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson5a72dc82DecodeDBForumInternalAppModels1(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *PostRevision) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson5a72dc82DecodeDBForumInternalAppModels1(l, v)
+}
+func easyjson5a72dc82DecodeDBForumInternalAppModels2(in *jlexer.Lexer, out *PostNodeList) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		in.Skip()
+		*out = nil
+	} else {
+		in.Delim('[')
+		if *out == nil {
+			if !in.IsDelim(']') {
+				*out = make(PostNodeList, 0, 0)
+			} else {
+				*out = PostNodeList{}
+			}
+		} else {
+			*out = (*out)[:0]
+		}
+		for !in.IsDelim(']') {
+			var v4 PostNode
+			(v4).UnmarshalEasyJSON(in)
+			*out = append(*out, v4)
+			in.WantComma()
+		}
+		in.Delim(']')
+	}
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson5a72dc82EncodeDBForumInternalAppModels2(out *jwriter.Writer, in PostNodeList) {
+	if in == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+		out.RawString("null")
+	} else {
+		out.RawByte('[')
+		for v5, v6 := range in {
+			if v5 > 0 {
+				out.RawByte(',')
+			}
+			(v6).MarshalEasyJSON(out)
+		}
+		out.RawByte(']')
+	}
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v PostNodeList) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson5a72dc82EncodeDBForumInternalAppModels2(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v PostNodeList) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson5a72dc82EncodeDBForumInternalAppModels2(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *PostNodeList) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson5a72dc82DecodeDBForumInternalAppModels2(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *PostNodeList) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson5a72dc82DecodeDBForumInternalAppModels2(l, v)
+}
+func easyjson5a72dc82DecodeDBForumInternalAppModels3(in *jlexer.Lexer, out *PostNode) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "depth":
+			out.Depth = int(in.Int())
+		case "children":
+			out.Children = int(in.Int())
+		case "id":
+			out.ID = uint64(in.Uint64())
+		case "parent":
+			out.Parent = int(in.Int())
+		case "author":
+			out.Author = string(in.String())
+		case "message":
+			out.Message = string(in.String())
+		case "isEdited":
+			out.IsEdited = bool(in.Bool())
+		case "forum":
+			out.Forum = string(in.String())
+		case "thread":
+			out.Thread = uint64(in.Uint64())
+		case "created":
+			if data := in.Raw(); in.Ok() {
+				in.AddError((out.Created).UnmarshalJSON(data))
+			}
+		case "deleted":
+			out.Deleted = bool(in.Bool())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson5a72dc82EncodeDBForumInternalAppModels3(out *jwriter.Writer, in PostNode) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"depth\":"
+		out.RawString(prefix[1:])
+		out.Int(int(in.Depth))
+	}
+	{
+		const prefix string = ",\"children\":"
+		out.RawString(prefix)
+		out.Int(int(in.Children))
+	}
+	if in.ID != 0 {
+		const prefix string = ",\"id\":"
+		out.RawString(prefix)
+		out.Uint64(uint64(in.ID))
+	}
+	{
+		const prefix string = ",\"parent\":"
+		out.RawString(prefix)
+		out.Int(int(in.Parent))
+	}
+	if in.Author != "" {
+		const prefix string = ",\"author\":"
+		out.RawString(prefix)
+		out.String(string(in.Author))
+	}
+	if in.Message != "" {
+		const prefix string = ",\"message\":"
+		out.RawString(prefix)
+		out.String(string(in.Message))
+	}
+	{
+		const prefix string = ",\"isEdited\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.IsEdited))
+	}
+	if in.Forum != "" {
+		const prefix string = ",\"forum\":"
+		out.RawString(prefix)
+		out.String(string(in.Forum))
+	}
+	if in.Thread != 0 {
+		const prefix string = ",\"thread\":"
+		out.RawString(prefix)
+		out.Uint64(uint64(in.Thread))
+	}
+	if true {
+		const prefix string = ",\"created\":"
+		out.RawString(prefix)
+		out.Raw((in.Created).MarshalJSON())
+	}
+	if in.Deleted {
+		const prefix string = ",\"deleted\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.Deleted))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v PostNode) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson5a72dc82EncodeDBForumInternalAppModels3(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v PostNode) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson5a72dc82EncodeDBForumInternalAppModels3(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *PostNode) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson5a72dc82DecodeDBForumInternalAppModels3(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *PostNode) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson5a72dc82DecodeDBForumInternalAppModels3(l, v)
+}
+func easyjson5a72dc82DecodeDBForumInternalAppModels4(in *jlexer.Lexer, out *PostList) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		in.Skip()
@@ -34,9 +400,9 @@ func easyjson5a72dc82DecodeDBForumInternalAppModels(in *jlexer.Lexer, out *PostL
 			*out = (*out)[:0]
 		}
 		for !in.IsDelim(']') {
-			var v1 Post
-			(v1).UnmarshalEasyJSON(in)
-			*out = append(*out, v1)
+			var v7 Post
+			(v7).UnmarshalEasyJSON(in)
+			*out = append(*out, v7)
 			in.WantComma()
 		}
 		in.Delim(']')
@@ -45,16 +411,16 @@ func easyjson5a72dc82DecodeDBForumInternalAppModels(in *jlexer.Lexer, out *PostL
 		in.Consumed()
 	}
 }
-func easyjson5a72dc82EncodeDBForumInternalAppModels(out *jwriter.Writer, in PostList) {
+func easyjson5a72dc82EncodeDBForumInternalAppModels4(out *jwriter.Writer, in PostList) {
 	if in == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
 		out.RawString("null")
 	} else {
 		out.RawByte('[')
-		for v2, v3 := range in {
-			if v2 > 0 {
+		for v8, v9 := range in {
+			if v8 > 0 {
 				out.RawByte(',')
 			}
-			(v3).MarshalEasyJSON(out)
+			(v9).MarshalEasyJSON(out)
 		}
 		out.RawByte(']')
 	}
@@ -63,27 +429,27 @@ func easyjson5a72dc82EncodeDBForumInternalAppModels(out *jwriter.Writer, in Post
 // MarshalJSON supports json.Marshaler interface
 func (v PostList) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjson5a72dc82EncodeDBForumInternalAppModels(&w, v)
+	easyjson5a72dc82EncodeDBForumInternalAppModels4(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v PostList) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjson5a72dc82EncodeDBForumInternalAppModels(w, v)
+	easyjson5a72dc82EncodeDBForumInternalAppModels4(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *PostList) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjson5a72dc82DecodeDBForumInternalAppModels(&r, v)
+	easyjson5a72dc82DecodeDBForumInternalAppModels4(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *PostList) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjson5a72dc82DecodeDBForumInternalAppModels(l, v)
+	easyjson5a72dc82DecodeDBForumInternalAppModels4(l, v)
 }
-func easyjson5a72dc82DecodeDBForumInternalAppModels1(in *jlexer.Lexer, out *PostInfo) {
+func easyjson5a72dc82DecodeDBForumInternalAppModels5(in *jlexer.Lexer, out *PostInfo) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -120,7 +486,7 @@ func easyjson5a72dc82DecodeDBForumInternalAppModels1(in *jlexer.Lexer, out *Post
 				if out.Author == nil {
 					out.Author = new(User)
 				}
-				easyjson5a72dc82DecodeDBForumInternalAppModels2(in, out.Author)
+				easyjson5a72dc82DecodeDBForumInternalAppModels6(in, out.Author)
 			}
 		case "thread":
 			if in.IsNull() {
@@ -130,7 +496,7 @@ func easyjson5a72dc82DecodeDBForumInternalAppModels1(in *jlexer.Lexer, out *Post
 				if out.Thread == nil {
 					out.Thread = new(Thread)
 				}
-				easyjson5a72dc82DecodeDBForumInternalAppModels3(in, out.Thread)
+				easyjson5a72dc82DecodeDBForumInternalAppModels7(in, out.Thread)
 			}
 		case "forum":
 			if in.IsNull() {
@@ -140,7 +506,7 @@ func easyjson5a72dc82DecodeDBForumInternalAppModels1(in *jlexer.Lexer, out *Post
 				if out.Forum == nil {
 					out.Forum = new(Forum)
 				}
-				(*out.Forum).UnmarshalEasyJSON(in)
+				easyjson5a72dc82DecodeDBForumInternalAppModels8(in, out.Forum)
 			}
 		default:
 			in.SkipRecursive()
@@ -152,7 +518,7 @@ func easyjson5a72dc82DecodeDBForumInternalAppModels1(in *jlexer.Lexer, out *Post
 		in.Consumed()
 	}
 }
-func easyjson5a72dc82EncodeDBForumInternalAppModels1(out *jwriter.Writer, in PostInfo) {
+func easyjson5a72dc82EncodeDBForumInternalAppModels5(out *jwriter.Writer, in PostInfo) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -170,7 +536,7 @@ func easyjson5a72dc82EncodeDBForumInternalAppModels1(out *jwriter.Writer, in Pos
 		} else {
 			out.RawString(prefix)
 		}
-		easyjson5a72dc82EncodeDBForumInternalAppModels2(out, *in.Author)
+		easyjson5a72dc82EncodeDBForumInternalAppModels6(out, *in.Author)
 	}
 	if in.Thread != nil {
 		const prefix string = ",\"thread\":"
@@ -180,7 +546,7 @@ func easyjson5a72dc82EncodeDBForumInternalAppModels1(out *jwriter.Writer, in Pos
 		} else {
 			out.RawString(prefix)
 		}
-		easyjson5a72dc82EncodeDBForumInternalAppModels3(out, *in.Thread)
+		easyjson5a72dc82EncodeDBForumInternalAppModels7(out, *in.Thread)
 	}
 	if in.Forum != nil {
 		const prefix string = ",\"forum\":"
@@ -190,7 +556,7 @@ func easyjson5a72dc82EncodeDBForumInternalAppModels1(out *jwriter.Writer, in Pos
 		} else {
 			out.RawString(prefix)
 		}
-		(*in.Forum).MarshalEasyJSON(out)
+		easyjson5a72dc82EncodeDBForumInternalAppModels8(out, *in.Forum)
 	}
 	out.RawByte('}')
 }
@@ -198,27 +564,125 @@ func easyjson5a72dc82EncodeDBForumInternalAppModels1(out *jwriter.Writer, in Pos
 // MarshalJSON supports json.Marshaler interface
 func (v PostInfo) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjson5a72dc82EncodeDBForumInternalAppModels1(&w, v)
+	easyjson5a72dc82EncodeDBForumInternalAppModels5(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v PostInfo) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjson5a72dc82EncodeDBForumInternalAppModels1(w, v)
+	easyjson5a72dc82EncodeDBForumInternalAppModels5(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *PostInfo) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjson5a72dc82DecodeDBForumInternalAppModels1(&r, v)
+	easyjson5a72dc82DecodeDBForumInternalAppModels5(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *PostInfo) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjson5a72dc82DecodeDBForumInternalAppModels1(l, v)
+	easyjson5a72dc82DecodeDBForumInternalAppModels5(l, v)
+}
+func easyjson5a72dc82DecodeDBForumInternalAppModels8(in *jlexer.Lexer, out *Forum) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "id":
+			out.ID = uint64(in.Uint64())
+		case "title":
+			out.Title = string(in.String())
+		case "user":
+			out.User = string(in.String())
+		case "slug":
+			out.Slug = string(in.String())
+		case "posts":
+			out.Posts = uint64(in.Uint64())
+		case "threads":
+			out.Threads = uint64(in.Uint64())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson5a72dc82EncodeDBForumInternalAppModels8(out *jwriter.Writer, in Forum) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	if in.ID != 0 {
+		const prefix string = ",\"id\":"
+		first = false
+		out.RawString(prefix[1:])
+		out.Uint64(uint64(in.ID))
+	}
+	if in.Title != "" {
+		const prefix string = ",\"title\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Title))
+	}
+	if in.User != "" {
+		const prefix string = ",\"user\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.User))
+	}
+	if in.Slug != "" {
+		const prefix string = ",\"slug\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.String(string(in.Slug))
+	}
+	{
+		const prefix string = ",\"posts\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Uint64(uint64(in.Posts))
+	}
+	{
+		const prefix string = ",\"threads\":"
+		out.RawString(prefix)
+		out.Uint64(uint64(in.Threads))
+	}
+	out.RawByte('}')
 }
-func easyjson5a72dc82DecodeDBForumInternalAppModels3(in *jlexer.Lexer, out *Thread) {
+func easyjson5a72dc82DecodeDBForumInternalAppModels7(in *jlexer.Lexer, out *Thread) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -255,6 +719,14 @@ func easyjson5a72dc82DecodeDBForumInternalAppModels3(in *jlexer.Lexer, out *Thre
 			if data := in.Raw(); in.Ok() {
 				in.AddError((out.Created).UnmarshalJSON(data))
 			}
+		case "archived":
+			out.Archived = bool(in.Bool())
+		case "locked":
+			out.Locked = bool(in.Bool())
+		case "pinned":
+			out.Pinned = bool(in.Bool())
+		case "closed":
+			out.Closed = bool(in.Bool())
 		default:
 			in.SkipRecursive()
 		}
@@ -265,7 +737,7 @@ func easyjson5a72dc82DecodeDBForumInternalAppModels3(in *jlexer.Lexer, out *Thre
 		in.Consumed()
 	}
 }
-func easyjson5a72dc82EncodeDBForumInternalAppModels3(out *jwriter.Writer, in Thread) {
+func easyjson5a72dc82EncodeDBForumInternalAppModels7(out *jwriter.Writer, in Thread) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -335,9 +807,29 @@ func easyjson5a72dc82EncodeDBForumInternalAppModels3(out *jwriter.Writer, in Thr
 		out.RawString(prefix)
 		out.Raw((in.Created).MarshalJSON())
 	}
+	if in.Archived {
+		const prefix string = ",\"archived\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.Archived))
+	}
+	if in.Locked {
+		const prefix string = ",\"locked\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.Locked))
+	}
+	if in.Pinned {
+		const prefix string = ",\"pinned\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.Pinned))
+	}
+	if in.Closed {
+		const prefix string = ",\"closed\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.Closed))
+	}
 	out.RawByte('}')
 }
-func easyjson5a72dc82DecodeDBForumInternalAppModels2(in *jlexer.Lexer, out *User) {
+func easyjson5a72dc82DecodeDBForumInternalAppModels6(in *jlexer.Lexer, out *User) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -374,7 +866,7 @@ func easyjson5a72dc82DecodeDBForumInternalAppModels2(in *jlexer.Lexer, out *User
 		in.Consumed()
 	}
 }
-func easyjson5a72dc82EncodeDBForumInternalAppModels2(out *jwriter.Writer, in User) {
+func easyjson5a72dc82EncodeDBForumInternalAppModels6(out *jwriter.Writer, in User) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -416,7 +908,175 @@ func easyjson5a72dc82EncodeDBForumInternalAppModels2(out *jwriter.Writer, in Use
 	}
 	out.RawByte('}')
 }
-func easyjson5a72dc82DecodeDBForumInternalAppModels4(in *jlexer.Lexer, out *Post) {
+func easyjson5a72dc82DecodeDBForumInternalAppModels9(in *jlexer.Lexer, out *PostDiff) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "post":
+			out.Post = uint64(in.Uint64())
+		case "from":
+			out.From = int(in.Int())
+		case "to":
+			out.To = int(in.Int())
+		case "changes":
+			if in.IsNull() {
+				in.Skip()
+				out.Changes = nil
+			} else {
+				in.Delim('[')
+				if out.Changes == nil {
+					if !in.IsDelim(']') {
+						out.Changes = make([]Change, 0, 2)
+					} else {
+						out.Changes = []Change{}
+					}
+				} else {
+					out.Changes = (out.Changes)[:0]
+				}
+				for !in.IsDelim(']') {
+					var v10 Change
+					easyjson5a72dc82DecodeDBForumInternalAppModels10(in, &v10)
+					out.Changes = append(out.Changes, v10)
+					in.WantComma()
+				}
+				in.Delim(']')
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson5a72dc82EncodeDBForumInternalAppModels9(out *jwriter.Writer, in PostDiff) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"post\":"
+		out.RawString(prefix[1:])
+		out.Uint64(uint64(in.Post))
+	}
+	{
+		const prefix string = ",\"from\":"
+		out.RawString(prefix)
+		out.Int(int(in.From))
+	}
+	{
+		const prefix string = ",\"to\":"
+		out.RawString(prefix)
+		out.Int(int(in.To))
+	}
+	{
+		const prefix string = ",\"changes\":"
+		out.RawString(prefix)
+		if in.Changes == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
+			out.RawString("null")
+		} else {
+			out.RawByte('[')
+			for v11, v12 := range in.Changes {
+				if v11 > 0 {
+					out.RawByte(',')
+				}
+				easyjson5a72dc82EncodeDBForumInternalAppModels10(out, v12)
+			}
+			out.RawByte(']')
+		}
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v PostDiff) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson5a72dc82EncodeDBForumInternalAppModels9(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v PostDiff) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson5a72dc82EncodeDBForumInternalAppModels9(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *PostDiff) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson5a72dc82DecodeDBForumInternalAppModels9(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *PostDiff) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson5a72dc82DecodeDBForumInternalAppModels9(l, v)
+}
+func easyjson5a72dc82DecodeDBForumInternalAppModels10(in *jlexer.Lexer, out *Change) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "op":
+			out.Op = string(in.String())
+		case "text":
+			out.Text = string(in.String())
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson5a72dc82EncodeDBForumInternalAppModels10(out *jwriter.Writer, in Change) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	{
+		const prefix string = ",\"op\":"
+		out.RawString(prefix[1:])
+		out.String(string(in.Op))
+	}
+	{
+		const prefix string = ",\"text\":"
+		out.RawString(prefix)
+		out.String(string(in.Text))
+	}
+	out.RawByte('}')
+}
+func easyjson5a72dc82DecodeDBForumInternalAppModels11(in *jlexer.Lexer, out *Post) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -453,6 +1113,8 @@ func easyjson5a72dc82DecodeDBForumInternalAppModels4(in *jlexer.Lexer, out *Post
 			if data := in.Raw(); in.Ok() {
 				in.AddError((out.Created).UnmarshalJSON(data))
 			}
+		case "deleted":
+			out.Deleted = bool(in.Bool())
 		default:
 			in.SkipRecursive()
 		}
@@ -463,7 +1125,7 @@ func easyjson5a72dc82DecodeDBForumInternalAppModels4(in *jlexer.Lexer, out *Post
 		in.Consumed()
 	}
 }
-func easyjson5a72dc82EncodeDBForumInternalAppModels4(out *jwriter.Writer, in Post) {
+func easyjson5a72dc82EncodeDBForumInternalAppModels11(out *jwriter.Writer, in Post) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -513,29 +1175,34 @@ func easyjson5a72dc82EncodeDBForumInternalAppModels4(out *jwriter.Writer, in Pos
 		out.RawString(prefix)
 		out.Raw((in.Created).MarshalJSON())
 	}
+	if in.Deleted {
+		const prefix string = ",\"deleted\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.Deleted))
+	}
 	out.RawByte('}')
 }
 
 // MarshalJSON supports json.Marshaler interface
 func (v Post) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjson5a72dc82EncodeDBForumInternalAppModels4(&w, v)
+	easyjson5a72dc82EncodeDBForumInternalAppModels11(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v Post) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjson5a72dc82EncodeDBForumInternalAppModels4(w, v)
+	easyjson5a72dc82EncodeDBForumInternalAppModels11(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *Post) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjson5a72dc82DecodeDBForumInternalAppModels4(&r, v)
+	easyjson5a72dc82DecodeDBForumInternalAppModels11(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *Post) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjson5a72dc82DecodeDBForumInternalAppModels4(l, v)
+	easyjson5a72dc82DecodeDBForumInternalAppModels11(l, v)
 }
@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	SearchKindPost   = "post"
+	SearchKindThread = "thread"
+)
+
+//easyjson:json
+type SearchResultList []SearchResult
+
+//easyjson:json
+type SearchResult struct {
+	Kind    string    `json:"kind"`
+	ID      uint64    `json:"id"`
+	Thread  uint64    `json:"thread"`
+	Forum   string    `json:"forum"`
+	Author  string    `json:"author"`
+	Title   string    `json:"title,omitempty"`
+	Snippet string    `json:"snippet"`
+	Created time.Time `json:"created"`
+	Rank    float32   `json:"rank"`
+}
+
+// SearchQuery filters and pages a search. Forum, Author and Since are
+// optional; After, when set, is the last result of the previous page, or
+// with Before the first result of the next one.
+type SearchQuery struct {
+	Query  string
+	Forum  string
+	Author string
+	Since  *time.Time
+	After  *SearchResult
+	Before bool
+	Limit  int
+}
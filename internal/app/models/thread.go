@@ -9,14 +9,33 @@ type ThreadList []Thread
 
 //easyjson:json
 type Thread struct {
-	ID      uint64    `json:"id,omitempty" db:"id"`
-	Title   string    `json:"title,omitempty" db:"title"`
-	Author  string    `json:"author,omitempty" db:"author_nickname"`
-	Forum   string    `json:"forum,omitempty" db:"forum_slug"`
-	Message string    `json:"message,omitempty" db:"message"`
-	Votes   int       `json:"votes" db:"votes"`
-	Slug    string    `json:"slug,omitempty" db:"slug"`
-	Created time.Time `json:"created,omitempty" db:"created"`
+	ID       uint64    `json:"id,omitempty" db:"id"`
+	Title    string    `json:"title,omitempty" db:"title"`
+	Author   string    `json:"author,omitempty" db:"author_nickname"`
+	Forum    string    `json:"forum,omitempty" db:"forum_slug"`
+	Message  string    `json:"message,omitempty" db:"message"`
+	Votes    int       `json:"votes" db:"votes"`
+	Slug     string    `json:"slug,omitempty" db:"slug"`
+	Created  time.Time `json:"created,omitempty" db:"created"`
+	Archived bool      `json:"archived,omitempty" db:"is_archived"`
+	Locked   bool      `json:"locked,omitempty" db:"is_locked"`
+	Pinned   bool      `json:"pinned,omitempty" db:"is_pinned"`
+	Closed   bool      `json:"closed,omitempty" db:"is_closed"`
+	Version  uint64    `json:"-" db:"version"`
+}
+
+// ThreadStatus carries the moderator-only flags of a thread update; nil
+// leaves a flag unchanged.
+//
+//easyjson:json
+type ThreadStatus struct {
+	Locked *bool `json:"locked,omitempty"`
+	Pinned *bool `json:"pinned,omitempty"`
+	Closed *bool `json:"closed,omitempty"`
+}
+
+func (s ThreadStatus) Empty() bool {
+	return s.Locked == nil && s.Pinned == nil && s.Closed == nil
 }
 
 //easyjson:json
@@ -96,7 +96,122 @@ func (v *Vote) UnmarshalJSON(data []byte) error {
 func (v *Vote) UnmarshalEasyJSON(l *jlexer.Lexer) {
 	easyjson2d00218DecodeDBForumInternalAppModels(l, v)
 }
-func easyjson2d00218DecodeDBForumInternalAppModels1(in *jlexer.Lexer, out *ThreadList) {
+func easyjson2d00218DecodeDBForumInternalAppModels1(in *jlexer.Lexer, out *ThreadStatus) {
+	isTopLevel := in.IsStart()
+	if in.IsNull() {
+		if isTopLevel {
+			in.Consumed()
+		}
+		in.Skip()
+		return
+	}
+	in.Delim('{')
+	for !in.IsDelim('}') {
+		key := in.UnsafeFieldName(false)
+		in.WantColon()
+		if in.IsNull() {
+			in.Skip()
+			in.WantComma()
+			continue
+		}
+		switch key {
+		case "locked":
+			if in.IsNull() {
+				in.Skip()
+				out.Locked = nil
+			} else {
+				if out.Locked == nil {
+					out.Locked = new(bool)
+				}
+				*out.Locked = bool(in.Bool())
+			}
+		case "pinned":
+			if in.IsNull() {
+				in.Skip()
+				out.Pinned = nil
+			} else {
+				if out.Pinned == nil {
+					out.Pinned = new(bool)
+				}
+				*out.Pinned = bool(in.Bool())
+			}
+		case "closed":
+			if in.IsNull() {
+				in.Skip()
+				out.Closed = nil
+			} else {
+				if out.Closed == nil {
+					out.Closed = new(bool)
+				}
+				*out.Closed = bool(in.Bool())
+			}
+		default:
+			in.SkipRecursive()
+		}
+		in.WantComma()
+	}
+	in.Delim('}')
+	if isTopLevel {
+		in.Consumed()
+	}
+}
+func easyjson2d00218EncodeDBForumInternalAppModels1(out *jwriter.Writer, in ThreadStatus) {
+	out.RawByte('{')
+	first := true
+	_ = first
+	if in.Locked != nil {
+		const prefix string = ",\"locked\":"
+		first = false
+		out.RawString(prefix[1:])
+		out.Bool(bool(*in.Locked))
+	}
+	if in.Pinned != nil {
+		const prefix string = ",\"pinned\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Bool(bool(*in.Pinned))
+	}
+	if in.Closed != nil {
+		const prefix string = ",\"closed\":"
+		if first {
+			first = false
+			out.RawString(prefix[1:])
+		} else {
+			out.RawString(prefix)
+		}
+		out.Bool(bool(*in.Closed))
+	}
+	out.RawByte('}')
+}
+
+// MarshalJSON supports json.Marshaler interface
+func (v ThreadStatus) MarshalJSON() ([]byte, error) {
+	w := jwriter.Writer{}
+	easyjson2d00218EncodeDBForumInternalAppModels1(&w, v)
+	return w.Buffer.BuildBytes(), w.Error
+}
+
+// MarshalEasyJSON supports easyjson.Marshaler interface
+func (v ThreadStatus) MarshalEasyJSON(w *jwriter.Writer) {
+	easyjson2d00218EncodeDBForumInternalAppModels1(w, v)
+}
+
+// UnmarshalJSON supports json.Unmarshaler interface
+func (v *ThreadStatus) UnmarshalJSON(data []byte) error {
+	r := jlexer.Lexer{Data: data}
+	easyjson2d00218DecodeDBForumInternalAppModels1(&r, v)
+	return r.Error()
+}
+
+// UnmarshalEasyJSON supports easyjson.Unmarshaler interface
+func (v *ThreadStatus) UnmarshalEasyJSON(l *jlexer.Lexer) {
+	easyjson2d00218DecodeDBForumInternalAppModels1(l, v)
+}
+func easyjson2d00218DecodeDBForumInternalAppModels2(in *jlexer.Lexer, out *ThreadList) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		in.Skip()
@@ -124,7 +239,7 @@ func easyjson2d00218DecodeDBForumInternalAppModels1(in *jlexer.Lexer, out *Threa
 		in.Consumed()
 	}
 }
-func easyjson2d00218EncodeDBForumInternalAppModels1(out *jwriter.Writer, in ThreadList) {
+func easyjson2d00218EncodeDBForumInternalAppModels2(out *jwriter.Writer, in ThreadList) {
 	if in == nil && (out.Flags&jwriter.NilSliceAsEmpty) == 0 {
 		out.RawString("null")
 	} else {
@@ -142,27 +257,27 @@ func easyjson2d00218EncodeDBForumInternalAppModels1(out *jwriter.Writer, in Thre
 // MarshalJSON supports json.Marshaler interface
 func (v ThreadList) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjson2d00218EncodeDBForumInternalAppModels1(&w, v)
+	easyjson2d00218EncodeDBForumInternalAppModels2(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v ThreadList) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjson2d00218EncodeDBForumInternalAppModels1(w, v)
+	easyjson2d00218EncodeDBForumInternalAppModels2(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *ThreadList) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjson2d00218DecodeDBForumInternalAppModels1(&r, v)
+	easyjson2d00218DecodeDBForumInternalAppModels2(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *ThreadList) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjson2d00218DecodeDBForumInternalAppModels1(l, v)
+	easyjson2d00218DecodeDBForumInternalAppModels2(l, v)
 }
-func easyjson2d00218DecodeDBForumInternalAppModels2(in *jlexer.Lexer, out *Thread) {
+func easyjson2d00218DecodeDBForumInternalAppModels3(in *jlexer.Lexer, out *Thread) {
 	isTopLevel := in.IsStart()
 	if in.IsNull() {
 		if isTopLevel {
@@ -199,6 +314,14 @@ func easyjson2d00218DecodeDBForumInternalAppModels2(in *jlexer.Lexer, out *Threa
 			if data := in.Raw(); in.Ok() {
 				in.AddError((out.Created).UnmarshalJSON(data))
 			}
+		case "archived":
+			out.Archived = bool(in.Bool())
+		case "locked":
+			out.Locked = bool(in.Bool())
+		case "pinned":
+			out.Pinned = bool(in.Bool())
+		case "closed":
+			out.Closed = bool(in.Bool())
 		default:
 			in.SkipRecursive()
 		}
@@ -209,7 +332,7 @@ func easyjson2d00218DecodeDBForumInternalAppModels2(in *jlexer.Lexer, out *Threa
 		in.Consumed()
 	}
 }
-func easyjson2d00218EncodeDBForumInternalAppModels2(out *jwriter.Writer, in Thread) {
+func easyjson2d00218EncodeDBForumInternalAppModels3(out *jwriter.Writer, in Thread) {
 	out.RawByte('{')
 	first := true
 	_ = first
@@ -279,29 +402,49 @@ func easyjson2d00218EncodeDBForumInternalAppModels2(out *jwriter.Writer, in Thre
 		out.RawString(prefix)
 		out.Raw((in.Created).MarshalJSON())
 	}
+	if in.Archived {
+		const prefix string = ",\"archived\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.Archived))
+	}
+	if in.Locked {
+		const prefix string = ",\"locked\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.Locked))
+	}
+	if in.Pinned {
+		const prefix string = ",\"pinned\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.Pinned))
+	}
+	if in.Closed {
+		const prefix string = ",\"closed\":"
+		out.RawString(prefix)
+		out.Bool(bool(in.Closed))
+	}
 	out.RawByte('}')
 }
 
 // MarshalJSON supports json.Marshaler interface
 func (v Thread) MarshalJSON() ([]byte, error) {
 	w := jwriter.Writer{}
-	easyjson2d00218EncodeDBForumInternalAppModels2(&w, v)
+	easyjson2d00218EncodeDBForumInternalAppModels3(&w, v)
 	return w.Buffer.BuildBytes(), w.Error
 }
 
 // MarshalEasyJSON supports easyjson.Marshaler interface
 func (v Thread) MarshalEasyJSON(w *jwriter.Writer) {
-	easyjson2d00218EncodeDBForumInternalAppModels2(w, v)
+	easyjson2d00218EncodeDBForumInternalAppModels3(w, v)
 }
 
 // UnmarshalJSON supports json.Unmarshaler interface
 func (v *Thread) UnmarshalJSON(data []byte) error {
 	r := jlexer.Lexer{Data: data}
-	easyjson2d00218DecodeDBForumInternalAppModels2(&r, v)
+	easyjson2d00218DecodeDBForumInternalAppModels3(&r, v)
 	return r.Error()
 }
 
 // UnmarshalEasyJSON supports easyjson.Unmarshaler interface
 func (v *Thread) UnmarshalEasyJSON(l *jlexer.Lexer) {
-	easyjson2d00218DecodeDBForumInternalAppModels2(l, v)
+	easyjson2d00218DecodeDBForumInternalAppModels3(l, v)
 }
@@ -9,4 +9,5 @@ type User struct {
 	Fullname string `json:"fullname,omitempty" db:"fullname"`
 	About    string `json:"about,omitempty" db:"about"`
 	Email    string `json:"email,omitempty" db:"email"`
+	Version  uint64 `json:"-" db:"version"`
 }
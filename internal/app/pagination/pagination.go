@@ -0,0 +1,127 @@
+package pagination
+
+import (
+	customErr "DBForum/internal/app/errors"
+	"DBForum/internal/app/models"
+	"encoding/base64"
+	"encoding/json"
+)
+
+type cursor struct {
+	Key    string `json:"k,omitempty"`
+	ID     uint64 `json:"i,omitempty"`
+	Sort   string `json:"s,omitempty"`
+	Pinned bool   `json:"p,omitempty"`
+	Before bool   `json:"b,omitempty"`
+	Desc   bool   `json:"d,omitempty"`
+}
+
+// Encode packs a position and the direction of the list it belongs to
+// into an opaque cursor.
+func Encode(position models.Position, desc bool) string {
+	data, _ := json.Marshal(cursor{
+		Key:    position.Key,
+		ID:     position.ID,
+		Sort:   position.Sort,
+		Pinned: position.Pinned,
+		Before: position.Before,
+		Desc:   desc,
+	})
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// Decode unpacks a cursor made by Encode.
+func Decode(s string) (models.Position, bool, error) {
+	invalid := customErr.Invalid("cursor", "is not a cursor returned by a previous page")
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return models.Position{}, false, invalid
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return models.Position{}, false, invalid
+	}
+	position := models.Position{Key: c.Key, ID: c.ID, Sort: c.Sort, Pinned: c.Pinned, Before: c.Before}
+	return position, c.Desc, nil
+}
+
+// Parse resolves the cursor query parameter of a list request. Without a
+// cursor the list starts at the beginning or at since and desc is kept.
+func Parse(s string, desc bool) (*models.Position, bool, error) {
+	if s == "" {
+		return nil, desc, nil
+	}
+	position, desc, err := Decode(s)
+	if err != nil {
+		return nil, false, err
+	}
+	return &position, desc, nil
+}
+
+// Trim cuts a page out of n rows read with a limit of limit+1: the extra
+// row, if any, is the last one, or the first one for rows read backwards.
+// It returns the range of the page and whether a row was cut.
+func Trim(n int, limit int, before bool) (int, int, bool) {
+	if n <= limit {
+		return 0, n, false
+	}
+	if before {
+		return n - limit, n, true
+	}
+	return 0, limit, true
+}
+
+// Links returns the cursors around a page whose first and last rows are at
+// first and last. from is the position the page was read from and more
+// reports that Trim cut a row; started tells that the page does not begin
+// the list, which is also the case for pages read from a since value.
+func Links(from *models.Position, more bool, started bool, desc bool, first models.Position, last models.Position) models.Cursors {
+	var cursors models.Cursors
+	next, prev := Neighbours(more, started || from != nil, from != nil && from.Before)
+	if next {
+		last.Before = false
+		cursors.Next = Encode(last, desc)
+	}
+	if prev {
+		first.Before = true
+		cursors.Prev = Encode(first, desc)
+	}
+	return cursors
+}
+
+// Neighbours reports whether there are pages after and before a page that
+// is not empty. A page read backwards always has the rows it was read from
+// after it.
+func Neighbours(more bool, started bool, before bool) (bool, bool) {
+	if before {
+		return true, more
+	}
+	return more, started
+}
+
+// Walk returns the comparison and ORDER BY direction that read a list
+// sorted in direction desc from a position: forwards, or backwards when
+// before is set. Rows read backwards have to be put back in list order.
+func Walk(desc bool, before bool) (string, string) {
+	if desc != before {
+		return "<", "DESC"
+	}
+	return ">", "ASC"
+}
+
+// OrderBy lists columns for an ORDER BY clause, all in direction dir.
+func OrderBy(dir string, columns ...string) string {
+	order := ""
+	for i, column := range columns {
+		if i > 0 {
+			order += ", "
+		}
+		order += column + " " + dir
+	}
+	return order
+}
+
+// Reorder puts rows read backwards by query back in list order.
+func Reorder(query string, order string) string {
+	return "SELECT * FROM (" + query + ") AS page ORDER BY " + order
+}
@@ -0,0 +1,101 @@
+package pagination
+
+import (
+	customErr "DBForum/internal/app/errors"
+	"DBForum/internal/app/models"
+	"errors"
+	"testing"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	position := models.Position{Key: "2021-06-01T12:00:00Z", ID: 42, Sort: "posts", Pinned: true, Before: true}
+	got, desc, err := Decode(Encode(position, true))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != position || !desc {
+		t.Errorf("decoded %+v, desc %v, want %+v, desc true", got, desc, position)
+	}
+
+	for _, cursor := range []string{"!!", "bm90IGpzb24"} {
+		if _, _, err := Decode(cursor); !errors.Is(err, customErr.ErrInvalid) {
+			t.Errorf("Decode(%q): err = %v, want ErrInvalid", cursor, err)
+		}
+	}
+}
+
+func TestParseKeepsDesc(t *testing.T) {
+	from, desc, err := Parse("", true)
+	if from != nil || !desc || err != nil {
+		t.Errorf("Parse without a cursor = %v, %v, %v", from, desc, err)
+	}
+	from, desc, err = Parse(Encode(models.Position{ID: 7}, false), true)
+	if err != nil || from == nil || from.ID != 7 || desc {
+		t.Errorf("Parse = %+v, %v, %v, want the cursor's position and direction", from, desc, err)
+	}
+}
+
+func TestTrim(t *testing.T) {
+	tests := []struct {
+		n      int
+		before bool
+		first  int
+		last   int
+		more   bool
+	}{
+		{2, false, 0, 2, false},
+		{3, false, 0, 3, false},
+		{4, false, 0, 3, true},
+		{4, true, 1, 4, true},
+		{0, true, 0, 0, false},
+	}
+	for _, tt := range tests {
+		first, last, more := Trim(tt.n, 3, tt.before)
+		if first != tt.first || last != tt.last || more != tt.more {
+			t.Errorf("Trim(%d, 3, %v) = %d, %d, %v, want %d, %d, %v", tt.n, tt.before, first, last, more, tt.first, tt.last, tt.more)
+		}
+	}
+}
+
+func TestNeighbours(t *testing.T) {
+	tests := []struct {
+		name    string
+		more    bool
+		started bool
+		before  bool
+		next    bool
+		prev    bool
+	}{
+		{"only page", false, false, false, false, false},
+		{"first page", true, false, false, true, false},
+		{"middle page", true, true, false, true, true},
+		{"last page", false, true, false, false, true},
+		{"page read backwards", true, true, true, true, true},
+		{"first page read backwards", false, true, true, true, false},
+	}
+	for _, tt := range tests {
+		next, prev := Neighbours(tt.more, tt.started, tt.before)
+		if next != tt.next || prev != tt.prev {
+			t.Errorf("%s: next, prev = %v, %v, want %v, %v", tt.name, next, prev, tt.next, tt.prev)
+		}
+	}
+}
+
+func TestWalk(t *testing.T) {
+	tests := []struct {
+		desc   bool
+		before bool
+		op     string
+		dir    string
+	}{
+		{false, false, ">", "ASC"},
+		{false, true, "<", "DESC"},
+		{true, false, "<", "DESC"},
+		{true, true, ">", "ASC"},
+	}
+	for _, tt := range tests {
+		if op, dir := Walk(tt.desc, tt.before); op != tt.op || dir != tt.dir {
+			t.Errorf("Walk(%v, %v) = %q, %q, want %q, %q", tt.desc, tt.before, op, dir, tt.op, tt.dir)
+		}
+	}
+}
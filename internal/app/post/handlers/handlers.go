@@ -1,14 +1,13 @@
 package handlers
 
 import (
-	customErr "DBForum/internal/app/errors"
+	"DBForum/internal/app/auth"
 	"DBForum/internal/app/httputils"
 	"DBForum/internal/app/models"
 	postUseCase "DBForum/internal/app/post/usecase"
-	"errors"
+	"DBForum/internal/app/validation"
 	"github.com/mailru/easyjson"
 	"github.com/valyala/fasthttp"
-	"log"
 	"net/http"
 	"strconv"
 	"strings"
@@ -29,44 +28,145 @@ func (h *Handlers) GetInfo(ctx *fasthttp.RequestCtx) {
 	related := strings.Split(string(ctx.QueryArgs().Peek("related")), ",")
 
 	postInfo, err := h.useCase.GetPostInfoByID(id, related)
-
-	if errors.Is(err, customErr.ErrPostNotFound) {
-		resp := map[string]string{
-			"message": "Can't find post with id: ",
+	if err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
+	// Related objects change on their own, so only the bare post has an ETag.
+	if postInfo.Author == nil && postInfo.Thread == nil && postInfo.Forum == nil {
+		if httputils.NotModified(ctx, postInfo.Post.Version) {
+			return
 		}
-		httputils.RespondErr(ctx, http.StatusNotFound, resp)
+		httputils.SetETag(ctx, postInfo.Post.Version)
+	}
+	httputils.Respond(ctx, http.StatusOK, postInfo)
+}
+
+func (h *Handlers) GetReplies(ctx *fasthttp.RequestCtx) {
+	id, _ := strconv.ParseUint(ctx.UserValue("id").(string), 10, 64)
+
+	limit := int64(ctx.QueryArgs().GetUintOrZero("limit"))
+	if limit == 0 {
+		limit = 100
+	}
+
+	maxDepth := ctx.QueryArgs().GetUintOrZero("max_depth")
+
+	cursor := string(ctx.QueryArgs().Peek("cursor"))
+
+	var replies models.PostNodeList
+	replies, cursors, err := h.useCase.GetReplies(id, maxDepth, limit, cursor)
+	if err != nil {
+		httputils.RespondError(ctx, err)
 		return
 	}
+	httputils.SetLinks(ctx, cursors)
+	httputils.Respond(ctx, http.StatusOK, replies)
+}
+
+func (h *Handlers) GetAncestors(ctx *fasthttp.RequestCtx) {
+	id, _ := strconv.ParseUint(ctx.UserValue("id").(string), 10, 64)
+
+	var ancestors models.PostNodeList
+	ancestors, err := h.useCase.GetAncestors(id)
 	if err != nil {
-		log.Println(err)
-		httputils.Respond(ctx, http.StatusInternalServerError, nil)
+		httputils.RespondError(ctx, err)
 		return
 	}
-	httputils.Respond(ctx, http.StatusOK, postInfo)
+	httputils.Respond(ctx, http.StatusOK, ancestors)
+}
+
+func (h *Handlers) GetRevisions(ctx *fasthttp.RequestCtx) {
+	actor, err := auth.Caller(ctx)
+	if err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
+	id, _ := strconv.ParseUint(ctx.UserValue("id").(string), 10, 64)
+
+	var revisions models.PostRevisionList
+	revisions, err = h.useCase.GetRevisions(actor, id)
+	if err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
+	httputils.Respond(ctx, http.StatusOK, revisions)
+}
+
+func (h *Handlers) DiffRevisions(ctx *fasthttp.RequestCtx) {
+	actor, err := auth.Caller(ctx)
+	if err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
+	id, _ := strconv.ParseUint(ctx.UserValue("id").(string), 10, 64)
+	from := ctx.QueryArgs().GetUintOrZero("from")
+	to := ctx.QueryArgs().GetUintOrZero("to")
+
+	diff, err := h.useCase.DiffRevisions(actor, id, from, to)
+	if err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
+	httputils.Respond(ctx, http.StatusOK, diff)
 }
 
 func (h *Handlers) ChangeMessage(ctx *fasthttp.RequestCtx) {
 	post := &models.Post{}
+	actor, err := auth.Caller(ctx)
+	if err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
+	if err := validation.PostUpdate.Object(ctx.PostBody()); err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
 	if err := easyjson.Unmarshal(ctx.PostBody(), post); err != nil {
-		log.Println(err)
-		httputils.Respond(ctx, http.StatusInternalServerError, post)
+		httputils.RespondError(ctx, err)
 		return
 	}
 	id, _ := strconv.ParseUint(ctx.UserValue("id").(string), 10, 64)
 
 	post.ID = id
-	var err error
-	post, err = h.useCase.ChangeMessage(*post)
-	if errors.Is(err, customErr.ErrPostNotFound) {
-		resp := map[string]string{
-			"message": "Can't find post with id: " + strconv.FormatUint(id, 10),
-		}
-		httputils.RespondErr(ctx, http.StatusNotFound, resp)
+	if post.Version, err = httputils.IfMatch(ctx); err != nil {
+		httputils.RespondError(ctx, err)
 		return
 	}
+	post, err = h.useCase.ChangeMessage(actor, *post)
+	if err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
+	httputils.SetETag(ctx, post.Version)
+	httputils.Respond(ctx, http.StatusOK, post)
+}
+
+func (h *Handlers) Delete(ctx *fasthttp.RequestCtx) {
+	actor, err := auth.Caller(ctx)
+	if err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
+	id, _ := strconv.ParseUint(ctx.UserValue("id").(string), 10, 64)
+	post, err := h.useCase.DeletePost(actor, id)
+	if err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
+	httputils.Respond(ctx, http.StatusOK, post)
+}
+
+func (h *Handlers) Restore(ctx *fasthttp.RequestCtx) {
+	actor, err := auth.Caller(ctx)
+	if err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
+	id, _ := strconv.ParseUint(ctx.UserValue("id").(string), 10, 64)
+	post, err := h.useCase.RestorePost(actor, id)
 	if err != nil {
-		log.Println(err)
-		httputils.Respond(ctx, http.StatusInternalServerError, post)
+		httputils.RespondError(ctx, err)
 		return
 	}
 	httputils.Respond(ctx, http.StatusOK, post)
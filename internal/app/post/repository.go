@@ -0,0 +1,16 @@
+package post
+
+import "DBForum/internal/app/models"
+
+type Repository interface {
+	CreatePosts(idOrSlug string, posts []models.Post) ([]models.Post, error)
+	GetPosts(idOrSlug string, limit int64, since int64, from *models.Position, desc bool, sort string) ([]models.Post, error)
+	GetUserPosts(nickname string, forumSlug string, limit int64, since int64, from *models.Position, desc bool) ([]models.Post, error)
+	GetReplies(id uint64, maxDepth int, limit int64, from *models.Position) ([]models.PostNode, error)
+	GetAncestors(id uint64) ([]models.PostNode, error)
+	GetPostInfoByID(id uint64, related []string) (*models.PostInfo, error)
+	ChangePost(post *models.Post, editor string) (models.Post, error)
+	GetRevisions(id uint64) ([]models.PostRevision, error)
+	DeletePost(id uint64) (models.Post, error)
+	RestorePost(id uint64) (models.Post, error)
+}
@@ -3,6 +3,8 @@ package repository
 import (
 	customErr "DBForum/internal/app/errors"
 	"DBForum/internal/app/models"
+	"DBForum/internal/app/pagination"
+	"DBForum/internal/app/post"
 	"database/sql"
 	"fmt"
 	"github.com/go-openapi/strfmt"
@@ -13,30 +15,67 @@ import (
 )
 
 const (
-	insertPost = `INSERT INTO dbforum.post(author_nickname, forum_slug, thread_id, parent, created, message)
-				VALUES ($1, $2, $3, $4, $5, $6)
+	postColumns = "id, author_nickname, forum_slug, thread_id, message, parent, is_edited, created, tree, is_deleted, version"
+
+	insertPost = `INSERT INTO dbforum.post(author_nickname, forum_slug, thread_id, parent, created, message, search)
+				VALUES ($1, $2, $3, $4, $5, $6, to_tsvector('simple', $6))
 				RETURNING ID`
 
-	selectByThreadIDFlatDesc = "SELECT * FROM dbforum.post WHERE thread_id=$1 AND CASE WHEN $2 > 0 THEN id < $2 ELSE TRUE END ORDER BY id DESC LIMIT $3"
+	selectByThreadIDFlatDesc = "SELECT " + postColumns + " FROM dbforum.post WHERE thread_id=$1 AND CASE WHEN $2 > 0 THEN id < $2 ELSE TRUE END ORDER BY id DESC LIMIT $3"
+
+	selectByThreadIDFlat = "SELECT " + postColumns + " FROM dbforum.post WHERE thread_id=$1 AND CASE WHEN $2 > 0 THEN id > $2 ELSE TRUE END ORDER BY id LIMIT $3"
+
+	selectByThreadIDTreeDesc = "SELECT " + postColumns + " FROM dbforum.post WHERE thread_id=$1 AND CASE WHEN $2 > 0 THEN tree < (SELECT tree FROM dbforum.post WHERE id=$2) ELSE TRUE END ORDER BY tree DESC LIMIT $3"
+
+	selectByThreadIDTree = "SELECT " + postColumns + " FROM dbforum.post WHERE thread_id=$1 AND CASE WHEN $2 > 0 THEN tree > (SELECT tree FROM dbforum.post WHERE id=$2) ELSE TRUE END ORDER BY tree LIMIT $3"
+
+	selectByThreadIDParentTreeDesc = "SELECT " + postColumns + " FROM dbforum.post WHERE tree[1] IN (SELECT id FROM dbforum.post WHERE thread_id = $1 AND parent = 0 AND CASE WHEN $3 > 0 THEN tree[1] < (SELECT tree[1] FROM dbforum.post WHERE id=$3) ELSE TRUE END ORDER BY id DESC LIMIT $2) ORDER BY tree[1] DESC, tree, id"
+
+	selectByThreadIDParentTree = "SELECT " + postColumns + " FROM dbforum.post WHERE tree[1] IN (SELECT id FROM dbforum.post WHERE thread_id = $1 AND parent = 0  AND CASE WHEN $3 > 0 THEN tree[1] > (SELECT tree[1] FROM dbforum.post WHERE id=$3) ELSE TRUE END ORDER BY id LIMIT $2) ORDER BY tree, id"
 
-	selectByThreadIDFlat = "SELECT * FROM dbforum.post WHERE thread_id=$1 AND CASE WHEN $2 > 0 THEN id > $2 ELSE TRUE END ORDER BY id LIMIT $3"
+	selectByAuthor = "SELECT " + postColumns + " FROM dbforum.post WHERE author_nickname=$1 AND ($2 = '' OR forum_slug = $2::citext) AND CASE WHEN $3 > 0 THEN id > $3 ELSE TRUE END ORDER BY id LIMIT $4"
 
-	selectByThreadIDTreeDesc = "SELECT * FROM dbforum.post WHERE thread_id=$1 AND CASE WHEN $2 > 0 THEN tree < (SELECT tree FROM dbforum.post WHERE id=$2) ELSE TRUE END ORDER BY tree DESC LIMIT $3"
+	selectByAuthorDesc = "SELECT " + postColumns + " FROM dbforum.post WHERE author_nickname=$1 AND ($2 = '' OR forum_slug = $2::citext) AND CASE WHEN $3 > 0 THEN id < $3 ELSE TRUE END ORDER BY id DESC LIMIT $4"
 
-	selectByThreadIDTree = "SELECT * FROM dbforum.post WHERE thread_id=$1 AND CASE WHEN $2 > 0 THEN tree > (SELECT tree FROM dbforum.post WHERE id=$2) ELSE TRUE END ORDER BY tree LIMIT $3"
+	nodeColumns = postColumns + ", array_length(tree, 1) - 1 AS depth," +
+		" (SELECT count(*) FROM dbforum.post c WHERE c.thread_id = p.thread_id AND c.parent = p.id)::int AS children"
 
-	selectByThreadIDParentTreeDesc = "SELECT * FROM dbforum.post WHERE tree[1] IN (SELECT id FROM dbforum.post WHERE thread_id = $1 AND parent = 0 AND CASE WHEN $3 > 0 THEN tree[1] < (SELECT tree[1] FROM dbforum.post WHERE id=$3) ELSE TRUE END ORDER BY id DESC LIMIT $2) ORDER BY tree[1] DESC, tree, id"
+	repliesWhere = " FROM dbforum.post p WHERE tree @> ARRAY[$1::bigint] AND id <> $1" +
+		" AND ($2 = 0 OR array_length(tree, 1) <= array_length((SELECT tree FROM dbforum.post WHERE id=$1), 1) + $2)"
 
-	selectByThreadIDParentTree = "SELECT * FROM dbforum.post WHERE tree[1] IN (SELECT id FROM dbforum.post WHERE thread_id = $1 AND parent = 0  AND CASE WHEN $3 > 0 THEN tree[1] > (SELECT tree[1] FROM dbforum.post WHERE id=$3) ELSE TRUE END ORDER BY id LIMIT $2) ORDER BY tree, id"
+	selectReplies = "SELECT " + nodeColumns + repliesWhere + " AND CASE WHEN $3 > 0 THEN tree > (SELECT tree FROM dbforum.post WHERE id=$3) ELSE TRUE END ORDER BY tree LIMIT $4"
 
-	selectPostByID = "SELECT * FROM dbforum.post WHERE id=$1"
+	selectRepliesBefore = "SELECT * FROM (SELECT " + nodeColumns + repliesWhere + " AND tree < (SELECT tree FROM dbforum.post WHERE id=$3) ORDER BY tree DESC LIMIT $4) AS page ORDER BY tree"
+
+	selectAncestors = "SELECT " + nodeColumns + " FROM dbforum.post p WHERE id = ANY((SELECT tree FROM dbforum.post WHERE id=$1)) AND id <> $1 ORDER BY tree"
+
+	selectPostByID = "SELECT " + postColumns + " FROM dbforum.post WHERE id=$1"
 
 	updatePost = `UPDATE dbforum.post SET message=COALESCE(NULLIF($1, ''), message),
-                	is_edited = CASE WHEN $1 = '' OR message = $1 THEN is_edited ELSE true END
-					WHERE id=$2 
-					RETURNING id, author_nickname, forum_slug, thread_id, message, parent, is_edited, created`
+                	is_edited = CASE WHEN $1 = '' OR message = $1 THEN is_edited ELSE true END,
+					search = to_tsvector('simple', COALESCE(NULLIF($1, ''), message))
+					WHERE id=$2 AND NOT is_deleted
+					RETURNING id, author_nickname, forum_slug, thread_id, message, parent, is_edited, created, version`
+
+	selectPostForEdit = "SELECT message, author_nickname, created, version FROM dbforum.post WHERE id=$1 AND NOT is_deleted FOR UPDATE"
+
+	insertFirstRevision = `INSERT INTO dbforum.post_revision (post_id, revision, message, editor, created)
+					VALUES ($1, 1, $2, $3, $4) ON CONFLICT DO NOTHING`
+
+	insertRevision = `INSERT INTO dbforum.post_revision (post_id, revision, message, editor, created)
+					SELECT $1, max(revision) + 1, $2, NULLIF($3, ''), $4 FROM dbforum.post_revision WHERE post_id = $1`
+
+	selectRevisions = "SELECT post_id, revision, message, COALESCE(editor, ''), created FROM dbforum.post_revision WHERE post_id=$1 ORDER BY revision"
+
+	selectPostForUpdate = "SELECT forum_slug, is_deleted FROM dbforum.post WHERE id=$1 FOR UPDATE"
+
+	setPostDeleted = "UPDATE dbforum.post SET is_deleted=$2 WHERE id=$1 RETURNING " + postColumns
+
+	updateForumPostCount = "UPDATE dbforum.forum SET posts = posts + $2 WHERE slug=$1"
 )
 
+var _ post.Repository = (*Repository)(nil)
+
 type Repository struct {
 	db *pgx.ConnPool
 }
@@ -57,6 +96,7 @@ func (r *Repository) CreatePosts(idOrSlug string, posts []models.Post) ([]models
 	}
 	var threadID uint64
 	var forumSlug string
+	var archived, locked bool
 	if threadID, err = strconv.ParseUint(idOrSlug, 10, 64); err != nil {
 		rows, err := tx.Query("selectThreadIDAndForumSlug", idOrSlug)
 		if err != nil {
@@ -65,9 +105,9 @@ func (r *Repository) CreatePosts(idOrSlug string, posts []models.Post) ([]models
 		}
 		if !rows.Next() {
 			_ = tx.Rollback()
-			return nil, customErr.ErrThreadNotFound
+			return nil, customErr.ThreadNotFound(idOrSlug)
 		}
-		err = rows.Scan(&threadID, &forumSlug)
+		err = rows.Scan(&threadID, &forumSlug, &archived, &locked)
 		if err != nil {
 			_ = tx.Rollback()
 			return nil, err
@@ -81,15 +121,23 @@ func (r *Repository) CreatePosts(idOrSlug string, posts []models.Post) ([]models
 		}
 		if !rows.Next() {
 			_ = tx.Rollback()
-			return nil, customErr.ErrThreadNotFound
+			return nil, customErr.ThreadNotFound(idOrSlug)
 		}
-		err = rows.Scan(&forumSlug)
+		err = rows.Scan(&forumSlug, &archived, &locked)
 		if err != nil {
 			_ = tx.Rollback()
 			return nil, err
 		}
 		rows.Close()
 	}
+	if archived {
+		_ = tx.Rollback()
+		return nil, customErr.ThreadArchived(idOrSlug)
+	}
+	if locked {
+		_ = tx.Rollback()
+		return nil, customErr.ThreadLocked(idOrSlug)
+	}
 	err = nil
 	if posts[0].Parent != 0 {
 		var parent uint64
@@ -108,13 +156,13 @@ func (r *Repository) CreatePosts(idOrSlug string, posts []models.Post) ([]models
 		if parent != threadID {
 			_ = tx.Rollback()
 			rows.Close()
-			return nil, customErr.ErrNoParent
+			return nil, customErr.NoParent(uint64(posts[0].Parent))
 		}
 		rows.Close()
 	}
 
 	created := strfmt.DateTime(time.Now())
-	query := "INSERT INTO dbforum.post(author_nickname, forum_slug, thread_id, parent, created, message) VALUES "
+	query := "INSERT INTO dbforum.post(author_nickname, forum_slug, thread_id, parent, created, message, search) VALUES "
 	var args []interface{}
 	for i, post := range posts {
 		posts[i].Created = created
@@ -128,7 +176,7 @@ func (r *Repository) CreatePosts(idOrSlug string, posts []models.Post) ([]models
 			}
 			if !row.Next() {
 				_ = tx.Rollback()
-				return nil, errors.Wrap(customErr.ErrUserNotFound, post.Author)
+				return nil, customErr.UserNotFound(post.Author)
 			}
 			row.Close()
 		} else {
@@ -136,8 +184,8 @@ func (r *Repository) CreatePosts(idOrSlug string, posts []models.Post) ([]models
 			return nil, nil
 		}
 
-		query += fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d)",
-			i*6+1, i*6+2, i*6+3, i*6+4, i*6+5, i*6+6)
+		query += fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, to_tsvector('simple', $%d))",
+			i*6+1, i*6+2, i*6+3, i*6+4, i*6+5, i*6+6, i*6+6)
 		if i != len(posts)-1 {
 			query += ","
 		} else {
@@ -168,7 +216,7 @@ func (r *Repository) CreatePosts(idOrSlug string, posts []models.Post) ([]models
 	return posts, nil
 }
 
-func (r *Repository) GetPosts(idOrSlug string, limit int64, since int64, desc bool, sort string) ([]models.Post, error) {
+func (r *Repository) GetPosts(idOrSlug string, limit int64, since int64, from *models.Position, desc bool, sort string) ([]models.Post, error) {
 	var posts []models.Post
 	tx, err := r.db.Begin()
 	if err != nil {
@@ -183,7 +231,7 @@ func (r *Repository) GetPosts(idOrSlug string, limit int64, since int64, desc bo
 		}
 		if !rows.Next() {
 			_ = tx.Rollback()
-			return nil, customErr.ErrThreadNotFound
+			return nil, customErr.ThreadNotFound(idOrSlug)
 		}
 		err = rows.Scan(&threadID)
 		if err != nil {
@@ -199,13 +247,22 @@ func (r *Repository) GetPosts(idOrSlug string, limit int64, since int64, desc bo
 		}
 		if !rows.Next() {
 			_ = tx.Rollback()
-			return nil, customErr.ErrThreadNotFound
+			return nil, customErr.ThreadNotFound(idOrSlug)
 		}
 		rows.Close()
 	}
 
+	if from != nil {
+		since = int64(from.ID)
+	}
 	var rows *pgx.Rows
-	if desc {
+	if from != nil && from.Before {
+		rows, err = tx.Query(postsBefore(sort, desc), threadID, since, limit)
+		if err != nil {
+			_ = tx.Rollback()
+			return nil, err
+		}
+	} else if desc {
 		switch sort {
 		case "flat":
 			rows, err = tx.Query("selectByThreadIDFlatDesc", threadID, since, limit)
@@ -251,7 +308,9 @@ func (r *Repository) GetPosts(idOrSlug string, limit int64, since int64, desc bo
 			&p.Parent,
 			&p.IsEdited,
 			&p.Created,
-			&p.Tree)
+			&p.Tree,
+			&p.Deleted,
+			&p.Version)
 		if err != nil {
 			_ = tx.Rollback()
 			return nil, err
@@ -266,6 +325,130 @@ func (r *Repository) GetPosts(idOrSlug string, limit int64, since int64, desc bo
 	return posts, nil
 }
 
+// postsBefore builds the query for the page of a thread's posts that lies
+// before the post in $2, read backwards and put back in list order. For
+// parent_tree the page is made of the root posts before the root of $2.
+func postsBefore(sort string, desc bool) string {
+	op, dir := pagination.Walk(desc, true)
+	_, listDir := pagination.Walk(desc, false)
+	switch sort {
+	case "tree":
+		return pagination.Reorder("SELECT "+postColumns+" FROM dbforum.post WHERE thread_id = $1"+
+			" AND tree "+op+" (SELECT tree FROM dbforum.post WHERE id = $2)"+
+			" ORDER BY tree "+dir+" LIMIT $3", "tree "+listDir)
+	case "parent_tree":
+		order := "tree, id"
+		if desc {
+			order = "tree[1] DESC, tree, id"
+		}
+		return "SELECT " + postColumns + " FROM dbforum.post WHERE tree[1] IN (" +
+			"SELECT id FROM dbforum.post WHERE thread_id = $1 AND parent = 0" +
+			" AND id " + op + " (SELECT tree[1] FROM dbforum.post WHERE id = $2)" +
+			" ORDER BY id " + dir + " LIMIT $3) ORDER BY " + order
+	default:
+		return pagination.Reorder("SELECT "+postColumns+" FROM dbforum.post WHERE thread_id = $1"+
+			" AND id "+op+" $2 ORDER BY id "+dir+" LIMIT $3", "id "+listDir)
+	}
+}
+
+func (r *Repository) GetUserPosts(nickname string, forumSlug string, limit int64, since int64, from *models.Position, desc bool) ([]models.Post, error) {
+	query := "selectByAuthor"
+	if desc {
+		query = "selectByAuthorDesc"
+	}
+	if from != nil {
+		since = int64(from.ID)
+		if from.Before {
+			op, dir := pagination.Walk(desc, true)
+			_, listDir := pagination.Walk(desc, false)
+			query = pagination.Reorder("SELECT "+postColumns+" FROM dbforum.post WHERE author_nickname = $1"+
+				" AND ($2 = '' OR forum_slug = $2::citext) AND id "+op+" $3 ORDER BY id "+dir+" LIMIT $4", "id "+listDir)
+		}
+	}
+	rows, err := r.db.Query(query, nickname, forumSlug, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := make([]models.Post, 0)
+	for rows.Next() {
+		p := models.Post{}
+		err := rows.Scan(
+			&p.ID,
+			&p.Author,
+			&p.Forum,
+			&p.Thread,
+			&p.Message,
+			&p.Parent,
+			&p.IsEdited,
+			&p.Created,
+			&p.Tree,
+			&p.Deleted,
+			&p.Version)
+		if err != nil {
+			return nil, err
+		}
+		posts = append(posts, p)
+	}
+	return posts, rows.Err()
+}
+
+// GetReplies reads the subtree under post id in tree order. maxDepth, if
+// set, limits how many levels below the post are read.
+func (r *Repository) GetReplies(id uint64, maxDepth int, limit int64, from *models.Position) ([]models.PostNode, error) {
+	query := "selectReplies"
+	var since uint64
+	if from != nil {
+		since = from.ID
+		if from.Before {
+			query = "selectRepliesBefore"
+		}
+	}
+	rows, err := r.db.Query(query, id, maxDepth, since, limit)
+	if err != nil {
+		return nil, err
+	}
+	return scanNodes(rows)
+}
+
+// GetAncestors reads the posts from the root of the thread down to the
+// parent of post id.
+func (r *Repository) GetAncestors(id uint64) ([]models.PostNode, error) {
+	rows, err := r.db.Query("selectAncestors", id)
+	if err != nil {
+		return nil, err
+	}
+	return scanNodes(rows)
+}
+
+func scanNodes(rows *pgx.Rows) ([]models.PostNode, error) {
+	defer rows.Close()
+	nodes := make([]models.PostNode, 0)
+	for rows.Next() {
+		n := models.PostNode{}
+		err := rows.Scan(
+			&n.ID,
+			&n.Author,
+			&n.Forum,
+			&n.Thread,
+			&n.Message,
+			&n.Parent,
+			&n.IsEdited,
+			&n.Created,
+			&n.Tree,
+			&n.Deleted,
+			&n.Version,
+			&n.Depth,
+			&n.Children)
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, n)
+	}
+	return nodes, rows.Err()
+}
+
 func Find(slice []string, val string) bool {
 	for _, item := range slice {
 		if item == val {
@@ -287,7 +470,7 @@ func (r *Repository) GetPostInfoByID(id uint64, related []string) (*models.PostI
 	}
 	if !rows.Next() {
 		_ = tx.Rollback()
-		return nil, customErr.ErrPostNotFound
+		return nil, customErr.PostNotFound(id)
 	}
 	err = rows.Scan(
 		&postInfo.Post.ID,
@@ -298,7 +481,9 @@ func (r *Repository) GetPostInfoByID(id uint64, related []string) (*models.PostI
 		&postInfo.Post.Parent,
 		&postInfo.Post.IsEdited,
 		&postInfo.Post.Created,
-		&postInfo.Post.Tree)
+		&postInfo.Post.Tree,
+		&postInfo.Post.Deleted,
+		&postInfo.Post.Version)
 	rows.Close()
 	if err != nil {
 		_ = tx.Rollback()
@@ -317,7 +502,8 @@ func (r *Repository) GetPostInfoByID(id uint64, related []string) (*models.PostI
 				&postInfo.Author.Nickname,
 				&postInfo.Author.Fullname,
 				&postInfo.Author.About,
-				&postInfo.Author.Email)
+				&postInfo.Author.Email,
+				&postInfo.Author.Version)
 			if err != nil {
 				_ = tx.Rollback()
 				return nil, err
@@ -341,7 +527,12 @@ func (r *Repository) GetPostInfoByID(id uint64, related []string) (*models.PostI
 				&postInfo.Thread.Message,
 				&postInfo.Thread.Votes,
 				&postInfo.Thread.Slug,
-				&postInfo.Thread.Created)
+				&postInfo.Thread.Created,
+				&postInfo.Thread.Archived,
+				&postInfo.Thread.Locked,
+				&postInfo.Thread.Pinned,
+				&postInfo.Thread.Closed,
+				&postInfo.Thread.Version)
 			if err != nil {
 				_ = tx.Rollback()
 				return nil, err
@@ -379,8 +570,30 @@ func (r *Repository) GetPostInfoByID(id uint64, related []string) (*models.PostI
 	return &postInfo, nil
 }
 
-func (r *Repository) ChangePost(post *models.Post) (models.Post, error) {
-	err := r.db.QueryRow("updatePost", &post.Message, &post.ID).Scan(
+// ChangePost updates the message and, if it changed, records the new one as
+// a revision. The first change also records the original message.
+func (r *Repository) ChangePost(post *models.Post, editor string) (models.Post, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.Post{}, err
+	}
+	var message, author string
+	var created time.Time
+	var version uint64
+	err = tx.QueryRow("selectPostForEdit", post.ID).Scan(&message, &author, &created, &version)
+	if err == pgx.ErrNoRows {
+		_ = tx.Rollback()
+		return models.Post{}, customErr.PostNotFound(post.ID)
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return models.Post{}, err
+	}
+	if post.Version != 0 && post.Version != version {
+		_ = tx.Rollback()
+		return models.Post{}, customErr.Stale(post.Version)
+	}
+	err = tx.QueryRow("updatePost", &post.Message, &post.ID).Scan(
 		&post.ID,
 		&post.Author,
 		&post.Forum,
@@ -388,24 +601,125 @@ func (r *Repository) ChangePost(post *models.Post) (models.Post, error) {
 		&post.Message,
 		&post.Parent,
 		&post.IsEdited,
-		&post.Created)
+		&post.Created,
+		&post.Version)
 	if err != nil {
-		return models.Post{}, customErr.ErrPostNotFound
+		_ = tx.Rollback()
+		return models.Post{}, err
+	}
+	if post.Message != message {
+		if _, err = tx.Exec("insertFirstRevision", post.ID, message, author, created); err != nil {
+			_ = tx.Rollback()
+			return models.Post{}, err
+		}
+		if _, err = tx.Exec("insertRevision", post.ID, post.Message, editor, time.Now()); err != nil {
+			_ = tx.Rollback()
+			return models.Post{}, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		_ = tx.Rollback()
+		return models.Post{}, err
 	}
 	return *post, nil
 }
 
+func (r *Repository) GetRevisions(id uint64) ([]models.PostRevision, error) {
+	rows, err := r.db.Query("selectRevisions", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	revisions := make([]models.PostRevision, 0)
+	for rows.Next() {
+		rev := models.PostRevision{}
+		err := rows.Scan(
+			&rev.Post,
+			&rev.Revision,
+			&rev.Message,
+			&rev.Editor,
+			&rev.Created)
+		if err != nil {
+			return nil, err
+		}
+		revisions = append(revisions, rev)
+	}
+	return revisions, rows.Err()
+}
+
+func (r *Repository) DeletePost(id uint64) (models.Post, error) {
+	return r.setDeleted(id, true)
+}
+
+func (r *Repository) RestorePost(id uint64) (models.Post, error) {
+	return r.setDeleted(id, false)
+}
+
+// setDeleted flips is_deleted and keeps the forum post counter in step.
+// Deleting a deleted post or restoring a live one changes nothing.
+func (r *Repository) setDeleted(id uint64, deleted bool) (models.Post, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.Post{}, err
+	}
+	var forumSlug string
+	var wasDeleted bool
+	err = tx.QueryRow("selectPostForUpdate", id).Scan(&forumSlug, &wasDeleted)
+	if err == pgx.ErrNoRows {
+		_ = tx.Rollback()
+		return models.Post{}, customErr.PostNotFound(id)
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return models.Post{}, err
+	}
+
+	var post models.Post
+	err = tx.QueryRow("setPostDeleted", id, deleted).Scan(
+		&post.ID,
+		&post.Author,
+		&post.Forum,
+		&post.Thread,
+		&post.Message,
+		&post.Parent,
+		&post.IsEdited,
+		&post.Created,
+		&post.Tree,
+		&post.Deleted,
+		&post.Version)
+	if err != nil {
+		_ = tx.Rollback()
+		return models.Post{}, err
+	}
+	if wasDeleted != deleted {
+		delta := 1
+		if deleted {
+			delta = -1
+		}
+		if _, err = tx.Exec("updateForumPostCount", forumSlug, delta); err != nil {
+			_ = tx.Rollback()
+			return models.Post{}, err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		_ = tx.Rollback()
+		return models.Post{}, err
+	}
+	return post, nil
+}
+
 func (r *Repository) Prepare() error {
 	_, err := r.db.Prepare("insertPost", insertPost)
 	if err != nil {
 		return err
 	}
-	_, err = r.db.Prepare("selectThreadIDAndForumSlug", "SELECT id, forum_slug FROM dbforum.thread WHERE slug=$1 LIMIT 1")
+	_, err = r.db.Prepare("selectThreadIDAndForumSlug", "SELECT id, forum_slug, is_archived, is_locked FROM dbforum.thread WHERE slug=$1 LIMIT 1")
 	if err != nil {
 		return err
 	}
 
-	_, err = r.db.Prepare("selectForumSlug", "SELECT forum_slug FROM dbforum.thread WHERE id=$1 LIMIT 1")
+	_, err = r.db.Prepare("selectForumSlug", "SELECT forum_slug, is_archived, is_locked FROM dbforum.thread WHERE id=$1 LIMIT 1")
 	if err != nil {
 		return err
 	}
@@ -435,11 +749,71 @@ func (r *Repository) Prepare() error {
 		return err
 	}
 
+	_, err = r.db.Prepare("selectByAuthor", selectByAuthor)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Prepare("selectByAuthorDesc", selectByAuthorDesc)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Prepare("selectReplies", selectReplies)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Prepare("selectRepliesBefore", selectRepliesBefore)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Prepare("selectAncestors", selectAncestors)
+	if err != nil {
+		return err
+	}
+
 	_, err = r.db.Prepare("selectPostByID", selectPostByID)
 	if err != nil {
 		return err
 	}
 
+	_, err = r.db.Prepare("selectPostForEdit", selectPostForEdit)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Prepare("insertFirstRevision", insertFirstRevision)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Prepare("insertRevision", insertRevision)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Prepare("selectRevisions", selectRevisions)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Prepare("selectPostForUpdate", selectPostForUpdate)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Prepare("setPostDeleted", setPostDeleted)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Prepare("updateForumPostCount", updateForumPostCount)
+	if err != nil {
+		return err
+	}
+
 	_, err = r.db.Prepare("selectByThreadIDFlatDesc", selectByThreadIDFlatDesc)
 	if err != nil {
 		return err
@@ -1,24 +1,29 @@
 package usecase
 
 import (
-	forumRepository "DBForum/internal/app/forum/repository"
+	"DBForum/internal/app/auth"
+	"DBForum/internal/app/diff"
+	customErr "DBForum/internal/app/errors"
+	"DBForum/internal/app/forum"
 	"DBForum/internal/app/models"
-	postRepository "DBForum/internal/app/post/repository"
-	threadRepository "DBForum/internal/app/thread/repository"
-	userRepository "DBForum/internal/app/user/repository"
+	"DBForum/internal/app/pagination"
+	"DBForum/internal/app/post"
+	"DBForum/internal/app/thread"
+	"DBForum/internal/app/user"
+	"strconv"
 )
 
 type UseCase struct {
-	postRepo   postRepository.Repository
-	userRepo   userRepository.Repository
-	threadRepo threadRepository.Repository
-	forumRepo  forumRepository.Repository
+	postRepo   post.Repository
+	userRepo   user.Repository
+	threadRepo thread.Repository
+	forumRepo  forum.Repository
 }
 
-func NewUseCase(postRepo postRepository.Repository,
-	userRepo userRepository.Repository,
-	threadRepo threadRepository.Repository,
-	forumRepo forumRepository.Repository) *UseCase {
+func NewUseCase(postRepo post.Repository,
+	userRepo user.Repository,
+	threadRepo thread.Repository,
+	forumRepo forum.Repository) *UseCase {
 	return &UseCase{
 		postRepo:   postRepo,
 		userRepo:   userRepo,
@@ -32,13 +37,179 @@ func (u *UseCase) GetPostInfoByID(id uint64, related []string) (models.PostInfo,
 	if err != nil {
 		return models.PostInfo{}, err
 	}
+	postInfo.Post.Tombstone()
 	return *postInfo, nil
 }
 
-func (u *UseCase) ChangeMessage(post models.Post) (*models.Post, error) {
-	post, err := u.postRepo.ChangePost(&post)
+// GetReplies pages through the replies under a post in tree order, down to
+// maxDepth levels below it when maxDepth is set.
+func (u *UseCase) GetReplies(id uint64, maxDepth int, limit int64, cursor string) ([]models.PostNode, models.Cursors, error) {
+	from, _, err := pagination.Parse(cursor, false)
+	if err != nil {
+		return nil, models.Cursors{}, err
+	}
+	if _, err := u.postRepo.GetPostInfoByID(id, nil); err != nil {
+		return nil, models.Cursors{}, err
+	}
+	replies, err := u.postRepo.GetReplies(id, maxDepth, limit+1, from)
+	if err != nil {
+		return nil, models.Cursors{}, err
+	}
+	if len(replies) == 0 {
+		return []models.PostNode{}, models.Cursors{}, nil
+	}
+	first, last, more := pagination.Trim(len(replies), int(limit), from != nil && from.Before)
+	replies = replies[first:last]
+	for i := range replies {
+		replies[i].Tombstone()
+	}
+	cursors := pagination.Links(from, more, false, false, replies[0].Position(), replies[len(replies)-1].Position())
+	return replies, cursors, nil
+}
+
+// GetAncestors returns the chain of posts from the root of the thread down
+// to the parent of a post.
+func (u *UseCase) GetAncestors(id uint64) ([]models.PostNode, error) {
+	if _, err := u.postRepo.GetPostInfoByID(id, nil); err != nil {
+		return nil, err
+	}
+	ancestors, err := u.postRepo.GetAncestors(id)
+	if err != nil {
+		return nil, err
+	}
+	for i := range ancestors {
+		ancestors[i].Tombstone()
+	}
+	return ancestors, nil
+}
+
+// loadEditable loads the post and actor's role in its forum, refusing
+// posts of an archived thread.
+func (u *UseCase) loadEditable(actor models.Actor, id uint64) (*models.PostInfo, string, error) {
+	postInfo, err := u.postRepo.GetPostInfoByID(id, []string{"thread"})
+	if err != nil {
+		return nil, "", err
+	}
+	if postInfo.Thread != nil && postInfo.Thread.Archived {
+		return nil, "", customErr.ThreadArchived(strconv.FormatUint(postInfo.Thread.ID, 10))
+	}
+	role, err := auth.RoleIn(u.forumRepo, actor, postInfo.Post.Forum)
+	if err != nil {
+		return nil, "", err
+	}
+	return postInfo, role, nil
+}
+
+// checkEditable refuses changes to posts of an archived thread and to posts
+// the actor may not edit.
+func (u *UseCase) checkEditable(actor models.Actor, id uint64, action string) error {
+	postInfo, role, err := u.loadEditable(actor, id)
+	if err != nil {
+		return err
+	}
+	return auth.CanEdit(actor, action, postInfo.Post.Author, role)
+}
+
+func (u *UseCase) ChangeMessage(actor models.Actor, post models.Post) (*models.Post, error) {
+	if err := u.checkEditable(actor, post.ID, "edit post "+strconv.FormatUint(post.ID, 10)); err != nil {
+		return nil, err
+	}
+	post, err := u.postRepo.ChangePost(&post, actor.Nickname)
+	if err != nil {
+		return nil, err
+	}
+	return &post, nil
+}
+
+func (u *UseCase) DeletePost(actor models.Actor, id uint64) (*models.Post, error) {
+	if err := u.checkEditable(actor, id, "delete post "+strconv.FormatUint(id, 10)); err != nil {
+		return nil, err
+	}
+	post, err := u.postRepo.DeletePost(id)
+	if err != nil {
+		return nil, err
+	}
+	post.Tombstone()
+	return &post, nil
+}
+
+func (u *UseCase) RestorePost(actor models.Actor, id uint64) (*models.Post, error) {
+	_, role, err := u.loadEditable(actor, id)
+	if err != nil {
+		return nil, err
+	}
+	if err := auth.CanModerate(actor, "restore post "+strconv.FormatUint(id, 10), role); err != nil {
+		return nil, err
+	}
+	post, err := u.postRepo.RestorePost(id)
 	if err != nil {
 		return nil, err
 	}
 	return &post, nil
 }
+
+// checkAuditor lets the forum's owner and moderators and admins read the
+// edit history of a post, archived threads included.
+func (u *UseCase) checkAuditor(actor models.Actor, id uint64) error {
+	postInfo, err := u.postRepo.GetPostInfoByID(id, nil)
+	if err != nil {
+		return err
+	}
+	role, err := auth.RoleIn(u.forumRepo, actor, postInfo.Post.Forum)
+	if err != nil {
+		return err
+	}
+	return auth.CanModerate(actor, "read the revisions of post "+strconv.FormatUint(id, 10), role)
+}
+
+// GetRevisions lists the revisions of a post, oldest first. A post that was
+// never edited has none.
+func (u *UseCase) GetRevisions(actor models.Actor, id uint64) ([]models.PostRevision, error) {
+	if err := u.checkAuditor(actor, id); err != nil {
+		return nil, err
+	}
+	return u.postRepo.GetRevisions(id)
+}
+
+// DiffRevisions compares revisions from and to of a post. to defaults to
+// the latest revision and from to the one before to.
+func (u *UseCase) DiffRevisions(actor models.Actor, id uint64, from int, to int) (models.PostDiff, error) {
+	revisions, err := u.GetRevisions(actor, id)
+	if err != nil {
+		return models.PostDiff{}, err
+	}
+	if len(revisions) < 2 {
+		return models.PostDiff{}, customErr.Invalid("", "post has fewer than two revisions to compare")
+	}
+	if to == 0 {
+		to = len(revisions)
+	}
+	if from == 0 {
+		from = to - 1
+	}
+	if from >= to {
+		return models.PostDiff{}, customErr.Invalid("from", "must be less than to")
+	}
+	find := func(revision int) (models.PostRevision, error) {
+		for _, rev := range revisions {
+			if rev.Revision == revision {
+				return rev, nil
+			}
+		}
+		return models.PostRevision{}, customErr.RevisionNotFound(id, revision)
+	}
+	older, err := find(from)
+	if err != nil {
+		return models.PostDiff{}, err
+	}
+	newer, err := find(to)
+	if err != nil {
+		return models.PostDiff{}, err
+	}
+	return models.PostDiff{
+		Post:    id,
+		From:    from,
+		To:      to,
+		Changes: diff.Words(older.Message, newer.Message),
+	}, nil
+}
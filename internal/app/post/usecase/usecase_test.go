@@ -0,0 +1,303 @@
+package usecase
+
+import (
+	"DBForum/internal/app/diff"
+	customErr "DBForum/internal/app/errors"
+	"DBForum/internal/app/memory"
+	"DBForum/internal/app/models"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+var (
+	alice = models.Actor{Nickname: "alice"}
+	admin = models.Actor{Nickname: "root", Admin: true}
+)
+
+// newUseCase sets up a forum owned by alice with a post by bob and returns
+// the post's id. carol has no role in the forum.
+func newUseCase(t *testing.T) (*UseCase, *memory.ForumRepo, uint64) {
+	t.Helper()
+	store := memory.NewStore()
+	users := memory.NewUserRepo(store)
+	forums := memory.NewForumRepo(store)
+	threads := memory.NewThreadRepo(store)
+	posts := memory.NewPostRepo(store)
+	for _, nick := range []string{"alice", "bob", "carol"} {
+		if err := users.CreateUser(models.User{Nickname: nick, Fullname: nick, Email: nick + "@example.com"}, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := forums.CreateForum(&models.Forum{Slug: "golang", Title: "Go", User: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := threads.CreateThread(&models.Thread{Forum: "golang", Author: "alice", Title: "t", Message: "m", Slug: "generics"}); err != nil {
+		t.Fatal(err)
+	}
+	created, err := posts.CreatePosts("generics", []models.Post{{Author: "bob", Message: "secret"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewUseCase(posts, users, threads, forums), forums, created[0].ID
+}
+
+func TestDeleteAndRestorePost(t *testing.T) {
+	u, forums, id := newUseCase(t)
+
+	forumPosts := func() uint64 {
+		t.Helper()
+		forum, err := forums.FindBySlug("golang")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return forum.Posts
+	}
+
+	for i := 0; i < 2; i++ {
+		deleted, err := u.DeletePost(alice, id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !deleted.Deleted || deleted.Message != "" {
+			t.Errorf("deleted post = %+v, want a tombstone", deleted)
+		}
+		if got := forumPosts(); got != 0 {
+			t.Errorf("forum posts after delete #%d = %d, want 0", i+1, got)
+		}
+	}
+
+	info, err := u.GetPostInfoByID(id, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.Post.Deleted || info.Post.Message != "" || info.Post.Author != "bob" {
+		t.Errorf("post details = %+v, want a tombstone keeping the author", info.Post)
+	}
+	_, err = u.ChangeMessage(alice, models.Post{ID: id, Message: "edit"})
+	if !errors.Is(err, customErr.ErrPostNotFound) {
+		t.Errorf("editing a deleted post: err = %v, want ErrPostNotFound", err)
+	}
+
+	restored, err := u.RestorePost(admin, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if restored.Deleted || restored.Message != "secret" {
+		t.Errorf("restored post = %+v", restored)
+	}
+	if got := forumPosts(); got != 1 {
+		t.Errorf("forum posts after restore = %d, want 1", got)
+	}
+
+	if _, err := u.DeletePost(alice, id+100); !errors.Is(err, customErr.ErrPostNotFound) {
+		t.Errorf("err = %v, want ErrPostNotFound", err)
+	}
+}
+
+func TestEditPermissions(t *testing.T) {
+	u, _, id := newUseCase(t)
+	carol := models.Actor{Nickname: "carol"}
+
+	tests := []struct {
+		name  string
+		actor models.Actor
+		want  error
+	}{
+		{"author", models.Actor{Nickname: "BOB"}, nil},
+		{"forum owner", alice, nil},
+		{"admin", admin, nil},
+		{"anonymous", models.Actor{}, customErr.ErrUnauthorized},
+		{"stranger", carol, customErr.ErrForbidden},
+	}
+	for _, tt := range tests {
+		_, err := u.ChangeMessage(tt.actor, models.Post{ID: id, Message: "by " + tt.name})
+		if tt.want == nil && err != nil || tt.want != nil && !errors.Is(err, tt.want) {
+			t.Errorf("%s: err = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+
+	if _, err := u.DeletePost(carol, id); !errors.Is(err, customErr.ErrForbidden) {
+		t.Errorf("delete by a stranger: err = %v, want ErrForbidden", err)
+	}
+	if _, err := u.DeletePost(models.Actor{}, id); !errors.Is(err, customErr.ErrUnauthorized) {
+		t.Errorf("anonymous delete: err = %v, want ErrUnauthorized", err)
+	}
+	if _, err := u.RestorePost(carol, id); !errors.Is(err, customErr.ErrForbidden) {
+		t.Errorf("restore by a non-moderator: err = %v, want ErrForbidden", err)
+	}
+	if _, err := u.RestorePost(models.Actor{}, id); !errors.Is(err, customErr.ErrUnauthorized) {
+		t.Errorf("anonymous restore: err = %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestModeratorPermissions(t *testing.T) {
+	u, forums, id := newUseCase(t)
+	carol := models.Actor{Nickname: "carol"}
+	if _, err := forums.SetRole(models.ForumRole{Forum: "golang", Nickname: "carol", Role: models.RoleModerator}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := u.ChangeMessage(carol, models.Post{ID: id, Message: "moderated"}); err != nil {
+		t.Errorf("edit by a moderator: %v", err)
+	}
+	if _, err := u.DeletePost(carol, id); err != nil {
+		t.Errorf("delete by a moderator: %v", err)
+	}
+	if _, err := u.RestorePost(carol, id); err != nil {
+		t.Errorf("restore by a moderator: %v", err)
+	}
+
+	if _, err := forums.SetRole(models.ForumRole{Forum: "golang", Nickname: "bob", Role: models.RoleBanned}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := u.ChangeMessage(models.Actor{Nickname: "bob"}, models.Post{ID: id, Message: "mine"}); !errors.Is(err, customErr.ErrForbidden) {
+		t.Errorf("edit by a banned author: err = %v, want ErrForbidden", err)
+	}
+}
+
+func TestRepliesAndAncestors(t *testing.T) {
+	u, _, root := newUseCase(t)
+	reply := func(parent uint64) uint64 {
+		t.Helper()
+		created, err := u.postRepo.CreatePosts("generics", []models.Post{{Author: "carol", Message: "re", Parent: int(parent)}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return created[0].ID
+	}
+	a := reply(root)
+	a1 := reply(a)
+	a11 := reply(a1)
+	b := reply(root)
+
+	ids := func(nodes []models.PostNode) []uint64 {
+		var ids []uint64
+		for _, n := range nodes {
+			ids = append(ids, n.ID)
+		}
+		return ids
+	}
+
+	replies, cursors, err := u.GetReplies(root, 0, 2, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ids(replies), []uint64{a, a1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("first page = %v, want %v", got, want)
+	}
+	if replies[0].Depth != 1 || replies[0].Children != 1 {
+		t.Errorf("reply %d: depth %d, children %d, want 1 and 1", a, replies[0].Depth, replies[0].Children)
+	}
+	if cursors.Next == "" || cursors.Prev != "" {
+		t.Fatalf("first page cursors = %+v, want only next", cursors)
+	}
+	replies, cursors, err = u.GetReplies(root, 0, 2, cursors.Next)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ids(replies), []uint64{a11, b}; !reflect.DeepEqual(got, want) {
+		t.Errorf("second page = %v, want %v", got, want)
+	}
+	if cursors.Next != "" || cursors.Prev == "" {
+		t.Errorf("last page cursors = %+v, want only prev", cursors)
+	}
+
+	replies, _, err = u.GetReplies(root, 1, 10, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ids(replies), []uint64{a, b}; !reflect.DeepEqual(got, want) {
+		t.Errorf("replies one level deep = %v, want %v", got, want)
+	}
+
+	ancestors, err := u.GetAncestors(a11)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ids(ancestors), []uint64{root, a, a1}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ancestors = %v, want %v", got, want)
+	}
+	if ancestors[0].Depth != 0 || ancestors[0].Children != 2 {
+		t.Errorf("root: depth %d, children %d, want 0 and 2", ancestors[0].Depth, ancestors[0].Children)
+	}
+
+	if _, _, err := u.GetReplies(1000, 0, 10, ""); !errors.Is(err, customErr.ErrPostNotFound) {
+		t.Errorf("replies of a missing post: err = %v, want ErrPostNotFound", err)
+	}
+	if _, err := u.GetAncestors(1000); !errors.Is(err, customErr.ErrPostNotFound) {
+		t.Errorf("ancestors of a missing post: err = %v, want ErrPostNotFound", err)
+	}
+}
+
+func TestRevisions(t *testing.T) {
+	u, _, id := newUseCase(t)
+	bob := models.Actor{Nickname: "bob"}
+
+	revisions, err := u.GetRevisions(alice, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(revisions) != 0 {
+		t.Errorf("revisions of an unedited post = %+v, want none", revisions)
+	}
+	if _, err := u.DiffRevisions(alice, id, 0, 0); !errors.Is(err, customErr.ErrInvalid) {
+		t.Errorf("diff of an unedited post: err = %v, want ErrInvalid", err)
+	}
+
+	for _, edit := range []struct {
+		actor   models.Actor
+		message string
+	}{
+		{alice, "secret message"},
+		{alice, "secret message"},
+		{bob, ""},
+		{bob, "public message"},
+	} {
+		if _, err := u.ChangeMessage(edit.actor, models.Post{ID: id, Message: edit.message}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	revisions, err = u.GetRevisions(admin, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, rev := range revisions {
+		got = append(got, strconv.Itoa(rev.Revision)+" "+rev.Editor+": "+rev.Message)
+	}
+	want := []string{"1 bob: secret", "2 alice: secret message", "3 bob: public message"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("revisions = %q, want %q", got, want)
+	}
+
+	latest, err := u.DiffRevisions(alice, id, 0, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wantChanges := []models.Change{
+		{Op: diff.Delete, Text: "secret"},
+		{Op: diff.Insert, Text: "public"},
+		{Op: diff.Equal, Text: " message"},
+	}
+	if latest.From != 2 || latest.To != 3 || !reflect.DeepEqual(latest.Changes, wantChanges) {
+		t.Errorf("latest diff = %+v, want 2..3 with %v", latest, wantChanges)
+	}
+	if _, err := u.DiffRevisions(alice, id, 1, 4); !errors.Is(err, customErr.ErrNoRevision) {
+		t.Errorf("diff with a missing revision: err = %v, want ErrNoRevision", err)
+	}
+	for _, bounds := range [][2]int{{3, 2}, {2, 2}, {3, 0}} {
+		if _, err := u.DiffRevisions(alice, id, bounds[0], bounds[1]); !errors.Is(err, customErr.ErrInvalid) {
+			t.Errorf("diff from %d to %d: err = %v, want ErrInvalid", bounds[0], bounds[1], err)
+		}
+	}
+
+	if _, err := u.GetRevisions(bob, id); !errors.Is(err, customErr.ErrForbidden) {
+		t.Errorf("revisions for the author: err = %v, want ErrForbidden", err)
+	}
+	if _, err := u.GetRevisions(models.Actor{}, id); !errors.Is(err, customErr.ErrUnauthorized) {
+		t.Errorf("anonymous revisions: err = %v, want ErrUnauthorized", err)
+	}
+}
@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	customErr "DBForum/internal/app/errors"
+	"DBForum/internal/app/httputils"
+	"DBForum/internal/app/models"
+	searchUseCase "DBForum/internal/app/search/usecase"
+	"github.com/valyala/fasthttp"
+	"net/http"
+	"time"
+)
+
+type Handlers struct {
+	useCase searchUseCase.UseCase
+}
+
+func NewHandler(useCase searchUseCase.UseCase) *Handlers {
+	return &Handlers{
+		useCase: useCase,
+	}
+}
+
+func (h *Handlers) Search(ctx *fasthttp.RequestCtx) {
+	args := ctx.QueryArgs()
+	query := models.SearchQuery{
+		Query:  string(args.Peek("q")),
+		Forum:  string(args.Peek("forum")),
+		Author: string(args.Peek("author")),
+		Limit:  args.GetUintOrZero("limit"),
+	}
+	if since := string(args.Peek("since")); since != "" {
+		sinceTime, err := time.Parse(time.RFC3339Nano, since)
+		if err != nil {
+			httputils.RespondError(ctx, customErr.Invalid("since", "must be an RFC 3339 date-time"))
+			return
+		}
+		query.Since = &sinceTime
+	}
+
+	results, cursors, err := h.useCase.Search(query, string(args.Peek("cursor")))
+	if err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
+	httputils.SetFilteredLinks(ctx, cursors)
+	httputils.Respond(ctx, http.StatusOK, models.SearchResultList(results))
+}
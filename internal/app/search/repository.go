@@ -0,0 +1,9 @@
+package search
+
+import "DBForum/internal/app/models"
+
+type Repository interface {
+	// Search returns live posts and threads matching query.Query, best
+	// ranked first and ties broken by kind and id.
+	Search(query models.SearchQuery) ([]models.SearchResult, error)
+}
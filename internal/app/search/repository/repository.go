@@ -0,0 +1,104 @@
+package repository
+
+import (
+	"DBForum/internal/app/models"
+	"DBForum/internal/app/search"
+	"github.com/jackc/pgx"
+)
+
+const (
+	searchHits = `WITH query AS (SELECT websearch_to_tsquery('simple', $1) AS q),
+						hits AS (
+							SELECT 'post' AS kind, p.id, p.thread_id, p.forum_slug, p.author_nickname,
+								'' AS title, p.message AS body, p.created, ts_rank(p.search, query.q) AS rank
+							FROM dbforum.post p, query
+							WHERE p.search @@ query.q AND NOT p.is_deleted
+								AND ($2 = '' OR p.forum_slug = $2::citext)
+								AND ($3 = '' OR p.author_nickname = $3::citext)
+								AND ($4::timestamptz IS NULL OR p.created >= $4)
+							UNION ALL
+							SELECT 'thread', t.id, t.id, t.forum_slug, t.author_nickname,
+								t.title, t.message, t.created, ts_rank(t.search, query.q)
+							FROM dbforum.thread t, query
+							WHERE t.search @@ query.q
+								AND ($2 = '' OR t.forum_slug = $2::citext)
+								AND ($3 = '' OR t.author_nickname = $3::citext)
+								AND ($4::timestamptz IS NULL OR t.created >= $4)
+						)
+						SELECT kind, id, thread_id, forum_slug, author_nickname, title,
+							ts_headline('simple', body, query.q, 'StartSel=<b>, StopSel=</b>, MaxFragments=2'),
+							created, rank
+						FROM hits, query `
+
+	searchPostsAndThreads = searchHits + `WHERE $5::real IS NULL OR rank < $5 OR rank = $5 AND (kind, id) > ($6::text, $7::bigint)
+						ORDER BY rank DESC, kind, id
+						LIMIT $8`
+
+	searchPostsAndThreadsBefore = "SELECT * FROM (" + searchHits + `WHERE rank > $5::real OR rank = $5 AND (kind, id) < ($6::text, $7::bigint)
+						ORDER BY rank, kind DESC, id DESC
+						LIMIT $8) AS page ORDER BY rank DESC, kind, id`
+)
+
+var _ search.Repository = (*Repository)(nil)
+
+type Repository struct {
+	db *pgx.ConnPool
+}
+
+func NewRepo(db *pgx.ConnPool) *Repository {
+	return &Repository{
+		db: db,
+	}
+}
+
+func (r *Repository) Search(query models.SearchQuery) ([]models.SearchResult, error) {
+	var afterRank *float32
+	var afterKind string
+	var afterID uint64
+	if query.After != nil {
+		afterRank = &query.After.Rank
+		afterKind = query.After.Kind
+		afterID = query.After.ID
+	}
+	statement := "searchPostsAndThreads"
+	if query.After != nil && query.Before {
+		statement = "searchPostsAndThreadsBefore"
+	}
+	rows, err := r.db.Query(statement, query.Query, query.Forum, query.Author, query.Since,
+		afterRank, afterKind, afterID, query.Limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var results []models.SearchResult
+	for rows.Next() {
+		var result models.SearchResult
+		err := rows.Scan(
+			&result.Kind,
+			&result.ID,
+			&result.Thread,
+			&result.Forum,
+			&result.Author,
+			&result.Title,
+			&result.Snippet,
+			&result.Created,
+			&result.Rank)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+func (r *Repository) Prepare() error {
+	_, err := r.db.Prepare("searchPostsAndThreads", searchPostsAndThreads)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Prepare("searchPostsAndThreadsBefore", searchPostsAndThreadsBefore)
+	if err != nil {
+		return err
+	}
+	return nil
+}
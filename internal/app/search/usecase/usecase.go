@@ -0,0 +1,78 @@
+package usecase
+
+import (
+	customErr "DBForum/internal/app/errors"
+	"DBForum/internal/app/models"
+	"DBForum/internal/app/pagination"
+	"DBForum/internal/app/search"
+	"strconv"
+	"strings"
+)
+
+const (
+	defaultLimit = 20
+	maxLimit     = 100
+)
+
+type UseCase struct {
+	searchRepo search.Repository
+}
+
+func NewUseCase(searchRepo search.Repository) *UseCase {
+	return &UseCase{
+		searchRepo: searchRepo,
+	}
+}
+
+// Search returns a page of results and the cursors around it. cursor is
+// one of the cursors of a previous page, or "" for the first one.
+func (u *UseCase) Search(query models.SearchQuery, cursor string) ([]models.SearchResult, models.Cursors, error) {
+	if strings.TrimSpace(query.Query) == "" {
+		return nil, models.Cursors{}, customErr.Invalid("q", "must not be empty")
+	}
+	if query.Limit <= 0 {
+		query.Limit = defaultLimit
+	}
+	if query.Limit > maxLimit {
+		query.Limit = maxLimit
+	}
+	from, _, err := pagination.Parse(cursor, false)
+	if err != nil {
+		return nil, models.Cursors{}, err
+	}
+	if from != nil {
+		after, ok := resultAt(*from)
+		if !ok {
+			return nil, models.Cursors{}, customErr.Invalid("cursor", "is not a cursor returned by a previous search")
+		}
+		query.After = &after
+		query.Before = from.Before
+	}
+
+	limit := query.Limit
+	query.Limit++
+	results, err := u.searchRepo.Search(query)
+	if err != nil {
+		return nil, models.Cursors{}, err
+	}
+	if len(results) == 0 {
+		return []models.SearchResult{}, models.Cursors{}, nil
+	}
+	first, last, more := pagination.Trim(len(results), limit, query.Before)
+	results = results[first:last]
+	cursors := pagination.Links(from, more, false, false, results[0].Position(), results[len(results)-1].Position())
+	return results, cursors, nil
+}
+
+// resultAt unpacks the rank and kind SearchResult.Position keeps in Key.
+func resultAt(position models.Position) (models.SearchResult, bool) {
+	parts := strings.Split(position.Key, " ")
+	if len(parts) != 2 || parts[1] != models.SearchKindPost && parts[1] != models.SearchKindThread {
+		return models.SearchResult{}, false
+	}
+	rank, err := strconv.ParseFloat(parts[0], 32)
+	if err != nil {
+		return models.SearchResult{}, false
+	}
+	return models.SearchResult{Rank: float32(rank), Kind: parts[1], ID: position.ID}, true
+}
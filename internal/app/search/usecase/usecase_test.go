@@ -0,0 +1,144 @@
+package usecase
+
+import (
+	customErr "DBForum/internal/app/errors"
+	"DBForum/internal/app/memory"
+	"DBForum/internal/app/models"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func newUseCase(t *testing.T) *UseCase {
+	t.Helper()
+	store := memory.NewStore()
+	users := memory.NewUserRepo(store)
+	forums := memory.NewForumRepo(store)
+	threads := memory.NewThreadRepo(store)
+	posts := memory.NewPostRepo(store)
+	for _, nick := range []string{"alice", "bob"} {
+		if err := users.CreateUser(models.User{Nickname: nick, Fullname: nick, Email: nick + "@example.com"}, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, slug := range []string{"golang", "rust"} {
+		if err := forums.CreateForum(&models.Forum{Slug: slug, Title: slug, User: "alice"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, thread := range []models.Thread{
+		{Forum: "golang", Author: "alice", Title: "Generics", Message: "When do generics land?", Slug: "generics"},
+		{Forum: "rust", Author: "bob", Title: "Traits", Message: "Traits are like generics", Slug: "traits"},
+	} {
+		thread.Created = start.Add(time.Duration(i) * time.Hour)
+		if _, err := threads.CreateThread(&thread); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := posts.CreatePosts("generics", []models.Post{
+		{Author: "bob", Message: "Generics, generics, generics"},
+		{Author: "alice", Message: "Not about that"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewUseCase(memory.NewSearchRepo(store))
+}
+
+func kinds(results []models.SearchResult) []string {
+	result := []string{}
+	for _, r := range results {
+		result = append(result, r.Kind+":"+r.Forum)
+	}
+	return result
+}
+
+func TestSearch(t *testing.T) {
+	u := newUseCase(t)
+
+	results, cursors, err := u.Search(models.SearchQuery{Query: "GENERICS"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"thread:golang", "thread:rust", "post:golang"}
+	if got := kinds(results); !reflect.DeepEqual(got, want) {
+		t.Errorf("results = %v, want %v", got, want)
+	}
+	if results[0].Snippet != "When do <b>generics</b> land?" {
+		t.Errorf("snippet = %q", results[0].Snippet)
+	}
+	if cursors.Next != "" {
+		t.Errorf("next = %q on the last page", cursors.Next)
+	}
+
+	results, _, err = u.Search(models.SearchQuery{Query: "generics", Forum: "GoLang", Author: "bob"}, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := kinds(results); !reflect.DeepEqual(got, []string{"post:golang"}) {
+		t.Errorf("filtered results = %v", got)
+	}
+
+	since := time.Date(2021, 1, 1, 0, 30, 0, 0, time.UTC)
+	results, _, err = u.Search(models.SearchQuery{Query: "traits", Since: &since}, "")
+	if err != nil || len(results) != 1 {
+		t.Errorf("results since = %+v, %v", results, err)
+	}
+
+	results, _, err = u.Search(models.SearchQuery{Query: "nothing matches"}, "")
+	if err != nil || results == nil || len(results) != 0 {
+		t.Errorf("no hits = %+v, %v, want an empty list", results, err)
+	}
+}
+
+func TestSearchPages(t *testing.T) {
+	u := newUseCase(t)
+
+	var got []string
+	var cursors models.Cursors
+	for i := 0; i < 5; i++ {
+		results, page, err := u.Search(models.SearchQuery{Query: "generics", Limit: 1}, cursors.Next)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, kinds(results)...)
+		cursors = page
+		if cursors.Next == "" {
+			break
+		}
+	}
+	want := []string{"thread:golang", "thread:rust", "post:golang"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("paged results = %v, want %v", got, want)
+	}
+
+	got = nil
+	for i := 0; i < 5 && cursors.Prev != ""; i++ {
+		var results []models.SearchResult
+		var err error
+		results, cursors, err = u.Search(models.SearchQuery{Query: "generics", Limit: 1}, cursors.Prev)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, kinds(results)...)
+	}
+	want = []string{"thread:rust", "thread:golang"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("results paged back = %v, want %v", got, want)
+	}
+	if cursors.Next == "" {
+		t.Error("no next cursor on a page read backwards")
+	}
+}
+
+func TestSearchValidation(t *testing.T) {
+	u := newUseCase(t)
+	if _, _, err := u.Search(models.SearchQuery{Query: "  "}, ""); !errors.Is(err, customErr.ErrInvalid) {
+		t.Errorf("empty query: err = %v, want ErrInvalid", err)
+	}
+	if _, _, err := u.Search(models.SearchQuery{Query: "go"}, "bogus"); !errors.Is(err, customErr.ErrInvalid) {
+		t.Errorf("bad cursor: err = %v, want ErrInvalid", err)
+	}
+}
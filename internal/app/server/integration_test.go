@@ -0,0 +1,1499 @@
+package server_test
+
+import (
+	"DBForum/internal/app/config"
+	"DBForum/internal/app/models"
+	"DBForum/internal/app/server"
+	userRepo "DBForum/internal/app/user/repository"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/jackc/pgx"
+	"github.com/valyala/fasthttp"
+	"github.com/valyala/fasthttp/fasthttputil"
+	"net"
+	"net/http"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+var (
+	client     *fasthttp.Client
+	pool       *pgx.ConnPool
+	skipReason string
+)
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	os.Exit(runIntegration(m))
+}
+
+func runIntegration(m *testing.M) int {
+	if testing.Short() {
+		skipReason = "integration tests are skipped in -short mode"
+		return m.Run()
+	}
+	dbConf, stop, err := testDatabase()
+	if err != nil {
+		if testRequired() {
+			fmt.Fprintln(os.Stderr, "no Postgres for the integration tests:", err)
+			return 1
+		}
+		skipReason = "no ephemeral Postgres: " + err.Error()
+		return m.Run()
+	}
+	defer stop()
+
+	postgres, err := connect(dbConf)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	defer postgres.Close()
+	pool = postgres.GetPostgres()
+
+	// Most tests drive the open API with anonymous requests, so the suite
+	// runs in legacy mode; TestAuthRequired covers the default.
+	conf := config.Default()
+	conf.Auth.Required = false
+	handler, err := server.New(postgres.GetPostgres(), conf.Auth, conf.Idempotency)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	ln := fasthttputil.NewInmemoryListener()
+	defer ln.Close()
+	go func() {
+		_ = fasthttp.Serve(ln, handler)
+	}()
+	client = &fasthttp.Client{
+		Dial: func(string) (net.Conn, error) {
+			return ln.Dial()
+		},
+	}
+	return m.Run()
+}
+
+// setup skips the test when no Postgres is available and otherwise starts
+// it from an empty database.
+func setup(t *testing.T) {
+	t.Helper()
+	if skipReason != "" {
+		t.Skip(skipReason)
+	}
+	expect(t, "POST", "/api/service/clear", nil, http.StatusOK, nil)
+}
+
+// call sends body as JSON and returns the status and decodes the response into out.
+func call(t *testing.T, method string, path string, body interface{}, out interface{}) int {
+	t.Helper()
+	return callAs(t, "", method, path, body, out)
+}
+
+// callAs is call with a bearer token, unless token is empty.
+func callAs(t *testing.T, token string, method string, path string, body interface{}, out interface{}) int {
+	t.Helper()
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.Header.SetMethod(method)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	req.SetRequestURI("http://forum" + path)
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.SetContentType("application/json")
+		req.SetBody(data)
+	}
+	if err := client.DoTimeout(req, resp, 10*time.Second); err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	if out != nil && len(resp.Body()) > 0 {
+		if err := json.Unmarshal(resp.Body(), out); err != nil {
+			t.Fatalf("%s %s: decoding %q: %v", method, path, resp.Body(), err)
+		}
+	}
+	return resp.StatusCode()
+}
+
+func expect(t *testing.T, method string, path string, body interface{}, status int, out interface{}) {
+	t.Helper()
+	if got := call(t, method, path, body, out); got != status {
+		t.Fatalf("%s %s: status %d, want %d", method, path, got, status)
+	}
+}
+
+// expectAs is expect with a bearer token.
+func expectAs(t *testing.T, token string, method string, path string, body interface{}, status int, out interface{}) {
+	t.Helper()
+	if got := callAs(t, token, method, path, body, out); got != status {
+		t.Fatalf("%s %s: status %d, want %d", method, path, got, status)
+	}
+}
+
+func createUser(t *testing.T, nickname string) {
+	t.Helper()
+	expect(t, "POST", "/api/user/"+nickname+"/create", map[string]string{
+		"fullname": "Full " + nickname,
+		"about":    "about " + nickname,
+		"email":    nickname + "@example.com",
+	}, http.StatusCreated, nil)
+}
+
+func createForum(t *testing.T, slug string, owner string) {
+	t.Helper()
+	expect(t, "POST", "/api/forum/create", map[string]string{
+		"title": "Forum " + slug,
+		"user":  owner,
+		"slug":  slug,
+	}, http.StatusCreated, nil)
+}
+
+func createThread(t *testing.T, forum string, author string, slug string) models.Thread {
+	t.Helper()
+	var thread models.Thread
+	expect(t, "POST", "/api/forum/"+forum+"/create", map[string]string{
+		"title":   "Thread " + slug,
+		"author":  author,
+		"message": "message",
+		"slug":    slug,
+		"created": "2021-06-01T12:00:00.000Z",
+	}, http.StatusCreated, &thread)
+	return thread
+}
+
+func createPost(t *testing.T, thread string, author string, parent uint64) uint64 {
+	t.Helper()
+	var posts models.PostList
+	expect(t, "POST", "/api/thread/"+thread+"/create", []map[string]interface{}{
+		{"author": author, "message": "post", "parent": parent},
+	}, http.StatusCreated, &posts)
+	if len(posts) != 1 {
+		t.Fatalf("created %d posts, want 1", len(posts))
+	}
+	return posts[0].ID
+}
+
+// login creates nickname with a password and returns a token for it.
+func login(t *testing.T, nickname string) string {
+	t.Helper()
+	expect(t, "POST", "/api/user/"+nickname+"/create", map[string]string{
+		"fullname": "Full " + nickname,
+		"about":    "about " + nickname,
+		"email":    nickname + "@example.com",
+		"password": "password " + nickname,
+	}, http.StatusCreated, nil)
+	var session models.Session
+	expect(t, "POST", "/api/auth/login", map[string]string{
+		"nickname": nickname,
+		"password": "password " + nickname,
+	}, http.StatusOK, &session)
+	return session.Token
+}
+
+// adminToken logs in as a new admin user.
+func adminToken(t *testing.T) string {
+	t.Helper()
+	token := login(t, "root")
+	if _, err := pool.Exec("UPDATE dbforum.credentials SET is_admin = true WHERE nickname = 'root'"); err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
+
+func TestUserRoutes(t *testing.T) {
+	setup(t)
+	alice := login(t, "alice")
+	createUser(t, "bob")
+
+	var conflicts models.UserList
+	expect(t, "POST", "/api/user/ALICE/create", map[string]string{
+		"fullname": "Alice", "email": "bob@example.com",
+	}, http.StatusConflict, &conflicts)
+	if len(conflicts) != 2 {
+		t.Errorf("conflict returned %v, want alice and bob", conflicts)
+	}
+
+	var user models.User
+	expect(t, "GET", "/api/user/Alice/profile", nil, http.StatusOK, &user)
+	if user.Nickname != "alice" || user.Email != "alice@example.com" {
+		t.Errorf("profile = %+v", user)
+	}
+
+	expectAs(t, alice, "POST", "/api/user/alice/profile", map[string]string{"about": "gopher"}, http.StatusOK, &user)
+	if user.About != "gopher" || user.Fullname != "Full alice" {
+		t.Errorf("changed profile = %+v", user)
+	}
+	expectAs(t, alice, "POST", "/api/user/alice/profile", map[string]string{"email": "BOB@example.com"}, http.StatusConflict, nil)
+	expect(t, "POST", "/api/user/alice/profile", map[string]string{"about": "x"}, http.StatusUnauthorized, nil)
+	expect(t, "GET", "/api/user/mallory/profile", nil, http.StatusNotFound, nil)
+}
+
+func TestForumRoutes(t *testing.T) {
+	setup(t)
+	createUser(t, "alice")
+	createUser(t, "bob")
+	createForum(t, "golang", "ALICE")
+
+	var forum models.Forum
+	expect(t, "POST", "/api/forum/create", map[string]string{
+		"title": "Other", "user": "bob", "slug": "GoLang",
+	}, http.StatusConflict, &forum)
+	if forum.Title != "Forum golang" || forum.User != "alice" {
+		t.Errorf("conflict returned %+v, want the existing forum", forum)
+	}
+	expect(t, "POST", "/api/forum/create", map[string]string{
+		"title": "Rust", "user": "mallory", "slug": "rust",
+	}, http.StatusNotFound, nil)
+
+	createThread(t, "golang", "bob", "generics")
+	var thread models.Thread
+	expect(t, "POST", "/api/forum/golang/create", map[string]string{
+		"title": "Again", "author": "alice", "message": "m", "slug": "GENERICS",
+	}, http.StatusConflict, &thread)
+	if thread.Title != "Thread generics" {
+		t.Errorf("conflict returned %+v, want the existing thread", thread)
+	}
+	expect(t, "POST", "/api/forum/rust/create", map[string]string{
+		"title": "t", "author": "alice", "message": "m",
+	}, http.StatusNotFound, nil)
+	expect(t, "POST", "/api/forum/golang/create", map[string]string{
+		"title": "t", "author": "mallory", "message": "m",
+	}, http.StatusNotFound, nil)
+
+	expect(t, "GET", "/api/forum/GOLANG/details", nil, http.StatusOK, &forum)
+	if forum.Slug != "golang" || forum.Threads != 1 {
+		t.Errorf("details = %+v", forum)
+	}
+	expect(t, "GET", "/api/forum/rust/details", nil, http.StatusNotFound, nil)
+
+	var users models.UserList
+	expect(t, "GET", "/api/forum/golang/users?limit=10", nil, http.StatusOK, &users)
+	if len(users) != 1 || users[0].Nickname != "bob" {
+		t.Errorf("forum users = %v, want [bob]", users)
+	}
+	expect(t, "GET", "/api/forum/rust/users", nil, http.StatusNotFound, nil)
+
+	var threads models.ThreadList
+	expect(t, "GET", "/api/forum/golang/threads?limit=10&desc=true", nil, http.StatusOK, &threads)
+	if len(threads) != 1 || threads[0].Slug != "generics" {
+		t.Errorf("forum threads = %v", threads)
+	}
+	expect(t, "GET", "/api/forum/rust/threads", nil, http.StatusNotFound, nil)
+}
+
+func TestThreadRoutes(t *testing.T) {
+	setup(t)
+	alice := login(t, "alice")
+	createUser(t, "bob")
+	createForum(t, "golang", "alice")
+	thread := createThread(t, "golang", "alice", "generics")
+	id := fmt.Sprint(thread.ID)
+
+	var got models.Thread
+	expect(t, "GET", "/api/thread/"+id+"/details", nil, http.StatusOK, &got)
+	if got.Slug != "generics" {
+		t.Errorf("details = %+v", got)
+	}
+	expect(t, "GET", "/api/thread/missing/details", nil, http.StatusNotFound, nil)
+
+	expect(t, "POST", "/api/thread/generics/details", map[string]string{"title": "x"}, http.StatusUnauthorized, nil)
+	expectAs(t, alice, "POST", "/api/thread/generics/details", map[string]string{"title": "Generics!"}, http.StatusOK, &got)
+	if got.Title != "Generics!" || got.Message != "message" {
+		t.Errorf("changed thread = %+v", got)
+	}
+	expect(t, "POST", "/api/thread/missing/details", map[string]string{"title": "x"}, http.StatusNotFound, nil)
+
+	expect(t, "POST", "/api/thread/"+id+"/vote", map[string]interface{}{"nickname": "bob", "voice": 1}, http.StatusOK, &got)
+	expect(t, "POST", "/api/thread/generics/vote", map[string]interface{}{"nickname": "alice", "voice": 1}, http.StatusOK, &got)
+	expect(t, "POST", "/api/thread/generics/vote", map[string]interface{}{"nickname": "BOB", "voice": -1}, http.StatusOK, &got)
+	if got.Votes != 0 {
+		t.Errorf("votes = %d, want 0", got.Votes)
+	}
+	expect(t, "POST", "/api/thread/generics/vote", map[string]interface{}{"nickname": "mallory", "voice": 1}, http.StatusNotFound, nil)
+	expect(t, "POST", "/api/thread/missing/vote", map[string]interface{}{"nickname": "bob", "voice": 1}, http.StatusNotFound, nil)
+}
+
+func TestPostRoutes(t *testing.T) {
+	setup(t)
+	createUser(t, "alice")
+	bob := login(t, "bob")
+	createForum(t, "golang", "alice")
+	createThread(t, "golang", "alice", "generics")
+	other := createThread(t, "golang", "bob", "other")
+
+	root := createPost(t, "generics", "bob", 0)
+	foreign := createPost(t, fmt.Sprint(other.ID), "bob", 0)
+
+	expect(t, "POST", "/api/thread/generics/create", []map[string]interface{}{
+		{"author": "alice", "message": "m", "parent": foreign},
+	}, http.StatusConflict, nil)
+	expect(t, "POST", "/api/thread/generics/create", []map[string]interface{}{
+		{"author": "mallory", "message": "m"},
+	}, http.StatusNotFound, nil)
+	expect(t, "POST", "/api/thread/missing/create", []map[string]interface{}{
+		{"author": "alice", "message": "m"},
+	}, http.StatusNotFound, nil)
+
+	var posts models.PostList
+	expect(t, "POST", "/api/thread/generics/create", []map[string]interface{}{}, http.StatusCreated, &posts)
+	if len(posts) != 0 {
+		t.Errorf("empty batch created %v", posts)
+	}
+
+	var info models.PostInfo
+	path := fmt.Sprintf("/api/post/%d/details", root)
+	expect(t, "GET", path+"?related=user,thread,forum", nil, http.StatusOK, &info)
+	if info.Post == nil || info.Author == nil || info.Thread == nil || info.Forum == nil {
+		t.Fatalf("related details missing: %+v", info)
+	}
+	if info.Author.Nickname != "bob" || info.Thread.Slug != "generics" || info.Forum.Posts != 2 {
+		t.Errorf("details = %+v %+v %+v", info.Author, info.Thread, info.Forum)
+	}
+	expect(t, "GET", "/api/post/999999/details", nil, http.StatusNotFound, nil)
+
+	var post models.Post
+	expectAs(t, bob, "POST", path, map[string]string{"message": "post"}, http.StatusOK, &post)
+	if post.IsEdited {
+		t.Error("unchanged message marked the post as edited")
+	}
+	expectAs(t, bob, "POST", path, map[string]string{"message": "edited"}, http.StatusOK, &post)
+	if !post.IsEdited || post.Message != "edited" {
+		t.Errorf("changed post = %+v", post)
+	}
+	expect(t, "POST", "/api/post/999999/details", map[string]string{"message": "x"}, http.StatusNotFound, nil)
+
+	var users models.UserList
+	expect(t, "GET", "/api/forum/golang/users?limit=10&desc=true", nil, http.StatusOK, &users)
+	if len(users) != 2 || users[0].Nickname != "bob" {
+		t.Errorf("forum users = %v, want [bob alice]", users)
+	}
+}
+
+func TestGetPostsSorts(t *testing.T) {
+	setup(t)
+	createUser(t, "alice")
+	createForum(t, "golang", "alice")
+	createThread(t, "golang", "alice", "generics")
+
+	// 1
+	// ├── 2
+	// │   └── 4
+	// └── 3
+	// 5
+	// └── 6
+	p1 := createPost(t, "generics", "alice", 0)
+	p2 := createPost(t, "generics", "alice", p1)
+	p3 := createPost(t, "generics", "alice", p1)
+	p4 := createPost(t, "generics", "alice", p2)
+	p5 := createPost(t, "generics", "alice", 0)
+	p6 := createPost(t, "generics", "alice", p5)
+
+	tests := []struct {
+		query string
+		want  []uint64
+	}{
+		{"sort=flat&limit=3", []uint64{p1, p2, p3}},
+		{fmt.Sprintf("sort=flat&limit=2&since=%d&desc=true", p4), []uint64{p3, p2}},
+		{"limit=2&desc=true", []uint64{p6, p5}},
+		{"sort=tree&limit=10", []uint64{p1, p2, p4, p3, p5, p6}},
+		{fmt.Sprintf("sort=tree&limit=3&since=%d", p4), []uint64{p3, p5, p6}},
+		{"sort=tree&limit=10&desc=true", []uint64{p6, p5, p3, p4, p2, p1}},
+		{fmt.Sprintf("sort=tree&limit=2&since=%d&desc=true", p5), []uint64{p3, p4}},
+		{"sort=parent_tree&limit=1", []uint64{p1, p2, p4, p3}},
+		{fmt.Sprintf("sort=parent_tree&limit=1&since=%d", p1), []uint64{p5, p6}},
+		{"sort=parent_tree&limit=10&desc=true", []uint64{p5, p6, p1, p2, p4, p3}},
+		{fmt.Sprintf("sort=parent_tree&limit=1&since=%d&desc=true", p5), []uint64{p1, p2, p4, p3}},
+	}
+	for _, tt := range tests {
+		var posts models.PostList
+		expect(t, "GET", "/api/thread/generics/posts?"+tt.query, nil, http.StatusOK, &posts)
+		got := []uint64{}
+		for _, p := range posts {
+			got = append(got, p.ID)
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.query, got, tt.want)
+		}
+	}
+	expect(t, "GET", "/api/thread/missing/posts", nil, http.StatusNotFound, nil)
+}
+
+func TestServiceRoutes(t *testing.T) {
+	setup(t)
+	createUser(t, "alice")
+	createForum(t, "golang", "alice")
+	createThread(t, "golang", "alice", "generics")
+	createPost(t, "generics", "alice", 0)
+
+	var status models.NumRecords
+	expect(t, "GET", "/api/service/status", nil, http.StatusOK, &status)
+	if status != (models.NumRecords{User: 1, Forum: 1, Thread: 1, Post: 1}) {
+		t.Errorf("status = %+v", status)
+	}
+
+	expect(t, "POST", "/api/service/clear", nil, http.StatusOK, nil)
+	expect(t, "GET", "/api/service/status", nil, http.StatusOK, &status)
+	if status != (models.NumRecords{}) {
+		t.Errorf("status after clear = %+v", status)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	setup(t)
+	expect(t, "GET", "/api/thread/missing/details", nil, http.StatusNotFound, nil)
+
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+	req.SetRequestURI("http://forum/metrics")
+	if err := client.DoTimeout(req, resp, 10*time.Second); err != nil {
+		t.Fatal(err)
+	}
+	body := string(resp.Body())
+	for _, want := range []string{
+		`dbforum_http_requests_total{method="GET",route="/api/thread/{slug_or_id}/details",status="404"}`,
+		`dbforum_handler_errors_total{error="thread_not_found",route="/api/thread/{slug_or_id}/details"}`,
+		`dbforum_db_pool_max_connections 10`,
+		`dbforum_db_pool_acquired_connections`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("/metrics has no %s", want)
+		}
+	}
+}
+
+func TestValidation(t *testing.T) {
+	setup(t)
+	createUser(t, "alice")
+	createForum(t, "golang", "alice")
+	createThread(t, "golang", "alice", "generics")
+
+	tests := []struct {
+		method string
+		path   string
+		body   interface{}
+		field  string
+	}{
+		{"POST", "/api/user/bad%20nick/create", map[string]string{"fullname": "x", "email": "x@example.com"}, "nickname"},
+		{"POST", "/api/user/bob/create", map[string]string{"fullname": "Bob", "email": "bob"}, "email"},
+		{"POST", "/api/forum/create", map[string]string{"title": "Go", "user": "alice"}, "slug"},
+		{"POST", "/api/forum/golang/create", map[string]interface{}{"title": "t", "author": "alice", "message": 1}, "message"},
+		{"POST", "/api/thread/generics/create", []map[string]interface{}{{"author": "alice"}}, "[0].message"},
+		{"POST", "/api/thread/generics/vote", map[string]interface{}{"nickname": "alice", "voice": "up"}, "voice"},
+	}
+	for _, tt := range tests {
+		var resp struct {
+			Message string `json:"message"`
+			Field   string `json:"field"`
+		}
+		expect(t, tt.method, tt.path, tt.body, http.StatusBadRequest, &resp)
+		if resp.Field != tt.field || resp.Message == "" {
+			t.Errorf("%s %s: error %+v, want field %q", tt.method, tt.path, resp, tt.field)
+		}
+	}
+}
+
+func TestErrorBodies(t *testing.T) {
+	setup(t)
+	alice := login(t, "alice")
+	createUser(t, "bob")
+	createForum(t, "golang", "alice")
+	createThread(t, "golang", "alice", "generics")
+
+	tests := []struct {
+		token  string
+		method string
+		path   string
+		body   interface{}
+		status int
+		want   map[string]string
+	}{
+		{"", "POST", "/api/thread/generics/create", []map[string]string{{"author": "mallory", "message": "m"}},
+			http.StatusNotFound, map[string]string{"code": "user_not_found", "nickname": "mallory"}},
+		{"", "GET", "/api/thread/missing/details", nil,
+			http.StatusNotFound, map[string]string{"code": "thread_not_found", "slug_or_id": "missing"}},
+		{"", "GET", "/api/forum/rust/details", nil,
+			http.StatusNotFound, map[string]string{"code": "forum_not_found", "slug": "rust"}},
+		{alice, "POST", "/api/user/alice/profile", map[string]string{"email": "bob@example.com"},
+			http.StatusConflict, map[string]string{"code": "conflict", "nickname": "bob"}},
+		{"", "GET", "/api/post/999999/details", nil,
+			http.StatusNotFound, map[string]string{"code": "post_not_found", "id": "999999"}},
+	}
+	for _, tt := range tests {
+		var got map[string]string
+		expectAs(t, tt.token, tt.method, tt.path, tt.body, tt.status, &got)
+		for k, v := range tt.want {
+			if got[k] != v {
+				t.Errorf("%s %s: %s = %q, want %q (%v)", tt.method, tt.path, k, got[k], v, got)
+			}
+		}
+		if got["message"] == "" {
+			t.Errorf("%s %s: empty message", tt.method, tt.path)
+		}
+	}
+}
+
+func TestForumUsersFollowProfileChanges(t *testing.T) {
+	setup(t)
+	alice := login(t, "alice")
+	createForum(t, "golang", "alice")
+	createThread(t, "golang", "alice", "generics")
+
+	forumUser := func() models.User {
+		t.Helper()
+		var users models.UserList
+		expect(t, "GET", "/api/forum/golang/users", nil, http.StatusOK, &users)
+		if len(users) != 1 {
+			t.Fatalf("forum users = %v, want alice", users)
+		}
+		return users[0]
+	}
+
+	expectAs(t, alice, "POST", "/api/user/alice/profile", map[string]string{"fullname": "Alice Liddell"}, http.StatusOK, nil)
+	if got := forumUser(); got.Fullname != "Alice Liddell" {
+		t.Errorf("forum user fullname = %q after profile change", got.Fullname)
+	}
+
+	if _, err := pool.Exec("UPDATE dbforum.forum_users SET about = 'stale'"); err != nil {
+		t.Fatal(err)
+	}
+	repaired, err := userRepo.NewRepo(pool).RepairForumUsers()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if repaired != 1 {
+		t.Errorf("repaired %d rows, want 1", repaired)
+	}
+	if got := forumUser(); got.About != "about alice" {
+		t.Errorf("forum user about = %q after repair", got.About)
+	}
+}
+
+func TestDeletePost(t *testing.T) {
+	setup(t)
+	alice := login(t, "alice")
+	createForum(t, "golang", "alice")
+	thread := createThread(t, "golang", "alice", "generics")
+	root := createPost(t, "generics", "alice", 0)
+	child := createPost(t, "generics", "alice", root)
+	id := fmt.Sprint(root)
+
+	var post models.Post
+	expect(t, "DELETE", "/api/post/"+id, nil, http.StatusUnauthorized, nil)
+	expectAs(t, alice, "DELETE", "/api/post/"+id, nil, http.StatusOK, &post)
+	if !post.Deleted || post.Message != "" {
+		t.Errorf("deleted post = %+v, want a tombstone", post)
+	}
+	expect(t, "DELETE", "/api/post/999999", nil, http.StatusNotFound, nil)
+
+	var forum models.Forum
+	expect(t, "GET", "/api/forum/golang/details", nil, http.StatusOK, &forum)
+	if forum.Posts != 1 {
+		t.Errorf("forum posts = %d, want 1", forum.Posts)
+	}
+
+	var posts models.PostList
+	expect(t, "GET", fmt.Sprintf("/api/thread/%d/posts?sort=tree", thread.ID), nil, http.StatusOK, &posts)
+	if len(posts) != 2 || posts[0].ID != root || !posts[0].Deleted || posts[1].ID != child {
+		t.Errorf("tree = %+v, want the tombstone followed by its reply", posts)
+	}
+	expectAs(t, alice, "POST", "/api/post/"+id+"/details", map[string]string{"message": "x"}, http.StatusNotFound, nil)
+
+	expect(t, "POST", "/api/admin/post/"+id+"/restore", nil, http.StatusUnauthorized, nil)
+	if status := callAs(t, adminToken(t), "POST", "/api/admin/post/"+id+"/restore", nil, &post); status != http.StatusOK {
+		t.Fatalf("restore: status %d, want %d", status, http.StatusOK)
+	}
+	if post.Deleted || post.Message != "post" {
+		t.Errorf("restored post = %+v", post)
+	}
+	expect(t, "GET", "/api/forum/golang/details", nil, http.StatusOK, &forum)
+	if forum.Posts != 2 {
+		t.Errorf("forum posts after restore = %d, want 2", forum.Posts)
+	}
+}
+
+func TestDeleteThread(t *testing.T) {
+	setup(t)
+	alice := login(t, "alice")
+	bob := login(t, "bob")
+	createForum(t, "golang", "alice")
+	createThread(t, "golang", "alice", "kept")
+	createPost(t, "kept", "alice", 0)
+	thread := createThread(t, "golang", "alice", "generics")
+	root := createPost(t, "generics", "bob", 0)
+	createPost(t, "generics", "bob", root)
+	expectAs(t, bob, "DELETE", fmt.Sprintf("/api/post/%d", root), nil, http.StatusOK, nil)
+	expect(t, "POST", "/api/thread/generics/vote", map[string]interface{}{"nickname": "bob", "voice": 1}, http.StatusOK, nil)
+
+	expect(t, "DELETE", "/api/thread/generics", nil, http.StatusUnauthorized, nil)
+	expectAs(t, bob, "DELETE", "/api/thread/generics", nil, http.StatusForbidden, nil)
+	expect(t, "GET", "/api/thread/generics/details", nil, http.StatusOK, nil)
+
+	var deleted models.Thread
+	expectAs(t, alice, "DELETE", "/api/thread/generics", nil, http.StatusOK, &deleted)
+	if deleted.ID != thread.ID {
+		t.Errorf("deleted thread %d, want %d", deleted.ID, thread.ID)
+	}
+	expectAs(t, alice, "DELETE", "/api/thread/generics", nil, http.StatusNotFound, nil)
+	expect(t, "GET", fmt.Sprintf("/api/post/%d/details", root), nil, http.StatusNotFound, nil)
+
+	var forum models.Forum
+	expect(t, "GET", "/api/forum/golang/details", nil, http.StatusOK, &forum)
+	if forum.Threads != 1 || forum.Posts != 1 {
+		t.Errorf("forum counters = %d threads, %d posts, want 1 and 1", forum.Threads, forum.Posts)
+	}
+	var users models.UserList
+	expect(t, "GET", "/api/forum/golang/users", nil, http.StatusOK, &users)
+	if len(users) != 1 || users[0].Nickname != "alice" {
+		t.Errorf("forum users = %+v, want only alice", users)
+	}
+
+	createThread(t, "golang", "bob", "mine")
+	expectAs(t, bob, "DELETE", "/api/thread/mine", nil, http.StatusForbidden, nil)
+}
+
+func TestArchiveThread(t *testing.T) {
+	setup(t)
+	alice := login(t, "alice")
+	createForum(t, "golang", "alice")
+	createThread(t, "golang", "alice", "generics")
+	id := fmt.Sprint(createPost(t, "generics", "alice", 0))
+
+	token := adminToken(t)
+	var thread models.Thread
+	if status := callAs(t, token, "POST", "/api/admin/thread/generics/archive", nil, &thread); status != http.StatusOK {
+		t.Fatalf("archive: status %d, want %d", status, http.StatusOK)
+	}
+	if !thread.Archived {
+		t.Errorf("thread = %+v, want archived", thread)
+	}
+	expect(t, "GET", "/api/thread/generics/details", nil, http.StatusOK, nil)
+	expect(t, "GET", "/api/thread/generics/posts", nil, http.StatusOK, nil)
+	expect(t, "POST", "/api/thread/generics/create", []map[string]string{{"author": "alice", "message": "m"}}, http.StatusConflict, nil)
+	expect(t, "POST", "/api/thread/generics/vote", map[string]interface{}{"nickname": "alice", "voice": 1}, http.StatusConflict, nil)
+	expectAs(t, alice, "POST", "/api/thread/generics/details", map[string]string{"title": "x"}, http.StatusConflict, nil)
+	expectAs(t, alice, "POST", "/api/post/"+id+"/details", map[string]string{"message": "x"}, http.StatusConflict, nil)
+	expectAs(t, alice, "DELETE", "/api/post/"+id, nil, http.StatusConflict, nil)
+	expectAs(t, alice, "DELETE", "/api/thread/generics", nil, http.StatusConflict, nil)
+
+	if status := callAs(t, token, "DELETE", "/api/admin/thread/generics/archive", nil, &thread); status != http.StatusOK {
+		t.Fatalf("unarchive: status %d, want %d", status, http.StatusOK)
+	}
+	if thread.Archived {
+		t.Errorf("thread = %+v, want unarchived", thread)
+	}
+	expect(t, "POST", "/api/thread/generics/vote", map[string]interface{}{"nickname": "alice", "voice": 1}, http.StatusOK, nil)
+}
+
+func TestAuth(t *testing.T) {
+	setup(t)
+	expect(t, "POST", "/api/user/alice/create", map[string]string{
+		"fullname": "Alice",
+		"email":    "alice@example.com",
+		"password": "correct horse",
+	}, http.StatusCreated, nil)
+	createUser(t, "bob")
+	createForum(t, "golang", "alice")
+	createThread(t, "golang", "alice", "generics")
+
+	expect(t, "POST", "/api/auth/login", map[string]string{"nickname": "alice", "password": "wrong horse"}, http.StatusUnauthorized, nil)
+	expect(t, "POST", "/api/auth/login", map[string]string{"nickname": "bob", "password": "correct horse"}, http.StatusUnauthorized, nil)
+	var session models.Session
+	expect(t, "POST", "/api/auth/login", map[string]string{"nickname": "ALICE", "password": "correct horse"}, http.StatusOK, &session)
+	if session.Token == "" || session.Nickname != "alice" {
+		t.Fatalf("session = %+v", session)
+	}
+
+	post := []map[string]string{{"author": "alice", "message": "m"}}
+	if status := callAs(t, session.Token, "POST", "/api/thread/generics/create", post, nil); status != http.StatusCreated {
+		t.Errorf("posting as yourself: status %d, want %d", status, http.StatusCreated)
+	}
+	vote := map[string]interface{}{"nickname": "bob", "voice": 1}
+	if status := callAs(t, session.Token, "POST", "/api/thread/generics/vote", vote, nil); status != http.StatusForbidden {
+		t.Errorf("voting as someone else: status %d, want %d", status, http.StatusForbidden)
+	}
+	if status := callAs(t, session.Token, "POST", "/api/user/bob/profile", map[string]string{"about": "x"}, nil); status != http.StatusForbidden {
+		t.Errorf("changing someone else's profile: status %d, want %d", status, http.StatusForbidden)
+	}
+	if status := callAs(t, "forged", "GET", "/api/thread/generics/details", nil, nil); status != http.StatusUnauthorized {
+		t.Errorf("unknown token: status %d, want %d", status, http.StatusUnauthorized)
+	}
+
+	if status := callAs(t, session.Token, "POST", "/api/auth/logout", nil, nil); status != http.StatusNoContent {
+		t.Errorf("logout: status %d, want %d", status, http.StatusNoContent)
+	}
+	if status := callAs(t, session.Token, "POST", "/api/thread/generics/create", post, nil); status != http.StatusUnauthorized {
+		t.Errorf("revoked token: status %d, want %d", status, http.StatusUnauthorized)
+	}
+}
+
+// serve runs one request through handler, with a bearer token unless token
+// is empty, and returns the status.
+func serve(t *testing.T, handler fasthttp.RequestHandler, token string, method string, path string, body interface{}) int {
+	t.Helper()
+	var req fasthttp.Request
+	req.Header.SetMethod(method)
+	req.SetRequestURI("http://forum" + path)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.SetContentType("application/json")
+		req.SetBody(data)
+	}
+	var ctx fasthttp.RequestCtx
+	ctx.Init(&req, nil, nil)
+	handler(&ctx)
+	return ctx.Response.StatusCode()
+}
+
+func TestAuthRequired(t *testing.T) {
+	setup(t)
+	alice := login(t, "alice")
+	createUser(t, "bob")
+	createForum(t, "golang", "alice")
+	createThread(t, "golang", "alice", "generics")
+
+	conf := config.Default()
+	handler, err := server.New(pool, conf.Auth, conf.Idempotency)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	post := []map[string]string{{"author": "alice", "message": "m"}}
+	for _, tt := range []struct {
+		token  string
+		method string
+		path   string
+		body   interface{}
+		status int
+	}{
+		{"", "POST", "/api/thread/generics/create", post, http.StatusUnauthorized},
+		{"", "POST", "/api/thread/generics/vote", map[string]interface{}{"nickname": "alice", "voice": 1}, http.StatusUnauthorized},
+		{"", "POST", "/api/user/bob/profile", map[string]string{"about": "x"}, http.StatusUnauthorized},
+		{"", "POST", "/api/forum/create", map[string]string{"title": "Rust", "user": "bob", "slug": "rust"}, http.StatusUnauthorized},
+		{"", "GET", "/api/thread/generics/details", nil, http.StatusOK},
+		{"", "POST", "/api/user/carol/create", map[string]string{"fullname": "Carol", "email": "carol@example.com"}, http.StatusCreated},
+		{alice, "POST", "/api/thread/generics/create", post, http.StatusCreated},
+	} {
+		if status := serve(t, handler, tt.token, tt.method, tt.path, tt.body); status != tt.status {
+			t.Errorf("%s %s (token %q): status %d, want %d", tt.method, tt.path, tt.token, status, tt.status)
+		}
+	}
+}
+
+func TestAuthorization(t *testing.T) {
+	setup(t)
+	alice := login(t, "alice")
+	bob := login(t, "bob")
+	createForum(t, "golang", "alice")
+	createThread(t, "golang", "alice", "generics")
+	id := fmt.Sprint(createPost(t, "generics", "bob", 0))
+
+	// The audit log outlives /api/service/clear, so only entries written
+	// by this test are checked.
+	var lastID int64
+	if err := pool.QueryRow("SELECT COALESCE(max(id), 0) FROM dbforum.audit_log").Scan(&lastID); err != nil {
+		t.Fatal(err)
+	}
+
+	edit := map[string]string{"message": "edited"}
+	if status := callAs(t, bob, "POST", "/api/thread/generics/details", map[string]string{"title": "x"}, nil); status != http.StatusForbidden {
+		t.Errorf("editing someone else's thread: status %d, want %d", status, http.StatusForbidden)
+	}
+	if status := callAs(t, alice, "POST", "/api/post/"+id+"/details", edit, nil); status != http.StatusOK {
+		t.Errorf("forum owner editing a post: status %d, want %d", status, http.StatusOK)
+	}
+	if status := callAs(t, bob, "POST", "/api/admin/thread/generics/archive", nil, nil); status != http.StatusForbidden {
+		t.Errorf("archiving as a non-admin: status %d, want %d", status, http.StatusForbidden)
+	}
+	if status := callAs(t, bob, "DELETE", "/api/post/"+id, nil, nil); status != http.StatusOK {
+		t.Errorf("deleting your own post: status %d, want %d", status, http.StatusOK)
+	}
+
+	var routes []string
+	rows, err := pool.Query("SELECT route FROM dbforum.audit_log WHERE actor = 'bob' AND id > $1 ORDER BY id", lastID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var route string
+		if err := rows.Scan(&route); err != nil {
+			t.Fatal(err)
+		}
+		routes = append(routes, route)
+	}
+	want := []string{"/api/thread/{slug_or_id}/details", "/api/admin/thread/{slug_or_id}/archive"}
+	if !reflect.DeepEqual(routes, want) {
+		t.Errorf("audit log routes = %v, want %v", routes, want)
+	}
+}
+
+func TestForumRoles(t *testing.T) {
+	setup(t)
+	alice := login(t, "alice")
+	bob := login(t, "bob")
+	createUser(t, "carol")
+	createForum(t, "golang", "alice")
+	createThread(t, "golang", "alice", "generics")
+	id := fmt.Sprint(createPost(t, "generics", "carol", 0))
+
+	moderator := map[string]string{"role": "moderator"}
+	if status := callAs(t, bob, "POST", "/api/forum/golang/roles/bob", moderator, nil); status != http.StatusForbidden {
+		t.Errorf("granting yourself a role: status %d, want %d", status, http.StatusForbidden)
+	}
+	expect(t, "POST", "/api/forum/golang/roles/bob", map[string]string{"role": "owner"}, http.StatusBadRequest, nil)
+	var role models.ForumRole
+	if status := callAs(t, alice, "POST", "/api/forum/golang/roles/BOB", moderator, &role); status != http.StatusOK {
+		t.Fatalf("grant: status %d, want %d", status, http.StatusOK)
+	}
+	if role.Nickname != "bob" || role.Role != "moderator" {
+		t.Errorf("role = %+v", role)
+	}
+
+	if status := callAs(t, bob, "POST", "/api/post/"+id+"/details", map[string]string{"message": "x"}, nil); status != http.StatusOK {
+		t.Errorf("moderator editing a post: status %d, want %d", status, http.StatusOK)
+	}
+	if status := callAs(t, bob, "POST", "/api/admin/thread/generics/archive", nil, nil); status != http.StatusOK {
+		t.Errorf("moderator archiving a thread: status %d, want %d", status, http.StatusOK)
+	}
+	if status := callAs(t, bob, "DELETE", "/api/admin/thread/generics/archive", nil, nil); status != http.StatusOK {
+		t.Errorf("moderator unarchiving a thread: status %d, want %d", status, http.StatusOK)
+	}
+	if status := callAs(t, bob, "POST", "/api/forum/golang/roles/carol", map[string]string{"role": "banned"}, nil); status != http.StatusOK {
+		t.Errorf("moderator banning a user: status %d, want %d", status, http.StatusOK)
+	}
+	expect(t, "POST", "/api/thread/generics/create", []map[string]string{{"author": "carol", "message": "m"}}, http.StatusForbidden, nil)
+	expect(t, "POST", "/api/thread/generics/vote", map[string]interface{}{"nickname": "carol", "voice": 1}, http.StatusForbidden, nil)
+
+	var roles []models.ForumRole
+	expect(t, "GET", "/api/forum/golang/roles", nil, http.StatusOK, &roles)
+	want := []models.ForumRole{
+		{Forum: "golang", Nickname: "alice", Role: "owner"},
+		{Forum: "golang", Nickname: "bob", Role: "moderator"},
+		{Forum: "golang", Nickname: "carol", Role: "banned"},
+	}
+	if !reflect.DeepEqual(roles, want) {
+		t.Errorf("roles = %+v, want %+v", roles, want)
+	}
+
+	if status := callAs(t, alice, "DELETE", "/api/forum/golang/roles/bob", nil, nil); status != http.StatusNoContent {
+		t.Errorf("revoke: status %d, want %d", status, http.StatusNoContent)
+	}
+	if status := callAs(t, bob, "POST", "/api/post/"+id+"/details", map[string]string{"message": "y"}, nil); status != http.StatusForbidden {
+		t.Errorf("former moderator editing a post: status %d, want %d", status, http.StatusForbidden)
+	}
+	expect(t, "GET", "/api/forum/rust/roles", nil, http.StatusNotFound, nil)
+}
+
+func TestThreadStatus(t *testing.T) {
+	setup(t)
+	alice := login(t, "alice")
+	bob := login(t, "bob")
+	createForum(t, "golang", "alice")
+	createThread(t, "golang", "bob", "generics")
+	createThread(t, "golang", "bob", "modules")
+	var lastID int64
+	if err := pool.QueryRow("SELECT COALESCE(max(id), 0) FROM dbforum.audit_log").Scan(&lastID); err != nil {
+		t.Fatal(err)
+	}
+
+	if status := callAs(t, bob, "POST", "/api/thread/generics/details", map[string]bool{"locked": true}, nil); status != http.StatusForbidden {
+		t.Errorf("author locking a thread: status %d, want %d", status, http.StatusForbidden)
+	}
+	var thread models.Thread
+	status := map[string]bool{"locked": true, "pinned": true, "closed": true}
+	if code := callAs(t, alice, "POST", "/api/thread/generics/details", status, &thread); code != http.StatusOK {
+		t.Fatalf("owner setting status: status %d, want %d", code, http.StatusOK)
+	}
+	if !thread.Locked || !thread.Pinned || !thread.Closed {
+		t.Errorf("thread = %+v, want locked, pinned and closed", thread)
+	}
+	expect(t, "POST", "/api/thread/generics/create", []map[string]string{{"author": "bob", "message": "m"}}, http.StatusForbidden, nil)
+	expect(t, "POST", "/api/thread/generics/vote", map[string]interface{}{"nickname": "bob", "voice": 1}, http.StatusForbidden, nil)
+	expect(t, "POST", "/api/thread/modules/vote", map[string]interface{}{"nickname": "bob", "voice": 1}, http.StatusOK, nil)
+	var audited []string
+	rows, err := pool.Query("SELECT route FROM dbforum.audit_log WHERE id > $1 ORDER BY id", lastID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for rows.Next() {
+		var route string
+		if err := rows.Scan(&route); err != nil {
+			t.Fatal(err)
+		}
+		audited = append(audited, route)
+	}
+	rows.Close()
+	if want := []string{"/api/thread/{slug_or_id}/details"}; !reflect.DeepEqual(audited, want) {
+		t.Errorf("audit log routes = %v, want only the refused status change %v", audited, want)
+	}
+
+	for _, desc := range []string{"false", "true"} {
+		var threads []models.Thread
+		expect(t, "GET", "/api/forum/golang/threads?desc="+desc, nil, http.StatusOK, &threads)
+		if len(threads) != 2 || threads[0].Slug != "generics" {
+			t.Errorf("desc=%s: threads = %+v, want the pinned one first", desc, threads)
+		}
+	}
+	// Both threads were created at 2021-06-01T12:00:00Z; since leaves out
+	// the unpinned one but not the pinned one.
+	for _, query := range []string{"since=2021-06-02T00:00:00Z", "since=2021-05-01T00:00:00Z&desc=true"} {
+		var threads []models.Thread
+		expect(t, "GET", "/api/forum/golang/threads?"+query, nil, http.StatusOK, &threads)
+		if len(threads) != 1 || threads[0].Slug != "generics" {
+			t.Errorf("%s: threads = %+v, want only the pinned one", query, threads)
+		}
+	}
+}
+
+func TestSearch(t *testing.T) {
+	setup(t)
+	createUser(t, "alice")
+	bob := login(t, "bob")
+	createForum(t, "golang", "alice")
+	createForum(t, "rust", "bob")
+	createThread(t, "golang", "alice", "generics")
+	createThread(t, "rust", "bob", "traits")
+	id := fmt.Sprint(createPost(t, "generics", "bob", 0))
+
+	var results []models.SearchResult
+	expect(t, "GET", "/api/search?q=thread+or+post", nil, http.StatusOK, &results)
+	if len(results) != 3 {
+		t.Fatalf("results = %+v, want both threads and the post", results)
+	}
+	expect(t, "GET", "/api/search?q=thread+or+post&forum=rust", nil, http.StatusOK, &results)
+	if len(results) != 1 || results[0].Kind != "thread" || results[0].Forum != "rust" {
+		t.Errorf("rust results = %+v", results)
+	}
+
+	var seen []string
+	next := "/api/search?q=thread+or+post&limit=2&since=2000-01-01T00:00:00Z"
+	for i := 0; i < 5 && next != ""; i++ {
+		if i > 0 && !strings.Contains(next, "since=") {
+			t.Errorf("next link %q should keep the since filter", next)
+		}
+		next, _ = links(t, next, &results)
+		for _, r := range results {
+			seen = append(seen, fmt.Sprint(r.Kind, r.ID))
+		}
+	}
+	if len(seen) != 3 || seen[0] == seen[1] || seen[1] == seen[2] || seen[0] == seen[2] {
+		t.Errorf("paged results = %v, want three distinct hits", seen)
+	}
+
+	expectAs(t, bob, "POST", "/api/post/"+id+"/details", map[string]string{"message": "gophers everywhere"}, http.StatusOK, nil)
+	expectAs(t, bob, "POST", "/api/thread/traits/details", map[string]string{"title": "Borrow checker"}, http.StatusOK, nil)
+	expect(t, "GET", "/api/search?q=gophers", nil, http.StatusOK, &results)
+	if len(results) != 1 || results[0].Snippet != "<b>gophers</b> everywhere" {
+		t.Errorf("edited post results = %+v", results)
+	}
+	expect(t, "GET", "/api/search?q=borrow+checker", nil, http.StatusOK, &results)
+	if len(results) != 1 || results[0].Title != "Borrow checker" {
+		t.Errorf("edited thread results = %+v", results)
+	}
+
+	expect(t, "GET", "/api/search", nil, http.StatusBadRequest, nil)
+	expect(t, "GET", "/api/search?q=x&since=yesterday", nil, http.StatusBadRequest, nil)
+}
+
+func TestSearchUsers(t *testing.T) {
+	setup(t)
+	createUser(t, "alice")
+	createUser(t, "al_bundy")
+	createUser(t, "bob")
+	createForum(t, "golang", "bob")
+	createThread(t, "golang", "al_bundy", "generics")
+
+	var users []models.User
+	expect(t, "GET", "/api/user/search?prefix=AL", nil, http.StatusOK, &users)
+	if len(users) != 2 || users[0].Nickname != "al_bundy" || users[1].Nickname != "alice" {
+		t.Errorf("users = %+v, want the active al_bundy first", users)
+	}
+	expect(t, "GET", "/api/user/search?prefix=al_&limit=5", nil, http.StatusOK, &users)
+	if len(users) != 1 {
+		t.Errorf("users = %+v, want only al_bundy", users)
+	}
+	expect(t, "GET", "/api/user/search?prefix=full+bob&fuzzy=true", nil, http.StatusOK, &users)
+	if len(users) == 0 || users[0].Nickname != "bob" {
+		t.Errorf("fuzzy users = %+v, want bob by full name", users)
+	}
+	expect(t, "GET", "/api/user/search", nil, http.StatusBadRequest, nil)
+}
+
+func TestListForums(t *testing.T) {
+	setup(t)
+	createUser(t, "alice")
+	createUser(t, "bob")
+	createForum(t, "golang", "alice")
+	createForum(t, "rust", "bob")
+	createForum(t, "c", "alice")
+	createThread(t, "rust", "bob", "traits")
+
+	slugs := func(path string) []string {
+		t.Helper()
+		var forums []models.Forum
+		expect(t, "GET", path, nil, http.StatusOK, &forums)
+		result := []string{}
+		for _, f := range forums {
+			result = append(result, f.Slug)
+		}
+		return result
+	}
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{"/api/forums", []string{"golang", "rust", "c"}},
+		{"/api/forums?sort=threads&desc=true", []string{"rust", "c", "golang"}},
+		{"/api/forums?sort=threads&desc=true&limit=1&since=rust", []string{"c"}},
+		{"/api/forums?sort=title", []string{"c", "golang", "rust"}},
+		{"/api/forums?user=Alice", []string{"golang", "c"}},
+	}
+	for _, tt := range tests {
+		if got := slugs(tt.path); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GET %s = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+	expect(t, "GET", "/api/forums?sort=votes", nil, http.StatusBadRequest, nil)
+	expect(t, "GET", "/api/forums?since=nope", nil, http.StatusNotFound, nil)
+
+	// Threads created between pages move c but do not make the next page
+	// skip rust.
+	var forums []models.Forum
+	next, _ := links(t, "/api/forums?sort=threads&limit=2", &forums)
+	if len(forums) != 2 || forums[1].Slug != "c" || next == "" {
+		t.Fatalf("first page = %+v, next %q", forums, next)
+	}
+	expect(t, "GET", strings.Replace(next, "sort=threads", "sort=posts", 1), nil, http.StatusBadRequest, nil)
+	createThread(t, "c", "alice", "pointers")
+	createThread(t, "c", "alice", "macros")
+	links(t, next, &forums)
+	if len(forums) != 2 || forums[0].Slug != "rust" || forums[1].Slug != "c" {
+		t.Errorf("next page = %+v, want rust and c", forums)
+	}
+}
+
+func TestUserActivity(t *testing.T) {
+	setup(t)
+	createUser(t, "alice")
+	createUser(t, "bob")
+	createForum(t, "golang", "bob")
+	createForum(t, "rust", "bob")
+	createThread(t, "golang", "alice", "generics")
+	createThread(t, "rust", "alice", "traits")
+	createThread(t, "rust", "bob", "borrowck")
+	first := createPost(t, "generics", "alice", 0)
+	createPost(t, "generics", "bob", 0)
+	second := createPost(t, "traits", "alice", 0)
+
+	var posts []models.Post
+	expect(t, "GET", "/api/user/Alice/posts", nil, http.StatusOK, &posts)
+	if len(posts) != 2 || posts[0].ID != first || posts[1].ID != second {
+		t.Errorf("posts = %+v, want %d and %d", posts, first, second)
+	}
+	expect(t, "GET", fmt.Sprintf("/api/user/alice/posts?desc=true&since=%d", second), nil, http.StatusOK, &posts)
+	if len(posts) != 1 || posts[0].ID != first {
+		t.Errorf("posts since %d = %+v, want %d", second, posts, first)
+	}
+	expect(t, "GET", "/api/user/alice/posts?forum=rust", nil, http.StatusOK, &posts)
+	if len(posts) != 1 || posts[0].ID != second {
+		t.Errorf("posts in rust = %+v, want %d", posts, second)
+	}
+
+	var threads []models.Thread
+	expect(t, "GET", "/api/user/alice/threads?limit=1", nil, http.StatusOK, &threads)
+	if len(threads) != 1 || threads[0].Slug != "generics" {
+		t.Errorf("threads = %+v, want generics", threads)
+	}
+	expect(t, "GET", "/api/user/alice/threads?forum=RUST&since=2021-06-01T12:00:00.000Z", nil, http.StatusOK, &threads)
+	if len(threads) != 1 || threads[0].Slug != "traits" {
+		t.Errorf("threads in rust = %+v, want traits", threads)
+	}
+	expect(t, "GET", "/api/user/alice/threads?since=never", nil, http.StatusBadRequest, nil)
+	expect(t, "GET", "/api/user/mallory/posts", nil, http.StatusNotFound, nil)
+}
+
+// links GETs a list page into out and returns the next and prev links of
+// its Link header.
+func links(t *testing.T, path string, out interface{}) (string, string) {
+	t.Helper()
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.SetRequestURI("http://forum" + path)
+	if err := client.DoTimeout(req, resp, 10*time.Second); err != nil {
+		t.Fatalf("GET %s: %v", path, err)
+	}
+	if resp.StatusCode() != http.StatusOK {
+		t.Fatalf("GET %s: status %d, want %d", path, resp.StatusCode(), http.StatusOK)
+	}
+	if err := json.Unmarshal(resp.Body(), out); err != nil {
+		t.Fatalf("GET %s: decoding %q: %v", path, resp.Body(), err)
+	}
+	var next, prev string
+	for _, link := range strings.Split(string(resp.Header.Peek("Link")), ", ") {
+		parts := strings.SplitN(link, ">; ", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		switch parts[1] {
+		case `rel="next"`:
+			next = strings.TrimPrefix(parts[0], "<")
+		case `rel="prev"`:
+			prev = strings.TrimPrefix(parts[0], "<")
+		}
+	}
+	return next, prev
+}
+
+func TestCursorPagination(t *testing.T) {
+	setup(t)
+	alice := login(t, "alice")
+	createForum(t, "golang", "alice")
+	for i := 0; i < 5; i++ {
+		slug := fmt.Sprintf("t%d", i)
+		createThread(t, "golang", "alice", slug)
+	}
+	if status := callAs(t, alice, "POST", "/api/thread/t3/details", map[string]bool{"pinned": true}, nil); status != http.StatusOK {
+		t.Fatalf("pinning a thread: status %d, want %d", status, http.StatusOK)
+	}
+	want := []string{"t3", "t0", "t1", "t2", "t4"}
+
+	// The threads share a timestamp, so only cursors page through them.
+	var pages [][]string
+	next := "/api/forum/golang/threads?limit=2"
+	var prev string
+	for next != "" && len(pages) < 10 {
+		var threads []models.Thread
+		link := next
+		next, prev = links(t, link, &threads)
+		var page []string
+		for _, th := range threads {
+			page = append(page, th.Slug)
+		}
+		pages = append(pages, page)
+	}
+	var got []string
+	for _, page := range pages {
+		got = append(got, page...)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("paged threads = %v, want %v", pages, want)
+	}
+
+	var back []string
+	for prev != "" && len(back) < 10 {
+		var threads []models.Thread
+		_, prev = links(t, prev, &threads)
+		for i := len(threads) - 1; i >= 0; i-- {
+			back = append(back, threads[i].Slug)
+		}
+	}
+	if wantBack := []string{"t2", "t1", "t0", "t3"}; !reflect.DeepEqual(back, wantBack) {
+		t.Errorf("threads paged back = %v, want %v", back, wantBack)
+	}
+
+	for i := 0; i < 4; i++ {
+		createPost(t, "t0", "alice", 0)
+	}
+	var posts []models.Post
+	next, prev = links(t, "/api/thread/t0/posts?limit=3&sort=tree&desc=true", &posts)
+	if len(posts) != 3 || next == "" || prev != "" {
+		t.Fatalf("first page: %d posts, next %q, prev %q", len(posts), next, prev)
+	}
+	if strings.Contains(next, "desc=") || !strings.Contains(next, "sort=tree") {
+		t.Errorf("next link %q should keep sort and drop desc", next)
+	}
+	last := posts[2].ID
+	next, prev = links(t, next, &posts)
+	if len(posts) != 1 || posts[0].ID >= last || next != "" || prev == "" {
+		t.Errorf("second page = %+v, next %q, prev %q", posts, next, prev)
+	}
+
+	expect(t, "GET", "/api/forum/golang/users?cursor=bogus", nil, http.StatusBadRequest, nil)
+}
+
+func TestPostReplies(t *testing.T) {
+	setup(t)
+	createUser(t, "alice")
+	createForum(t, "golang", "alice")
+	createThread(t, "golang", "alice", "generics")
+	root := createPost(t, "generics", "alice", 0)
+	a := createPost(t, "generics", "alice", root)
+	a1 := createPost(t, "generics", "alice", a)
+	b := createPost(t, "generics", "alice", root)
+
+	var replies []models.PostNode
+	next, _ := links(t, fmt.Sprintf("/api/post/%d/replies?limit=2", root), &replies)
+	if len(replies) != 2 || replies[0].ID != a || replies[1].ID != a1 || next == "" {
+		t.Fatalf("first page = %+v, next %q", replies, next)
+	}
+	if replies[0].Depth != 1 || replies[0].Children != 1 || replies[1].Depth != 2 || replies[1].Children != 0 {
+		t.Errorf("first page depths and children = %+v", replies)
+	}
+	next, prev := links(t, next, &replies)
+	if len(replies) != 1 || replies[0].ID != b || next != "" || prev == "" {
+		t.Errorf("second page = %+v, next %q, prev %q", replies, next, prev)
+	}
+	expect(t, "GET", fmt.Sprintf("/api/post/%d/replies?max_depth=1", root), nil, http.StatusOK, &replies)
+	if len(replies) != 2 || replies[0].ID != a || replies[1].ID != b {
+		t.Errorf("direct replies = %+v, want %d and %d", replies, a, b)
+	}
+
+	var ancestors []models.PostNode
+	expect(t, "GET", fmt.Sprintf("/api/post/%d/ancestors", a1), nil, http.StatusOK, &ancestors)
+	if len(ancestors) != 2 || ancestors[0].ID != root || ancestors[1].ID != a || ancestors[0].Children != 2 {
+		t.Errorf("ancestors = %+v, want %d and %d", ancestors, root, a)
+	}
+	expect(t, "GET", fmt.Sprintf("/api/post/%d/ancestors", root), nil, http.StatusOK, &ancestors)
+	if len(ancestors) != 0 {
+		t.Errorf("ancestors of a root post = %+v, want none", ancestors)
+	}
+	expect(t, "GET", "/api/post/1000000/replies", nil, http.StatusNotFound, nil)
+	expect(t, "GET", "/api/post/1000000/ancestors", nil, http.StatusNotFound, nil)
+}
+
+func TestPostRevisions(t *testing.T) {
+	setup(t)
+	alice := login(t, "alice")
+	bob := login(t, "bob")
+	createForum(t, "golang", "alice")
+	createThread(t, "golang", "alice", "generics")
+	id := fmt.Sprint(createPost(t, "generics", "bob", 0))
+
+	expectAs(t, bob, "POST", "/api/post/"+id+"/details", map[string]string{"message": "first post"}, http.StatusOK, nil)
+	if status := callAs(t, alice, "POST", "/api/post/"+id+"/details", map[string]string{"message": "first post"}, nil); status != http.StatusOK {
+		t.Fatalf("repeating the message: status %d, want %d", status, http.StatusOK)
+	}
+	if status := callAs(t, alice, "POST", "/api/post/"+id+"/details", map[string]string{"message": "edited post"}, nil); status != http.StatusOK {
+		t.Fatalf("edit: status %d, want %d", status, http.StatusOK)
+	}
+
+	var revisions []models.PostRevision
+	if status := callAs(t, alice, "GET", "/api/post/"+id+"/revisions", nil, &revisions); status != http.StatusOK {
+		t.Fatalf("revisions: status %d, want %d", status, http.StatusOK)
+	}
+	if len(revisions) != 3 || revisions[0].Message != "post" || revisions[0].Editor != "bob" ||
+		revisions[1].Editor != "bob" || revisions[2].Editor != "alice" || revisions[2].Message != "edited post" {
+		t.Errorf("revisions = %+v", revisions)
+	}
+
+	var diff models.PostDiff
+	if status := callAs(t, alice, "GET", "/api/post/"+id+"/revisions/diff?from=1", nil, &diff); status != http.StatusOK {
+		t.Fatalf("diff: status %d, want %d", status, http.StatusOK)
+	}
+	want := []models.Change{
+		{Op: "insert", Text: "edited "},
+		{Op: "equal", Text: "post"},
+	}
+	if diff.From != 1 || diff.To != 3 || !reflect.DeepEqual(diff.Changes, want) {
+		t.Errorf("diff = %+v, want 1..3 with %v", diff, want)
+	}
+	if status := callAs(t, alice, "GET", "/api/post/"+id+"/revisions/diff?to=7", nil, nil); status != http.StatusNotFound {
+		t.Errorf("diff with a missing revision: status %d, want %d", status, http.StatusNotFound)
+	}
+	if status := callAs(t, alice, "GET", "/api/post/"+id+"/revisions/diff?from=3&to=2", nil, nil); status != http.StatusBadRequest {
+		t.Errorf("diff from a later revision: status %d, want %d", status, http.StatusBadRequest)
+	}
+	expect(t, "GET", "/api/post/"+id+"/revisions", nil, http.StatusUnauthorized, nil)
+}
+
+// conditional makes a request as token with header set to tag, unless tag
+// is empty, and returns the status and the ETag of the response.
+func conditional(t *testing.T, token string, method string, path string, header string, tag string, body interface{}) (int, string) {
+	t.Helper()
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	req.Header.SetMethod(method)
+	req.SetRequestURI("http://forum" + path)
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	if tag != "" {
+		req.Header.Set(header, tag)
+	}
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.SetContentType("application/json")
+		req.SetBody(data)
+	}
+	if err := client.DoTimeout(req, resp, 10*time.Second); err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	return resp.StatusCode(), string(resp.Header.Peek("ETag"))
+}
+
+func TestETags(t *testing.T) {
+	setup(t)
+	alice := login(t, "alice")
+	createForum(t, "golang", "alice")
+	createThread(t, "golang", "alice", "generics")
+	post := fmt.Sprintf("/api/post/%d/details", createPost(t, "generics", "alice", 0))
+
+	for _, tt := range []struct {
+		path string
+		body map[string]string
+	}{
+		{"/api/thread/generics/details", map[string]string{"title": "Soon"}},
+		{post, map[string]string{"message": "edited"}},
+		{"/api/user/alice/profile", map[string]string{"about": "gopher"}},
+	} {
+		status, etag := conditional(t, alice, "GET", tt.path, "", "", nil)
+		if status != http.StatusOK || etag == "" {
+			t.Fatalf("GET %s: status %d, ETag %q", tt.path, status, etag)
+		}
+		if status, _ := conditional(t, alice, "GET", tt.path, "If-None-Match", etag, nil); status != http.StatusNotModified {
+			t.Errorf("GET %s with a fresh tag: status %d, want %d", tt.path, status, http.StatusNotModified)
+		}
+
+		status, updated := conditional(t, alice, "POST", tt.path, "If-Match", etag, tt.body)
+		if status != http.StatusOK || updated == etag {
+			t.Errorf("POST %s: status %d, ETag %q after %q", tt.path, status, updated, etag)
+		}
+		if status, _ := conditional(t, alice, "POST", tt.path, "If-Match", etag, tt.body); status != http.StatusPreconditionFailed {
+			t.Errorf("POST %s with a stale tag: status %d, want %d", tt.path, status, http.StatusPreconditionFailed)
+		}
+		if status, _ := conditional(t, alice, "GET", tt.path, "If-None-Match", etag, nil); status != http.StatusOK {
+			t.Errorf("GET %s with a stale tag: status %d, want %d", tt.path, status, http.StatusOK)
+		}
+		for _, tag := range []string{"bogus", "W/" + updated} {
+			if status, _ := conditional(t, alice, "POST", tt.path, "If-Match", tag, tt.body); status != http.StatusPreconditionFailed {
+				t.Errorf("POST %s with If-Match %s: status %d, want %d", tt.path, tag, status, http.StatusPreconditionFailed)
+			}
+		}
+	}
+
+	_, etag := conditional(t, alice, "GET", "/api/thread/generics/details", "", "", nil)
+	expectAs(t, alice, "POST", "/api/thread/generics/vote", map[string]interface{}{"nickname": "alice", "voice": 1}, http.StatusOK, nil)
+	if status, _ := conditional(t, alice, "POST", "/api/thread/generics/details", "If-Match", etag, map[string]string{"title": "Voted"}); status != http.StatusOK {
+		t.Errorf("POST with the tag read before a vote: status %d, want %d", status, http.StatusOK)
+	}
+	if status, _ := conditional(t, alice, "POST", "/api/thread/missing/details", "If-Match", `"1"`, map[string]string{"title": "Gone"}); status != http.StatusNotFound {
+		t.Errorf("POST to a missing thread with If-Match: status %d, want %d", status, http.StatusNotFound)
+	}
+	if _, etag := conditional(t, alice, "GET", post+"?related=user", "", "", nil); etag != "" {
+		t.Errorf("post details with related objects have ETag %q", etag)
+	}
+}
+
+// idempotent sends a POST with an Idempotency-Key and returns the status,
+// the body and whether the response was replayed.
+func idempotent(t *testing.T, token string, key string, path string, body interface{}) (int, string, bool) {
+	t.Helper()
+	req := fasthttp.AcquireRequest()
+	resp := fasthttp.AcquireResponse()
+	defer fasthttp.ReleaseRequest(req)
+	defer fasthttp.ReleaseResponse(resp)
+
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.SetMethod("POST")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	req.SetRequestURI("http://forum" + path)
+	req.Header.Set("Idempotency-Key", key)
+	req.Header.SetContentType("application/json")
+	req.SetBody(data)
+	if err := client.DoTimeout(req, resp, 10*time.Second); err != nil {
+		t.Fatalf("POST %s: %v", path, err)
+	}
+	return resp.StatusCode(), string(resp.Body()), string(resp.Header.Peek("Idempotent-Replayed")) == "true"
+}
+
+func TestIdempotencyKeys(t *testing.T) {
+	setup(t)
+	alice := login(t, "alice")
+	createForum(t, "golang", "alice")
+	createThread(t, "golang", "alice", "generics")
+
+	posts := []map[string]interface{}{{"author": "alice", "message": "first"}}
+	status, first, replayed := idempotent(t, alice, "retry-1", "/api/thread/generics/create", posts)
+	if status != http.StatusCreated || replayed {
+		t.Fatalf("first request: status %d, replayed %v", status, replayed)
+	}
+	status, retry, replayed := idempotent(t, alice, "retry-1", "/api/thread/generics/create", posts)
+	if status != http.StatusCreated || !replayed || retry != first {
+		t.Errorf("retry: status %d, replayed %v, body %s, want %s", status, replayed, retry, first)
+	}
+	var stored models.PostList
+	expect(t, "GET", "/api/thread/generics/posts", nil, http.StatusOK, &stored)
+	if len(stored) != 1 {
+		t.Errorf("thread has %d posts after a retry, want 1", len(stored))
+	}
+
+	other := []map[string]interface{}{{"author": "alice", "message": "second"}}
+	if status, _, _ := idempotent(t, alice, "retry-1", "/api/thread/generics/create", other); status != http.StatusUnprocessableEntity {
+		t.Errorf("same key, different body: status %d, want %d", status, http.StatusUnprocessableEntity)
+	}
+
+	user := map[string]string{"fullname": "Bob", "email": "bob@example.com"}
+	if status, _, _ := idempotent(t, alice, "user-1", "/api/user/bob/create", user); status != http.StatusCreated {
+		t.Fatalf("create user: status %d", status)
+	}
+	if status, _, replayed := idempotent(t, alice, "user-1", "/api/user/bob/create", user); status != http.StatusCreated || !replayed {
+		t.Errorf("create user again: status %d, replayed %v, want the first 201", status, replayed)
+	}
+	if status, _, _ := idempotent(t, alice, strings.Repeat("k", 256), "/api/user/carol/create", user); status != http.StatusBadRequest {
+		t.Errorf("overlong key: status %d, want %d", status, http.StatusBadRequest)
+	}
+	dave := map[string]string{"fullname": "Dave", "email": "dave@example.com", "password": "password dave"}
+	status, first, replayed = idempotent(t, "", "signup-1", "/api/user/dave/create", dave)
+	if status != http.StatusCreated || replayed {
+		t.Fatalf("anonymous signup: status %d, replayed %v", status, replayed)
+	}
+	status, retry, replayed = idempotent(t, "", "signup-1", "/api/user/dave/create", dave)
+	if status != http.StatusCreated || !replayed || retry != first {
+		t.Errorf("anonymous signup again: status %d, replayed %v, body %s, want %s", status, replayed, retry, first)
+	}
+	carol := []map[string]interface{}{{"author": "carol", "message": "first"}}
+	if status, _, replayed := idempotent(t, login(t, "carol"), "retry-1", "/api/thread/generics/create", carol); status != http.StatusCreated || replayed {
+		t.Errorf("the same key of another user: status %d, replayed %v, want a new 201", status, replayed)
+	}
+}
+
+func TestSetPassword(t *testing.T) {
+	setup(t)
+	alice := login(t, "alice")
+	createUser(t, "bob")
+	root := adminToken(t)
+
+	password := map[string]string{"password": "password bob"}
+	expect(t, "POST", "/api/user/bob/password", password, http.StatusUnauthorized, nil)
+	expectAs(t, alice, "POST", "/api/user/bob/password", password, http.StatusForbidden, nil)
+	expectAs(t, root, "POST", "/api/user/bob/password", password, http.StatusNoContent, nil)
+	expect(t, "POST", "/api/auth/login", map[string]string{"nickname": "bob", "password": "password bob"}, http.StatusOK, nil)
+
+	expectAs(t, alice, "POST", "/api/user/alice/password", map[string]string{"password": "short"}, http.StatusBadRequest, nil)
+	expectAs(t, alice, "POST", "/api/user/alice/password", map[string]string{"password": "new password"}, http.StatusNoContent, nil)
+	expect(t, "POST", "/api/auth/login", map[string]string{"nickname": "alice", "password": "password alice"}, http.StatusUnauthorized, nil)
+	expect(t, "POST", "/api/auth/login", map[string]string{"nickname": "alice", "password": "new password"}, http.StatusOK, nil)
+	expectAs(t, root, "POST", "/api/user/mallory/password", password, http.StatusNotFound, nil)
+}
@@ -0,0 +1,136 @@
+package server_test
+
+import (
+	"DBForum/internal/app/config"
+	"DBForum/internal/app/database"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ephemeralPostgres is a throwaway cluster created with initdb in a
+// temporary directory. It listens on a unix socket only, so it never
+// clashes with a Postgres already running on the machine.
+type ephemeralPostgres struct {
+	bin string
+	dir string
+}
+
+const (
+	// envTestDSN points the suite at an existing database instead of a
+	// throwaway cluster. The database is migrated and emptied by the tests.
+	envTestDSN = "DBFORUM_TEST_DSN"
+	// envTestRequired makes the suite fail instead of skipping when no
+	// Postgres can be started, so that CI can't silently skip it.
+	envTestRequired = "DBFORUM_TEST_PG"
+)
+
+// testDatabase returns the database the suite runs against and a function
+// that releases it: the one at $DBFORUM_TEST_DSN, or else a fresh
+// ephemeral cluster.
+func testDatabase() (config.Database, func(), error) {
+	if dsn := os.Getenv(envTestDSN); dsn != "" {
+		return config.Database{DSN: dsn, MaxConnections: 10}, func() {}, nil
+	}
+	pg, err := startPostgres()
+	if err != nil {
+		return config.Database{}, nil, err
+	}
+	return pg.config(), pg.stop, nil
+}
+
+// testRequired reports whether the suite must run rather than be skipped.
+func testRequired() bool {
+	required, _ := strconv.ParseBool(os.Getenv(envTestRequired))
+	return required || os.Getenv(envTestDSN) != ""
+}
+
+// connect opens a pool on conf and migrates it to the latest schema.
+func connect(conf config.Database) (*database.Postgres, error) {
+	postgres, err := database.NewPostgres(conf)
+	if err != nil {
+		return nil, err
+	}
+	migrator, err := database.NewMigrator(postgres.GetPostgres())
+	if err != nil {
+		_ = postgres.Close()
+		return nil, err
+	}
+	if _, err := migrator.Up(); err != nil {
+		_ = postgres.Close()
+		return nil, err
+	}
+	return postgres, nil
+}
+
+// findPostgresBin looks for initdb in $DBFORUM_TEST_PGBIN, $PATH and the
+// Debian/Ubuntu install location.
+func findPostgresBin() (string, error) {
+	if dir := os.Getenv("DBFORUM_TEST_PGBIN"); dir != "" {
+		return dir, nil
+	}
+	if path, err := exec.LookPath("initdb"); err == nil {
+		return filepath.Dir(path), nil
+	}
+	matches, _ := filepath.Glob("/usr/lib/postgresql/*/bin/initdb")
+	if len(matches) > 0 {
+		return filepath.Dir(matches[len(matches)-1]), nil
+	}
+	return "", fmt.Errorf("initdb not found; install Postgres or set DBFORUM_TEST_PGBIN")
+}
+
+func startPostgres() (*ephemeralPostgres, error) {
+	bin, err := findPostgresBin()
+	if err != nil {
+		return nil, err
+	}
+	if os.Geteuid() == 0 {
+		return nil, fmt.Errorf("initdb refuses to run as root")
+	}
+	dir, err := ioutil.TempDir("", "dbforum-pg-")
+	if err != nil {
+		return nil, err
+	}
+	pg := &ephemeralPostgres{bin: bin, dir: dir}
+
+	err = pg.run("initdb", "-D", pg.dataDir(), "-U", "postgres", "-A", "trust", "-E", "UTF8", "--locale=C", "--no-sync")
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+	options := fmt.Sprintf("-k %s -c listen_addresses='' -F", dir)
+	err = pg.run("pg_ctl", "-D", pg.dataDir(), "-l", filepath.Join(dir, "postgres.log"), "-o", options, "-w", "start")
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, err
+	}
+	return pg, nil
+}
+
+func (pg *ephemeralPostgres) dataDir() string {
+	return filepath.Join(pg.dir, "data")
+}
+
+func (pg *ephemeralPostgres) run(name string, args ...string) error {
+	out, err := exec.Command(filepath.Join(pg.bin, name), args...).CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("%s: %v\n%s", name, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (pg *ephemeralPostgres) config() config.Database {
+	return config.Database{
+		Host:           pg.dir,
+		MaxConnections: 10,
+	}
+}
+
+func (pg *ephemeralPostgres) stop() {
+	_ = pg.run("pg_ctl", "-D", pg.dataDir(), "-m", "immediate", "stop")
+	_ = os.RemoveAll(pg.dir)
+}
@@ -0,0 +1,142 @@
+package server
+
+import (
+	authHandlers "DBForum/internal/app/auth/handlers"
+	authRepo "DBForum/internal/app/auth/repository"
+	authUCase "DBForum/internal/app/auth/usecase"
+	"DBForum/internal/app/config"
+	forumHandlers "DBForum/internal/app/forum/handlers"
+	forumRepo "DBForum/internal/app/forum/repository"
+	forumUCase "DBForum/internal/app/forum/usecase"
+	idempotencyHandlers "DBForum/internal/app/idempotency/handlers"
+	idempotencyRepo "DBForum/internal/app/idempotency/repository"
+	idempotencyUCase "DBForum/internal/app/idempotency/usecase"
+	"DBForum/internal/app/metrics"
+	"DBForum/internal/app/middleware"
+	postHandlers "DBForum/internal/app/post/handlers"
+	postRepo "DBForum/internal/app/post/repository"
+	postUCase "DBForum/internal/app/post/usecase"
+	searchHandlers "DBForum/internal/app/search/handlers"
+	searchRepo "DBForum/internal/app/search/repository"
+	searchUCase "DBForum/internal/app/search/usecase"
+	serviceHandlers "DBForum/internal/app/service/handlers"
+	serviceRepo "DBForum/internal/app/service/repository"
+	serviceUCase "DBForum/internal/app/service/usecase"
+	threadHandlers "DBForum/internal/app/thread/handlers"
+	threadRepo "DBForum/internal/app/thread/repository"
+	threadUCase "DBForum/internal/app/thread/usecase"
+	userHandlers "DBForum/internal/app/user/handlers"
+	userRepo "DBForum/internal/app/user/repository"
+	userUCase "DBForum/internal/app/user/usecase"
+	router2 "github.com/fasthttp/router"
+	"github.com/jackc/pgx"
+	"github.com/valyala/fasthttp"
+	"time"
+)
+
+// New prepares the repositories on db and returns the API handler.
+func New(db *pgx.ConnPool, authConf config.Auth, idempotencyConf config.Idempotency) (fasthttp.RequestHandler, error) {
+	authRepository := authRepo.NewRepo(db)
+	if err := authRepository.Prepare(); err != nil {
+		return nil, err
+	}
+	forumRepository := forumRepo.NewRepo(db)
+	if err := forumRepository.Prepare(); err != nil {
+		return nil, err
+	}
+	idempotencyRepository := idempotencyRepo.NewRepo(db)
+	if err := idempotencyRepository.Prepare(); err != nil {
+		return nil, err
+	}
+	postRepository := postRepo.NewRepo(db)
+	if err := postRepository.Prepare(); err != nil {
+		return nil, err
+	}
+	searchRepository := searchRepo.NewRepo(db)
+	if err := searchRepository.Prepare(); err != nil {
+		return nil, err
+	}
+	serviceRepository := serviceRepo.NewRepo(db)
+	if err := serviceRepository.Prepare(); err != nil {
+		return nil, err
+	}
+	threadRepository := threadRepo.NewRepo(db)
+	if err := threadRepository.Prepare(); err != nil {
+		return nil, err
+	}
+	userRepository := userRepo.NewRepo(db)
+	if err := userRepository.Prepare(); err != nil {
+		return nil, err
+	}
+
+	authUseCase := authUCase.NewUseCase(authRepository, time.Duration(authConf.TokenTTL), authConf.Required)
+	forumUseCase := forumUCase.NewUseCase(forumRepository, userRepository, threadRepository)
+	idempotencyUseCase := idempotencyUCase.NewUseCase(idempotencyRepository, time.Duration(idempotencyConf.Retention))
+	postUseCase := postUCase.NewUseCase(postRepository, userRepository, threadRepository, forumRepository)
+	searchUseCase := searchUCase.NewUseCase(searchRepository)
+	serviceUseCase := serviceUCase.NewUseCase(serviceRepository)
+	threadUseCase := threadUCase.NewUseCase(threadRepository, postRepository, forumRepository)
+	userUseCase := userUCase.NewUseCase(userRepository, postRepository, threadRepository)
+
+	authHandler := authHandlers.NewHandler(*authUseCase)
+	forumHandler := forumHandlers.NewHandler(*forumUseCase)
+	idempotencyHandler := idempotencyHandlers.NewHandler(*idempotencyUseCase)
+	postHandler := postHandlers.NewHandler(*postUseCase)
+	searchHandler := searchHandlers.NewHandler(*searchUseCase)
+	serviceHandler := serviceHandlers.NewHandler(*serviceUseCase)
+	threadHandler := threadHandlers.NewHandler(*threadUseCase)
+	userHandler := userHandlers.NewHandler(*userUseCase)
+
+	serverMetrics := metrics.New(db)
+
+	r := router2.New()
+	r.SaveMatchedRoutePath = true
+
+	r.POST("/api/auth/login", authHandler.Login)
+	r.POST("/api/auth/logout", authHandler.Logout)
+	r.POST("/api/user/{nickname}/password", authHandler.SetPassword)
+
+	r.GET("/api/forums", forumHandler.List)
+	r.POST("/api/forum/create", idempotencyHandler.Wrap(forumHandler.Create))
+	r.GET("/api/forum/{slug}/details", forumHandler.Details)
+	r.POST("/api/forum/{slug}/create", idempotencyHandler.Wrap(forumHandler.CreateThread))
+	r.GET("/api/forum/{slug}/users", forumHandler.GetUsers)
+	r.GET("/api/forum/{slug}/threads", forumHandler.GetThreads)
+	r.GET("/api/forum/{slug}/roles", forumHandler.GetRoles)
+	r.POST("/api/forum/{slug}/roles/{nickname}", forumHandler.GrantRole)
+	r.DELETE("/api/forum/{slug}/roles/{nickname}", forumHandler.RevokeRole)
+
+	r.GET("/api/post/{id}/details", postHandler.GetInfo)
+	r.POST("/api/post/{id}/details", postHandler.ChangeMessage)
+	r.GET("/api/post/{id}/replies", postHandler.GetReplies)
+	r.GET("/api/post/{id}/ancestors", postHandler.GetAncestors)
+	r.GET("/api/post/{id}/revisions", postHandler.GetRevisions)
+	r.GET("/api/post/{id}/revisions/diff", postHandler.DiffRevisions)
+	r.DELETE("/api/post/{id}", postHandler.Delete)
+	r.POST("/api/admin/post/{id}/restore", postHandler.Restore)
+
+	r.GET("/api/search", searchHandler.Search)
+
+	r.POST("/api/service/clear", serviceHandler.ClearDB)
+	r.GET("/api/service/status", serviceHandler.Status)
+
+	r.POST("/api/thread/{slug_or_id}/create", idempotencyHandler.Wrap(threadHandler.CreatePost))
+	r.GET("/api/thread/{slug_or_id}/details", threadHandler.ThreadInfo)
+	r.POST("/api/thread/{slug_or_id}/details", threadHandler.ChangeThread)
+	r.GET("/api/thread/{slug_or_id}/posts", threadHandler.GetPosts)
+	r.POST("/api/thread/{slug_or_id}/vote", threadHandler.VoteThread)
+	r.DELETE("/api/thread/{slug_or_id}", threadHandler.Delete)
+	r.POST("/api/admin/thread/{slug_or_id}/archive", threadHandler.Archive)
+	r.DELETE("/api/admin/thread/{slug_or_id}/archive", threadHandler.Unarchive)
+
+	r.GET("/api/user/search", userHandler.Search)
+	r.POST("/api/user/{nickname}/create", idempotencyHandler.Wrap(userHandler.CreateUser))
+	r.GET("/api/user/{nickname}/profile", userHandler.GetUserInfo)
+	r.POST("/api/user/{nickname}/profile", userHandler.ChangeUser)
+	r.GET("/api/user/{nickname}/posts", userHandler.GetPosts)
+	r.GET("/api/user/{nickname}/threads", userHandler.GetThreads)
+
+	r.GET("/metrics", serverMetrics.Handler())
+
+	return middleware.Chain(r.Handler, middleware.RequestID, middleware.AccessLog, serverMetrics.Middleware, authHandler.Middleware), nil
+}
@@ -4,7 +4,6 @@ import (
 	"DBForum/internal/app/httputils"
 	serviceUseCase "DBForum/internal/app/service/usecase"
 	"github.com/valyala/fasthttp"
-	"log"
 	"net/http"
 )
 
@@ -21,8 +20,7 @@ func NewHandler(useCase serviceUseCase.UseCase) *Handlers {
 func (h *Handlers) ClearDB(ctx *fasthttp.RequestCtx) {
 	err := h.useCase.ClearDB()
 	if err != nil {
-		httputils.Respond(ctx, http.StatusInternalServerError, nil)
-		log.Println(err)
+		httputils.RespondError(ctx, err)
 		return
 	}
 	httputils.Respond(ctx, http.StatusOK, nil)
@@ -31,8 +29,7 @@ func (h *Handlers) ClearDB(ctx *fasthttp.RequestCtx) {
 func (h *Handlers) Status(ctx *fasthttp.RequestCtx) {
 	numRec, err := h.useCase.Status()
 	if err != nil {
-		httputils.Respond(ctx, http.StatusInternalServerError, nil)
-		log.Println(err)
+		httputils.RespondError(ctx, err)
 		return
 	}
 	httputils.Respond(ctx, http.StatusOK, numRec)
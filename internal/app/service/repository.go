@@ -0,0 +1,8 @@
+package service
+
+import "DBForum/internal/app/models"
+
+type Repository interface {
+	ClearDB() error
+	Status() (models.NumRecords, error)
+}
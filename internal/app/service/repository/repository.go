@@ -2,9 +2,12 @@ package repository
 
 import (
 	"DBForum/internal/app/models"
+	"DBForum/internal/app/service"
 	"github.com/jackc/pgx"
 )
 
+var _ service.Repository = (*Repository)(nil)
+
 type Repository struct {
 	db *pgx.ConnPool
 }
@@ -27,6 +30,7 @@ func (r *Repository) ClearDB() error {
 	_, err = tx.Exec("truncVotes")
 	_, err = tx.Exec("truncForum")
 	_, err = tx.Exec("truncUsers")
+	_, err = tx.Exec("truncIdempotencyKeys")
 
 	if err != nil {
 		_ = tx.Rollback()
@@ -89,7 +93,12 @@ func (r *Repository) Prepare() error {
 		return err
 	}
 
-	_, err = r.db.Prepare("countPost", "SELECT COUNT(*) as post_count FROM dbforum.post")
+	_, err = r.db.Prepare("truncIdempotencyKeys", `TRUNCATE dbforum.idempotency_key`)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Prepare("countPost", "SELECT COUNT(*) as post_count FROM dbforum.post WHERE NOT is_deleted")
 	if err != nil {
 		return err
 	}
@@ -2,14 +2,14 @@ package usecase
 
 import (
 	"DBForum/internal/app/models"
-	serviceRepo "DBForum/internal/app/service/repository"
+	"DBForum/internal/app/service"
 )
 
 type UseCase struct {
-	repo serviceRepo.Repository
+	repo service.Repository
 }
 
-func NewUseCase(repo serviceRepo.Repository) *UseCase {
+func NewUseCase(repo service.Repository) *UseCase {
 	return &UseCase{
 		repo: repo,
 	}
@@ -1,16 +1,14 @@
 package handlers
 
 import (
-	customErr "DBForum/internal/app/errors"
+	"DBForum/internal/app/auth"
 	"DBForum/internal/app/httputils"
 	"DBForum/internal/app/models"
 	threadUseCase "DBForum/internal/app/thread/usecase"
+	"DBForum/internal/app/validation"
 	"github.com/mailru/easyjson"
-	"github.com/pkg/errors"
 	"github.com/valyala/fasthttp"
-	"log"
 	"net/http"
-	"strconv"
 )
 
 type Handlers struct {
@@ -25,44 +23,25 @@ func NewHandler(useCase threadUseCase.UseCase) *Handlers {
 
 func (h *Handlers) CreatePost(ctx *fasthttp.RequestCtx) {
 	var posts models.PostList
-	if err := easyjson.Unmarshal(ctx.PostBody(), &posts); err != nil {
-		httputils.Respond(ctx, http.StatusInternalServerError, nil)
-		log.Println(err)
-		return
-	}
-
-	idOrSlug := ctx.UserValue("slug_or_id").(string)
-	posts, err := h.useCase.CreatePosts(idOrSlug, posts)
-	if errors.Is(err, customErr.ErrThreadNotFound) {
-		var message string
-		if _, err := strconv.ParseUint(idOrSlug, 10, 64); err != nil {
-			message = "Can't find post thread by slug: " + idOrSlug
-		} else {
-			message = "Can't find post thread by id: " + idOrSlug
-		}
-		resp := map[string]string{
-			"message": message,
-		}
-		httputils.RespondErr(ctx, http.StatusNotFound, resp)
+	if err := validation.PostCreate.Array(ctx.PostBody()); err != nil {
+		httputils.RespondError(ctx, err)
 		return
 	}
-	if errors.Is(err, customErr.ErrUserNotFound) {
-		resp := map[string]string{
-			"message": "Can't find post author by nickname: ",
-		}
-		httputils.RespondErr(ctx, http.StatusNotFound, resp)
+	if err := easyjson.Unmarshal(ctx.PostBody(), &posts); err != nil {
+		httputils.RespondError(ctx, err)
 		return
 	}
-	if errors.Is(err, customErr.ErrNoParent) {
-		resp := map[string]string{
-			"message": "Parent post was created in another thread",
+	for _, post := range posts {
+		if err := auth.Authorize(ctx, post.Author); err != nil {
+			httputils.RespondError(ctx, err)
+			return
 		}
-		httputils.RespondErr(ctx, http.StatusConflict, resp)
-		return
 	}
+
+	idOrSlug := ctx.UserValue("slug_or_id").(string)
+	posts, err := h.useCase.CreatePosts(idOrSlug, posts)
 	if err != nil {
-		httputils.Respond(ctx, http.StatusInternalServerError, nil)
-		log.Println(err)
+		httputils.RespondError(ctx, err)
 		return
 	}
 	httputils.Respond(ctx, http.StatusCreated, posts)
@@ -71,44 +50,49 @@ func (h *Handlers) CreatePost(ctx *fasthttp.RequestCtx) {
 func (h *Handlers) ThreadInfo(ctx *fasthttp.RequestCtx) {
 	idOrSlug := ctx.UserValue("slug_or_id").(string)
 	thread, err := h.useCase.ThreadInfo(idOrSlug)
-	if errors.Is(err, customErr.ErrForumNotFound) {
-		resp := map[string]string{
-			"message": "Can't find thread by slug or id: " + idOrSlug,
-		}
-		httputils.RespondErr(ctx, http.StatusNotFound, resp)
+	if err != nil {
+		httputils.RespondError(ctx, err)
 		return
 	}
-	if err != nil {
-		httputils.Respond(ctx, http.StatusInternalServerError, nil)
-		log.Println(err)
+	if httputils.NotModified(ctx, thread.Version) {
 		return
 	}
+	httputils.SetETag(ctx, thread.Version)
 	httputils.Respond(ctx, http.StatusOK, thread)
 }
 
 func (h *Handlers) ChangeThread(ctx *fasthttp.RequestCtx) {
 	var thread models.Thread
+	var status models.ThreadStatus
+	actor, err := auth.Caller(ctx)
+	if err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
+	if err := validation.ThreadUpdate.Object(ctx.PostBody()); err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
 	if err := easyjson.Unmarshal(ctx.PostBody(), &thread); err != nil {
-		httputils.Respond(ctx, http.StatusInternalServerError, nil)
-		log.Println(err)
+		httputils.RespondError(ctx, err)
 		return
 	}
-
-	idOrSlug := ctx.UserValue("slug_or_id").(string)
-	thread, err := h.useCase.ChangeThread(idOrSlug, thread)
-
-	if errors.Is(err, customErr.ErrThreadNotFound) {
-		resp := map[string]string{
-			"message": "Can't find thread by slug or id: " + idOrSlug,
-		}
-		httputils.RespondErr(ctx, http.StatusNotFound, resp)
+	if err := easyjson.Unmarshal(ctx.PostBody(), &status); err != nil {
+		httputils.RespondError(ctx, err)
 		return
 	}
+	if thread.Version, err = httputils.IfMatch(ctx); err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
+
+	idOrSlug := ctx.UserValue("slug_or_id").(string)
+	thread, err = h.useCase.ChangeThread(actor, idOrSlug, thread, status)
 	if err != nil {
-		httputils.Respond(ctx, http.StatusInternalServerError, nil)
-		log.Println(err)
+		httputils.RespondError(ctx, err)
 		return
 	}
+	httputils.SetETag(ctx, thread.Version)
 	httputils.Respond(ctx, http.StatusOK, thread)
 }
 
@@ -126,55 +110,70 @@ func (h *Handlers) GetPosts(ctx *fasthttp.RequestCtx) {
 
 	desc := ctx.QueryArgs().GetBool("desc")
 
-	var posts models.PostList
-	var err error
-	posts, err = h.useCase.GetPosts(idOrSlug, limit, since, sort, desc)
+	cursor := string(ctx.QueryArgs().Peek("cursor"))
 
-	if errors.Is(err, customErr.ErrThreadNotFound) {
-		resp := map[string]string{
-			"message": "Can't find thread by slug or id: " + idOrSlug,
-		}
-		httputils.RespondErr(ctx, http.StatusNotFound, resp)
-		return
-	}
+	var posts models.PostList
+	posts, cursors, err := h.useCase.GetPosts(idOrSlug, limit, since, cursor, sort, desc)
 	if err != nil {
-		httputils.Respond(ctx, http.StatusInternalServerError, nil)
-		log.Println(err)
+		httputils.RespondError(ctx, err)
 		return
 	}
+	httputils.SetLinks(ctx, cursors)
 	httputils.Respond(ctx, http.StatusOK, posts)
 }
 
 func (h *Handlers) VoteThread(ctx *fasthttp.RequestCtx) {
 	var vote models.Vote
+	if err := validation.Vote.Object(ctx.PostBody()); err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
 	if err := easyjson.Unmarshal(ctx.PostBody(), &vote); err != nil {
-		httputils.Respond(ctx, http.StatusInternalServerError, nil)
-		log.Println(err)
+		httputils.RespondError(ctx, err)
+		return
+	}
+	if err := auth.Authorize(ctx, vote.Nickname); err != nil {
+		httputils.RespondError(ctx, err)
 		return
 	}
 
 	idOrSlug := ctx.UserValue("slug_or_id").(string)
-	nickname := vote.Nickname
-
 	thread, err := h.useCase.VoteThread(idOrSlug, vote)
+	if err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
+	httputils.Respond(ctx, http.StatusOK, thread)
+}
 
-	if errors.Is(err, customErr.ErrThreadNotFound) {
-		resp := map[string]string{
-			"message": "Can't find thread by slug or id: " + idOrSlug,
-		}
-		httputils.RespondErr(ctx, http.StatusNotFound, resp)
+func (h *Handlers) Delete(ctx *fasthttp.RequestCtx) {
+	actor, err := auth.Caller(ctx)
+	if err != nil {
+		httputils.RespondError(ctx, err)
 		return
 	}
-	if errors.Is(err, customErr.ErrUserNotFound) {
-		resp := map[string]string{
-			"message": "Can't find user by nickname: " + nickname,
-		}
-		httputils.RespondErr(ctx, http.StatusNotFound, resp)
+	idOrSlug := ctx.UserValue("slug_or_id").(string)
+	thread, err := h.useCase.DeleteThread(actor, idOrSlug)
+	if err != nil {
+		httputils.RespondError(ctx, err)
 		return
 	}
+	httputils.Respond(ctx, http.StatusOK, thread)
+}
+
+func (h *Handlers) Archive(ctx *fasthttp.RequestCtx) {
+	h.setArchived(ctx, true)
+}
+
+func (h *Handlers) Unarchive(ctx *fasthttp.RequestCtx) {
+	h.setArchived(ctx, false)
+}
+
+func (h *Handlers) setArchived(ctx *fasthttp.RequestCtx, archived bool) {
+	idOrSlug := ctx.UserValue("slug_or_id").(string)
+	thread, err := h.useCase.SetArchived(auth.Actor(ctx), idOrSlug, archived)
 	if err != nil {
-		httputils.Respond(ctx, http.StatusInternalServerError, nil)
-		log.Println(err)
+		httputils.RespondError(ctx, err)
 		return
 	}
 	httputils.Respond(ctx, http.StatusOK, thread)
@@ -0,0 +1,16 @@
+package thread
+
+import "DBForum/internal/app/models"
+
+type Repository interface {
+	CreateThread(thread *models.Thread) (*models.Thread, error)
+	FindThreadBySlug(threadSlug string) (*models.Thread, error)
+	FindThreadByID(id uint64) (*models.Thread, error)
+	GetForumThreads(forumSlug string, limit int, since string, from *models.Position, desc bool) ([]models.Thread, error)
+	GetUserThreads(nickname string, forumSlug string, limit int, since string, from *models.Position, desc bool) ([]models.Thread, error)
+	UpdateThreadBySlug(threadSlug string, thread models.Thread, status models.ThreadStatus) (models.Thread, error)
+	UpdateThreadByID(threadID uint64, thread models.Thread, status models.ThreadStatus) (models.Thread, error)
+	VoteThreadByID(idOrSlug string, vote models.Vote) (models.Thread, error)
+	DeleteThread(idOrSlug string) (models.Thread, error)
+	SetThreadArchived(idOrSlug string, archived bool) (models.Thread, error)
+}
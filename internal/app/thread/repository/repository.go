@@ -3,18 +3,24 @@ package repository
 import (
 	customErr "DBForum/internal/app/errors"
 	"DBForum/internal/app/models"
+	"DBForum/internal/app/pagination"
+	"DBForum/internal/app/thread"
 	"github.com/jackc/pgx"
 	"strconv"
+	"strings"
 )
 
 const (
+	threadColumns = "id, forum_slug, author_nickname, title, message, votes, COALESCE(slug, '') AS slug, created, is_archived, is_locked, is_pinned, is_closed, version"
+
 	insertThread = `INSERT INTO dbforum.thread(
 							   forum_slug, 
 							   author_nickname, 
 							   title, 
 							   message, 
 							   slug, 
-							   created
+							   created,
+							   search
                            ) 
                            VALUES (
                                    $1, 
@@ -22,23 +28,42 @@ const (
                                    $3, 
                                    $4, 
                                    NULLIF($5,''), 
-                                   $6) RETURNING ID`
+                                   $6,
+                                   setweight(to_tsvector('simple', $3), 'A') || setweight(to_tsvector('simple', $4), 'B')) RETURNING ID, version`
+
+	selectThreadBySlug = "SELECT " + threadColumns + " FROM dbforum.thread WHERE slug = $1"
+
+	// Pinned threads head every forum list, so since only filters the rest.
+	selectThreadsByForumSlugSinceDesc = "SELECT " + threadColumns + " FROM dbforum.thread WHERE forum_slug = $1 AND (is_pinned OR created <= $2) ORDER BY is_pinned DESC, created DESC, id DESC LIMIT $3"
+
+	selectThreadsByForumSlugSince = "SELECT " + threadColumns + " FROM dbforum.thread WHERE forum_slug = $1 AND (is_pinned OR created >= $2) ORDER BY is_pinned DESC, created, id LIMIT $3"
+
+	selectThreadsByForumSlugDesc = "SELECT " + threadColumns + " FROM dbforum.thread WHERE forum_slug = $1 ORDER BY is_pinned DESC, created DESC, id DESC LIMIT $2"
+
+	selectThreadsByForumSlug = "SELECT " + threadColumns + " FROM dbforum.thread WHERE forum_slug = $1 ORDER BY is_pinned DESC, created, id LIMIT $2"
 
-	selectThreadBySlug = "SELECT id, forum_slug, author_nickname, title, message, votes,  COALESCE(slug, '') as slug, created FROM dbforum.thread WHERE slug = $1"
+	selectThreadsByAuthor = "SELECT " + threadColumns + " FROM dbforum.thread WHERE author_nickname = $1 AND ($2 = '' OR forum_slug = $2::citext) AND ($3 = '' OR created >= $3::timestamptz) ORDER BY created, id LIMIT $4"
 
-	selectThreadsByForumSlugSinceDesc = "SELECT id, forum_slug, author_nickname, title, message, votes, COALESCE(slug, '') as slug, created FROM dbforum.thread WHERE forum_slug = $1 AND created <= $2 ORDER BY created DESC LIMIT $3"
+	selectThreadsByAuthorDesc = "SELECT " + threadColumns + " FROM dbforum.thread WHERE author_nickname = $1 AND ($2 = '' OR forum_slug = $2::citext) AND ($3 = '' OR created <= $3::timestamptz) ORDER BY created DESC, id DESC LIMIT $4"
 
-	selectThreadsByForumSlugSince = "SELECT id, forum_slug, author_nickname, title, message, votes, COALESCE(slug, '') as slug, created FROM dbforum.thread WHERE forum_slug = $1 AND created >= $2 ORDER BY created LIMIT $3"
+	forumThreadsWhere = "forum_slug = $1"
 
-	selectThreadsByForumSlugDesc = "SELECT id, forum_slug, author_nickname, title, message, votes, COALESCE(slug, '') as slug, created FROM dbforum.thread WHERE forum_slug = $1 ORDER BY created DESC LIMIT $2"
+	userThreadsWhere = "author_nickname = $1 AND ($2 = '' OR forum_slug = $2::citext)"
 
-	selectThreadsByForumSlug = "SELECT id, forum_slug, author_nickname, title, message, votes, COALESCE(slug, '') as slug, created FROM dbforum.thread WHERE forum_slug = $1 ORDER BY created LIMIT $2"
+	selectThreadByID = "SELECT " + threadColumns + " FROM dbforum.thread WHERE id = $1"
 
-	selectThreadByID = "SELECT id, forum_slug, author_nickname, title, message, votes, COALESCE(slug,'') as slug, created from dbforum.thread WHERE id = $1"
+	threadUpdates = `title=COALESCE(NULLIF($1, ''), title), message=COALESCE(NULLIF($2, ''), message),
+						search=setweight(to_tsvector('simple', COALESCE(NULLIF($1, ''), title)), 'A') ||
+							setweight(to_tsvector('simple', COALESCE(NULLIF($2, ''), message)), 'B'),
+						is_locked=COALESCE($4, is_locked), is_pinned=COALESCE($5, is_pinned), is_closed=COALESCE($6, is_closed)`
 
-	updateThreadBySlug = "UPDATE dbforum.thread SET title=COALESCE(NULLIF($1, ''), title), message=COALESCE(NULLIF($2, ''), message) WHERE slug=$3 RETURNING *"
+	updateThreadBySlug = "UPDATE dbforum.thread SET " + threadUpdates + " WHERE slug=$3 AND ($7::bigint = 0 OR version = $7) RETURNING " + threadColumns
 
-	updateThreadByID = "UPDATE dbforum.thread SET title=COALESCE(NULLIF($1, ''), title), message=COALESCE(NULLIF($2, ''), message) WHERE id=$3 RETURNING *"
+	selectThreadVersionBySlug = "SELECT version FROM dbforum.thread WHERE slug = $1"
+
+	selectThreadVersionByID = "SELECT version FROM dbforum.thread WHERE id = $1"
+
+	updateThreadByID = "UPDATE dbforum.thread SET " + threadUpdates + " WHERE id=$3 AND ($7::bigint = 0 OR version = $7) RETURNING " + threadColumns
 
 	selectVoteInfo = "SELECT nickname, voice FROM dbforum.votes WHERE thread_id = $1 AND nickname = $2"
 
@@ -53,8 +78,33 @@ const (
 	selectSlugBySlug = "SELECT slug  as slug FROM dbforum.forum WHERE slug = $1"
 
 	selectNicknameByNickname = "SELECT nickname FROM dbforum.users WHERE nickname = $1"
+
+	selectThreadForUpdateBySlug = "SELECT " + threadColumns + " FROM dbforum.thread WHERE slug = $1 FOR UPDATE"
+
+	selectThreadForUpdateByID = "SELECT " + threadColumns + " FROM dbforum.thread WHERE id = $1 FOR UPDATE"
+
+	deleteThreadVotes = "DELETE FROM dbforum.votes WHERE thread_id = $1"
+
+	deleteThreadPosts = `WITH deleted AS (DELETE FROM dbforum.post WHERE thread_id = $1 RETURNING author_nickname, is_deleted)
+						SELECT count(*) FILTER (WHERE NOT is_deleted), COALESCE(array_agg(DISTINCT author_nickname::text), '{}')
+						FROM deleted`
+
+	deleteThread = "DELETE FROM dbforum.thread WHERE id = $1"
+
+	updateForumCounters = "UPDATE dbforum.forum SET threads = threads - 1, posts = posts - $2 WHERE slug = $1"
+
+	pruneForumUsers = `DELETE FROM dbforum.forum_users fu
+						WHERE fu.forum_slug = $1 AND fu.nickname = ANY($2::text[]::citext[])
+						AND NOT EXISTS (SELECT 1 FROM dbforum.thread t WHERE t.forum_slug = fu.forum_slug AND t.author_nickname = fu.nickname)
+						AND NOT EXISTS (SELECT 1 FROM dbforum.post p WHERE p.forum_slug = fu.forum_slug AND p.author_nickname = fu.nickname)`
+
+	setThreadArchivedBySlug = "UPDATE dbforum.thread SET is_archived = $2 WHERE slug = $1 RETURNING " + threadColumns
+
+	setThreadArchivedByID = "UPDATE dbforum.thread SET is_archived = $2 WHERE id = $1 RETURNING " + threadColumns
 )
 
+var _ thread.Repository = (*Repository)(nil)
+
 type Repository struct {
 	db *pgx.ConnPool
 }
@@ -85,7 +135,12 @@ func (r *Repository) CreateThread(thread *models.Thread) (*models.Thread, error)
 			&thread.Message,
 			&thread.Votes,
 			&thread.Slug,
-			&thread.Created)
+			&thread.Created,
+			&thread.Archived,
+			&thread.Locked,
+			&thread.Pinned,
+			&thread.Closed,
+			&thread.Version)
 		if err != nil {
 			_ = tx.Rollback()
 			return nil, err
@@ -104,7 +159,7 @@ func (r *Repository) CreateThread(thread *models.Thread) (*models.Thread, error)
 	}
 	if !rows.Next() {
 		_ = tx.Rollback()
-		return nil, customErr.ErrForumNotFound
+		return nil, customErr.ForumNotFound(thread.Forum)
 	}
 	err = rows.Scan(&slug)
 	if err != nil {
@@ -122,7 +177,7 @@ func (r *Repository) CreateThread(thread *models.Thread) (*models.Thread, error)
 	}
 	if !rows.Next() {
 		_ = tx.Rollback()
-		return nil, customErr.ErrUserNotFound
+		return nil, customErr.UserNotFound(thread.Author)
 	}
 	err = rows.Scan(&nickname)
 	if err != nil {
@@ -140,7 +195,7 @@ func (r *Repository) CreateThread(thread *models.Thread) (*models.Thread, error)
 		thread.Title,
 		thread.Message,
 		thread.Slug,
-		thread.Created).Scan(&thread.ID)
+		thread.Created).Scan(&thread.ID, &thread.Version)
 
 	if driverErr, ok := err.(pgx.PgError); ok {
 		if driverErr.Code == "23505" {
@@ -166,7 +221,7 @@ func (r *Repository) FindThreadBySlug(threadSlug string) (*models.Thread, error)
 		return nil, err
 	}
 	if !rows.Next() {
-		return nil, customErr.ErrForumNotFound
+		return nil, customErr.ThreadNotFound(threadSlug)
 	}
 	err = rows.Scan(
 		&thread.ID,
@@ -176,7 +231,12 @@ func (r *Repository) FindThreadBySlug(threadSlug string) (*models.Thread, error)
 		&thread.Message,
 		&thread.Votes,
 		&thread.Slug,
-		&thread.Created)
+		&thread.Created,
+		&thread.Archived,
+		&thread.Locked,
+		&thread.Pinned,
+		&thread.Closed,
+		&thread.Version)
 	if err != nil {
 		return nil, err
 	}
@@ -191,7 +251,7 @@ func (r *Repository) FindThreadByID(id uint64) (*models.Thread, error) {
 		return nil, err
 	}
 	if !rows.Next() {
-		return nil, customErr.ErrForumNotFound
+		return nil, customErr.ThreadNotFound(strconv.FormatUint(id, 10))
 	}
 	err = rows.Scan(
 		&thread.ID,
@@ -201,7 +261,12 @@ func (r *Repository) FindThreadByID(id uint64) (*models.Thread, error) {
 		&thread.Message,
 		&thread.Votes,
 		&thread.Slug,
-		&thread.Created)
+		&thread.Created,
+		&thread.Archived,
+		&thread.Locked,
+		&thread.Pinned,
+		&thread.Closed,
+		&thread.Version)
 	if err != nil {
 		return nil, err
 	}
@@ -209,7 +274,7 @@ func (r *Repository) FindThreadByID(id uint64) (*models.Thread, error) {
 	return &thread, nil
 }
 
-func (r *Repository) GetForumThreads(forumSlug string, limit int, since string, desc bool) ([]models.Thread, error) {
+func (r *Repository) GetForumThreads(forumSlug string, limit int, since string, from *models.Position, desc bool) ([]models.Thread, error) {
 	tx, err := r.db.Begin()
 	if err != nil {
 		return nil, err
@@ -222,10 +287,19 @@ func (r *Repository) GetForumThreads(forumSlug string, limit int, since string,
 	}
 	if !row.Next() {
 		_ = tx.Rollback()
-		return nil, customErr.ErrForumNotFound
+		return nil, customErr.ForumNotFound(forumSlug)
 	}
 	row.Close()
-	if since == "" {
+	if from != nil {
+		columns := []string{"NOT is_pinned", "created", "id"}
+		position := "NOT $2::boolean, $3::timestamptz, $4::bigint"
+		if desc {
+			columns[0] = "is_pinned"
+			position = "$2::boolean, $3::timestamptz, $4::bigint"
+		}
+		query := threadsFrom(forumThreadsWhere, columns, position, desc, from.Before)
+		row, err = tx.Query(query, forumSlug, from.Pinned, from.Key, from.ID, limit)
+	} else if since == "" {
 		if desc {
 			row, err = tx.Query("selectThreadsByForumSlugDesc", forumSlug, limit)
 		} else {
@@ -252,7 +326,12 @@ func (r *Repository) GetForumThreads(forumSlug string, limit int, since string,
 			&th.Message,
 			&th.Votes,
 			&th.Slug,
-			&th.Created)
+			&th.Created,
+			&th.Archived,
+			&th.Locked,
+			&th.Pinned,
+			&th.Closed,
+			&th.Version)
 		if err != nil {
 			_ = tx.Rollback()
 			return nil, err
@@ -268,12 +347,75 @@ func (r *Repository) GetForumThreads(forumSlug string, limit int, since string,
 	return threads, nil
 }
 
-func (r *Repository) UpdateThreadBySlug(threadSlug string, thread models.Thread) (models.Thread, error) {
+func (r *Repository) GetUserThreads(nickname string, forumSlug string, limit int, since string, from *models.Position, desc bool) ([]models.Thread, error) {
+	var rows *pgx.Rows
+	var err error
+	switch {
+	case from != nil:
+		columns := []string{"created", "id"}
+		query := threadsFrom(userThreadsWhere, columns, "$3::timestamptz, $4::bigint", desc, from.Before)
+		rows, err = r.db.Query(query, nickname, forumSlug, from.Key, from.ID, limit)
+	case desc:
+		rows, err = r.db.Query("selectThreadsByAuthorDesc", nickname, forumSlug, since, limit)
+	default:
+		rows, err = r.db.Query("selectThreadsByAuthor", nickname, forumSlug, since, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	return scanThreads(rows)
+}
+
+// threadsFrom builds the query for a page of the threads matching where,
+// read from the cursor position that columns are compared with. The limit
+// is always the fifth parameter.
+func threadsFrom(where string, columns []string, position string, desc bool, before bool) string {
+	op, dir := pagination.Walk(desc, before)
+	query := "SELECT " + threadColumns + " FROM dbforum.thread WHERE " + where +
+		" AND (" + strings.Join(columns, ", ") + ") " + op + " (" + position + ")" +
+		" ORDER BY " + pagination.OrderBy(dir, columns...) + " LIMIT $5"
+	if before {
+		_, dir = pagination.Walk(desc, false)
+		query = pagination.Reorder(query, pagination.OrderBy(dir, columns...))
+	}
+	return query
+}
+
+func scanThreads(rows *pgx.Rows) ([]models.Thread, error) {
+	threads := make([]models.Thread, 0)
+	for rows.Next() {
+		th := models.Thread{}
+		err := rows.Scan(
+			&th.ID,
+			&th.Forum,
+			&th.Author,
+			&th.Title,
+			&th.Message,
+			&th.Votes,
+			&th.Slug,
+			&th.Created,
+			&th.Archived,
+			&th.Locked,
+			&th.Pinned,
+			&th.Closed,
+			&th.Version)
+		if err != nil {
+			return nil, err
+		}
+		threads = append(threads, th)
+	}
+	return threads, rows.Err()
+}
+
+func (r *Repository) UpdateThreadBySlug(threadSlug string, thread models.Thread, status models.ThreadStatus) (models.Thread, error) {
 	tx, err := r.db.Begin()
 	if err != nil {
 		return models.Thread{}, err
 	}
-	err = tx.QueryRow("updateThreadBySlug", &thread.Title, &thread.Message, &threadSlug).Scan(
+	expected := thread.Version
+	err = tx.QueryRow("updateThreadBySlug", &thread.Title, &thread.Message, &threadSlug,
+		status.Locked, status.Pinned, status.Closed, expected).Scan(
 		&thread.ID,
 		&thread.Forum,
 		&thread.Author,
@@ -281,10 +423,19 @@ func (r *Repository) UpdateThreadBySlug(threadSlug string, thread models.Thread)
 		&thread.Message,
 		&thread.Votes,
 		&thread.Slug,
-		&thread.Created)
+		&thread.Created,
+		&thread.Archived,
+		&thread.Locked,
+		&thread.Pinned,
+		&thread.Closed,
+		&thread.Version)
+	if err == pgx.ErrNoRows {
+		_ = tx.Rollback()
+		return models.Thread{}, r.staleOrMissing("selectThreadVersionBySlug", threadSlug, expected, customErr.ThreadNotFound(threadSlug))
+	}
 	if err != nil {
 		_ = tx.Rollback()
-		return models.Thread{}, customErr.ErrThreadNotFound
+		return models.Thread{}, err
 	}
 	if err := tx.Commit(); err != nil {
 		_ = tx.Rollback()
@@ -293,12 +444,14 @@ func (r *Repository) UpdateThreadBySlug(threadSlug string, thread models.Thread)
 	return thread, nil
 }
 
-func (r *Repository) UpdateThreadByID(threadID uint64, thread models.Thread) (models.Thread, error) {
+func (r *Repository) UpdateThreadByID(threadID uint64, thread models.Thread, status models.ThreadStatus) (models.Thread, error) {
 	tx, err := r.db.Begin()
 	if err != nil {
 		return models.Thread{}, err
 	}
-	err = tx.QueryRow("updateThreadByID", &thread.Title, &thread.Message, &threadID).Scan(
+	expected := thread.Version
+	err = tx.QueryRow("updateThreadByID", &thread.Title, &thread.Message, &threadID,
+		status.Locked, status.Pinned, status.Closed, expected).Scan(
 		&thread.ID,
 		&thread.Forum,
 		&thread.Author,
@@ -306,10 +459,19 @@ func (r *Repository) UpdateThreadByID(threadID uint64, thread models.Thread) (mo
 		&thread.Message,
 		&thread.Votes,
 		&thread.Slug,
-		&thread.Created)
+		&thread.Created,
+		&thread.Archived,
+		&thread.Locked,
+		&thread.Pinned,
+		&thread.Closed,
+		&thread.Version)
+	if err == pgx.ErrNoRows {
+		_ = tx.Rollback()
+		return models.Thread{}, r.staleOrMissing("selectThreadVersionByID", threadID, expected, customErr.ThreadNotFound(strconv.FormatUint(threadID, 10)))
+	}
 	if err != nil {
 		_ = tx.Rollback()
-		return models.Thread{}, customErr.ErrThreadNotFound
+		return models.Thread{}, err
 	}
 	if err := tx.Commit(); err != nil {
 		_ = tx.Rollback()
@@ -318,6 +480,23 @@ func (r *Repository) UpdateThreadByID(threadID uint64, thread models.Thread) (mo
 	return thread, nil
 }
 
+// staleOrMissing explains an update against version expected that matched
+// no row: the thread is missing, or has moved past that version.
+func (r *Repository) staleOrMissing(query string, arg interface{}, expected uint64, missing error) error {
+	if expected == 0 {
+		return missing
+	}
+	var version uint64
+	err := r.db.QueryRow(query, arg).Scan(&version)
+	if err == pgx.ErrNoRows {
+		return missing
+	}
+	if err != nil {
+		return err
+	}
+	return customErr.Stale(expected)
+}
+
 func (r *Repository) VoteThreadByID(idOrSlug string, vote models.Vote) (models.Thread, error) {
 	var thread models.Thread
 	tx, err := r.db.Begin()
@@ -337,7 +516,7 @@ func (r *Repository) VoteThreadByID(idOrSlug string, vote models.Vote) (models.T
 	}
 	if !rows.Next() {
 		_ = tx.Rollback()
-		return models.Thread{}, customErr.ErrThreadNotFound
+		return models.Thread{}, customErr.ThreadNotFound(idOrSlug)
 	}
 	err = rows.Scan(
 		&thread.ID,
@@ -347,12 +526,25 @@ func (r *Repository) VoteThreadByID(idOrSlug string, vote models.Vote) (models.T
 		&thread.Message,
 		&thread.Votes,
 		&thread.Slug,
-		&thread.Created)
+		&thread.Created,
+		&thread.Archived,
+		&thread.Locked,
+		&thread.Pinned,
+		&thread.Closed,
+		&thread.Version)
 	rows.Close()
 	if err != nil {
 		_ = tx.Rollback()
 		return models.Thread{}, err
 	}
+	if thread.Archived {
+		_ = tx.Rollback()
+		return models.Thread{}, customErr.ThreadArchived(idOrSlug)
+	}
+	if thread.Closed {
+		_ = tx.Rollback()
+		return models.Thread{}, customErr.ThreadClosed(idOrSlug)
+	}
 	curVote := models.Vote{}
 	rows, err = tx.Query("selectVoteInfo", thread.ID, vote.Nickname)
 	if err != nil {
@@ -364,7 +556,7 @@ func (r *Repository) VoteThreadByID(idOrSlug string, vote models.Vote) (models.T
 		_, err = tx.Exec("intertVote", vote.Nickname, vote.Voice, thread.ID)
 		if err != nil {
 			_ = tx.Rollback()
-			return models.Thread{}, customErr.ErrUserNotFound
+			return models.Thread{}, customErr.UserNotFound(vote.Nickname)
 		}
 		if err := tx.Commit(); err != nil {
 			_ = tx.Rollback()
@@ -399,6 +591,105 @@ func (r *Repository) VoteThreadByID(idOrSlug string, vote models.Vote) (models.T
 	return thread, nil
 }
 
+// DeleteThread removes the thread together with its posts and votes, takes
+// them off the forum counters and drops the forum_users rows of authors
+// who have nothing else left in the forum.
+func (r *Repository) DeleteThread(idOrSlug string) (models.Thread, error) {
+	var thread models.Thread
+	tx, err := r.db.Begin()
+	if err != nil {
+		return models.Thread{}, err
+	}
+	var row *pgx.Row
+	if id, err := strconv.ParseUint(idOrSlug, 10, 64); err != nil {
+		row = tx.QueryRow("selectThreadForUpdateBySlug", idOrSlug)
+	} else {
+		row = tx.QueryRow("selectThreadForUpdateByID", id)
+	}
+	err = row.Scan(
+		&thread.ID,
+		&thread.Forum,
+		&thread.Author,
+		&thread.Title,
+		&thread.Message,
+		&thread.Votes,
+		&thread.Slug,
+		&thread.Created,
+		&thread.Archived,
+		&thread.Locked,
+		&thread.Pinned,
+		&thread.Closed,
+		&thread.Version)
+	if err == pgx.ErrNoRows {
+		_ = tx.Rollback()
+		return models.Thread{}, customErr.ThreadNotFound(idOrSlug)
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return models.Thread{}, err
+	}
+
+	if _, err = tx.Exec("deleteThreadVotes", thread.ID); err != nil {
+		_ = tx.Rollback()
+		return models.Thread{}, err
+	}
+	var livePosts int64
+	var authors []string
+	if err = tx.QueryRow("deleteThreadPosts", thread.ID).Scan(&livePosts, &authors); err != nil {
+		_ = tx.Rollback()
+		return models.Thread{}, err
+	}
+	if _, err = tx.Exec("deleteThread", thread.ID); err != nil {
+		_ = tx.Rollback()
+		return models.Thread{}, err
+	}
+	if _, err = tx.Exec("updateForumCounters", thread.Forum, livePosts); err != nil {
+		_ = tx.Rollback()
+		return models.Thread{}, err
+	}
+	authors = append(authors, thread.Author)
+	if _, err = tx.Exec("pruneForumUsers", thread.Forum, authors); err != nil {
+		_ = tx.Rollback()
+		return models.Thread{}, err
+	}
+	if err := tx.Commit(); err != nil {
+		_ = tx.Rollback()
+		return models.Thread{}, err
+	}
+	return thread, nil
+}
+
+func (r *Repository) SetThreadArchived(idOrSlug string, archived bool) (models.Thread, error) {
+	var thread models.Thread
+	var row *pgx.Row
+	if id, err := strconv.ParseUint(idOrSlug, 10, 64); err != nil {
+		row = r.db.QueryRow("setThreadArchivedBySlug", idOrSlug, archived)
+	} else {
+		row = r.db.QueryRow("setThreadArchivedByID", id, archived)
+	}
+	err := row.Scan(
+		&thread.ID,
+		&thread.Forum,
+		&thread.Author,
+		&thread.Title,
+		&thread.Message,
+		&thread.Votes,
+		&thread.Slug,
+		&thread.Created,
+		&thread.Archived,
+		&thread.Locked,
+		&thread.Pinned,
+		&thread.Closed,
+		&thread.Version)
+	if err == pgx.ErrNoRows {
+		return models.Thread{}, customErr.ThreadNotFound(idOrSlug)
+	}
+	if err != nil {
+		return models.Thread{}, err
+	}
+	return thread, nil
+}
+
 func (r *Repository) Prepare() error {
 	_, err := r.db.Prepare("selectThreadBySlug", selectThreadBySlug)
 	if err != nil {
@@ -418,11 +709,29 @@ func (r *Repository) Prepare() error {
 		return err
 	}
 
+	_, err = r.db.Prepare("selectThreadsByAuthor", selectThreadsByAuthor)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Prepare("selectThreadsByAuthorDesc", selectThreadsByAuthorDesc)
+	if err != nil {
+		return err
+	}
+
 	_, err = r.db.Prepare("updateThreadBySlug", updateThreadBySlug)
 	if err != nil {
 		return err
 	}
 
+	_, err = r.db.Prepare("selectThreadVersionBySlug", selectThreadVersionBySlug)
+	if err != nil {
+		return err
+	}
+	_, err = r.db.Prepare("selectThreadVersionByID", selectThreadVersionByID)
+	if err != nil {
+		return err
+	}
 	_, err = r.db.Prepare("updateThreadByID", updateThreadByID)
 	if err != nil {
 		return err
@@ -473,5 +782,50 @@ func (r *Repository) Prepare() error {
 		return err
 	}
 
+	_, err = r.db.Prepare("selectThreadForUpdateBySlug", selectThreadForUpdateBySlug)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Prepare("selectThreadForUpdateByID", selectThreadForUpdateByID)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Prepare("deleteThreadVotes", deleteThreadVotes)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Prepare("deleteThreadPosts", deleteThreadPosts)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Prepare("deleteThread", deleteThread)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Prepare("updateForumCounters", updateForumCounters)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Prepare("pruneForumUsers", pruneForumUsers)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Prepare("setThreadArchivedBySlug", setThreadArchivedBySlug)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Prepare("setThreadArchivedByID", setThreadArchivedByID)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
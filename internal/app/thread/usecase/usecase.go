@@ -1,21 +1,27 @@
 package usecase
 
 import (
+	"DBForum/internal/app/auth"
+	customErr "DBForum/internal/app/errors"
+	"DBForum/internal/app/forum"
 	"DBForum/internal/app/models"
-	postRepo "DBForum/internal/app/post/repository"
-	threadRepo "DBForum/internal/app/thread/repository"
+	"DBForum/internal/app/pagination"
+	"DBForum/internal/app/post"
+	"DBForum/internal/app/thread"
 	"strconv"
 )
 
 type UseCase struct {
-	threadRepo threadRepo.Repository
-	postRepo   postRepo.Repository
+	threadRepo thread.Repository
+	postRepo   post.Repository
+	forumRepo  forum.Repository
 }
 
-func NewUseCase(threadRepo threadRepo.Repository, postRepo postRepo.Repository) *UseCase {
+func NewUseCase(threadRepo thread.Repository, postRepo post.Repository, forumRepo forum.Repository) *UseCase {
 	return &UseCase{
 		threadRepo: threadRepo,
 		postRepo:   postRepo,
+		forumRepo:  forumRepo,
 	}
 }
 
@@ -36,24 +42,72 @@ func (u *UseCase) ThreadInfo(idOrSlug string) (*models.Thread, error) {
 	return thread, nil
 }
 
-func (u *UseCase) ChangeThread(idOrSlug string, thread models.Thread) (models.Thread, error) {
+// checkEditable loads the thread and checks that actor may edit it and, when
+// status changes any flags, moderate its forum.
+func (u *UseCase) checkEditable(actor models.Actor, idOrSlug string, action string, status models.ThreadStatus) (*models.Thread, error) {
+	thread, err := u.ThreadInfo(idOrSlug)
+	if err != nil {
+		return nil, err
+	}
+	role, err := auth.RoleIn(u.forumRepo, actor, thread.Forum)
+	if err != nil {
+		return nil, err
+	}
+	if err := auth.CanEdit(actor, action, thread.Author, role); err != nil {
+		return nil, err
+	}
+	if !status.Empty() {
+		if err := auth.CanModerate(actor, "change status of thread "+idOrSlug, role); err != nil {
+			return nil, err
+		}
+	}
+	return thread, nil
+}
+
+func (u *UseCase) ChangeThread(actor models.Actor, idOrSlug string, thread models.Thread, status models.ThreadStatus) (models.Thread, error) {
+	current, err := u.checkEditable(actor, idOrSlug, "edit thread "+idOrSlug, status)
+	if err != nil {
+		return models.Thread{}, err
+	}
+	if current.Archived {
+		return models.Thread{}, customErr.ThreadArchived(idOrSlug)
+	}
 	var id uint64
-	var err error
 	if id, err = strconv.ParseUint(idOrSlug, 10, 64); err != nil {
-		thread, err = u.threadRepo.UpdateThreadBySlug(idOrSlug, thread)
+		thread, err = u.threadRepo.UpdateThreadBySlug(idOrSlug, thread, status)
 		if err != nil {
 			return models.Thread{}, err
 		}
 		return thread, nil
 	}
-	thread, err = u.threadRepo.UpdateThreadByID(id, thread)
+	thread, err = u.threadRepo.UpdateThreadByID(id, thread, status)
 	if err != nil {
 		return models.Thread{}, err
 	}
 	return thread, nil
 }
 
+// checkBanned refuses writes by any of nicknames banned from the thread's
+// forum.
+func (u *UseCase) checkBanned(idOrSlug string, nicknames []string) error {
+	thread, err := u.ThreadInfo(idOrSlug)
+	if err != nil {
+		return err
+	}
+	banned, err := u.forumRepo.FindBanned(thread.Forum, nicknames)
+	if err != nil {
+		return err
+	}
+	if banned != "" {
+		return customErr.Banned(banned, thread.Forum)
+	}
+	return nil
+}
+
 func (u *UseCase) VoteThread(idOrSlug string, vote models.Vote) (models.Thread, error) {
+	if err := u.checkBanned(idOrSlug, []string{vote.Nickname}); err != nil {
+		return models.Thread{}, err
+	}
 	thread, err := u.threadRepo.VoteThreadByID(idOrSlug, vote)
 	if err != nil {
 		return models.Thread{}, err
@@ -61,7 +115,60 @@ func (u *UseCase) VoteThread(idOrSlug string, vote models.Vote) (models.Thread,
 	return thread, nil
 }
 
+// DeleteThread removes the thread with all its posts and votes, other
+// users' included, so it is left to the forum's moderators and admins.
+// Archived threads are kept for the record; only admins delete them.
+func (u *UseCase) DeleteThread(actor models.Actor, idOrSlug string) (models.Thread, error) {
+	current, err := u.ThreadInfo(idOrSlug)
+	if err != nil {
+		return models.Thread{}, err
+	}
+	role, err := auth.RoleIn(u.forumRepo, actor, current.Forum)
+	if err != nil {
+		return models.Thread{}, err
+	}
+	if err := auth.CanModerate(actor, "delete thread "+idOrSlug, role); err != nil {
+		return models.Thread{}, err
+	}
+	if current.Archived && !actor.Admin {
+		return models.Thread{}, customErr.ThreadArchived(idOrSlug)
+	}
+	thread, err := u.threadRepo.DeleteThread(idOrSlug)
+	if err != nil {
+		return models.Thread{}, err
+	}
+	return thread, nil
+}
+
+func (u *UseCase) SetArchived(actor models.Actor, idOrSlug string, archived bool) (models.Thread, error) {
+	current, err := u.ThreadInfo(idOrSlug)
+	if err != nil {
+		return models.Thread{}, err
+	}
+	role, err := auth.RoleIn(u.forumRepo, actor, current.Forum)
+	if err != nil {
+		return models.Thread{}, err
+	}
+	if err := auth.CanModerate(actor, "archive thread "+idOrSlug, role); err != nil {
+		return models.Thread{}, err
+	}
+	thread, err := u.threadRepo.SetThreadArchived(idOrSlug, archived)
+	if err != nil {
+		return models.Thread{}, err
+	}
+	return thread, nil
+}
+
 func (u *UseCase) CreatePosts(idOrSlug string, posts []models.Post) ([]models.Post, error) {
+	if len(posts) != 0 {
+		authors := make([]string, 0, len(posts))
+		for _, post := range posts {
+			authors = append(authors, post.Author)
+		}
+		if err := u.checkBanned(idOrSlug, authors); err != nil {
+			return nil, err
+		}
+	}
 	posts, err := u.postRepo.CreatePosts(idOrSlug, posts)
 	if err != nil {
 		return nil, err
@@ -72,13 +179,48 @@ func (u *UseCase) CreatePosts(idOrSlug string, posts []models.Post) ([]models.Po
 	return posts, nil
 }
 
-func (u *UseCase) GetPosts(idOrSlug string, limit int64, since int64, sort string, desc bool) ([]models.Post, error) {
-	posts, err := u.postRepo.GetPosts(idOrSlug, limit, since, desc, sort)
+func (u *UseCase) GetPosts(idOrSlug string, limit int64, since int64, cursor string, sort string, desc bool) ([]models.Post, models.Cursors, error) {
+	from, desc, err := pagination.Parse(cursor, desc)
 	if err != nil {
-		return nil, err
+		return nil, models.Cursors{}, err
 	}
-	if posts == nil {
-		return []models.Post{}, nil
+	posts, err := u.postRepo.GetPosts(idOrSlug, limit+1, since, from, desc, sort)
+	if err != nil {
+		return nil, models.Cursors{}, err
 	}
-	return posts, nil
+	if len(posts) == 0 {
+		return []models.Post{}, models.Cursors{}, nil
+	}
+	before := from != nil && from.Before
+	var more bool
+	if sort == "parent_tree" {
+		posts, more = trimRoots(posts, limit, before)
+	} else {
+		var first, last int
+		first, last, more = pagination.Trim(len(posts), int(limit), before)
+		posts = posts[first:last]
+	}
+	for i := range posts {
+		posts[i].Tombstone()
+	}
+	cursors := pagination.Links(from, more, since > 0, desc, posts[0].Position(), posts[len(posts)-1].Position())
+	return posts, cursors, nil
+}
+
+// trimRoots is pagination.Trim for parent_tree pages, whose limit counts
+// root posts: it drops the subtree of the extra root.
+func trimRoots(posts []models.Post, limit int64, before bool) ([]models.Post, bool) {
+	var roots []int
+	for i, p := range posts {
+		if p.Parent == 0 {
+			roots = append(roots, i)
+		}
+	}
+	if int64(len(roots)) <= limit {
+		return posts, false
+	}
+	if before {
+		return posts[roots[1]:], true
+	}
+	return posts[:roots[limit]], true
 }
@@ -0,0 +1,567 @@
+package usecase
+
+import (
+	customErr "DBForum/internal/app/errors"
+	"DBForum/internal/app/memory"
+	"DBForum/internal/app/models"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+var admin = models.Actor{Nickname: "root", Admin: true}
+
+type fixture struct {
+	store   *memory.Store
+	forums  *memory.ForumRepo
+	useCase *UseCase
+	thread  *models.Thread
+}
+
+func newFixture(t *testing.T) *fixture {
+	t.Helper()
+	store := memory.NewStore()
+	users := memory.NewUserRepo(store)
+	forums := memory.NewForumRepo(store)
+	threads := memory.NewThreadRepo(store)
+
+	for _, nick := range []string{"alice", "bob"} {
+		if err := users.CreateUser(models.User{Nickname: nick, Fullname: nick, Email: nick + "@example.com"}, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := forums.CreateForum(&models.Forum{Slug: "golang", Title: "Go", User: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	thread, err := threads.CreateThread(&models.Thread{
+		Forum:   "GoLang",
+		Author:  "Alice",
+		Title:   "Generics",
+		Message: "When?",
+		Slug:    "generics",
+		Created: time.Now(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &fixture{
+		store:   store,
+		forums:  forums,
+		useCase: NewUseCase(threads, memory.NewPostRepo(store), forums),
+		thread:  thread,
+	}
+}
+
+func (f *fixture) post(t *testing.T, parent uint64, author string) uint64 {
+	t.Helper()
+	posts, err := f.useCase.CreatePosts("generics", []models.Post{{Author: author, Message: "m", Parent: int(parent)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return posts[0].ID
+}
+
+func ids(posts []models.Post) []uint64 {
+	result := []uint64{}
+	for _, p := range posts {
+		result = append(result, p.ID)
+	}
+	return result
+}
+
+func TestCreatePostsBuildsTree(t *testing.T) {
+	f := newFixture(t)
+	root := f.post(t, 0, "alice")
+	child := f.post(t, root, "bob")
+
+	info, err := memory.NewPostRepo(f.store).GetPostInfoByID(child, []string{"forum"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int64{int64(root), int64(child)}; !reflect.DeepEqual([]int64(info.Post.Tree), want) {
+		t.Errorf("tree = %v, want %v", info.Post.Tree, want)
+	}
+	if info.Forum.Posts != 2 {
+		t.Errorf("forum posts = %d, want 2", info.Forum.Posts)
+	}
+}
+
+func TestCreatePostsParentFromAnotherThread(t *testing.T) {
+	f := newFixture(t)
+	other, err := memory.NewThreadRepo(f.store).CreateThread(&models.Thread{
+		Forum: "golang", Author: "bob", Title: "Other", Message: "m",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	foreign, err := f.useCase.CreatePosts(strconv.FormatUint(other.ID, 10), []models.Post{{Author: "bob", Message: "m"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = f.useCase.CreatePosts("generics", []models.Post{{Author: "alice", Message: "m", Parent: int(foreign[0].ID)}})
+	if !errors.Is(err, customErr.ErrNoParent) {
+		t.Errorf("err = %v, want ErrNoParent", err)
+	}
+}
+
+func TestCreatePostsUnknownAuthor(t *testing.T) {
+	f := newFixture(t)
+	_, err := f.useCase.CreatePosts("generics", []models.Post{{Author: "mallory", Message: "m"}})
+	if !errors.Is(err, customErr.ErrUserNotFound) {
+		t.Errorf("err = %v, want ErrUserNotFound", err)
+	}
+	if e := customErr.From(err); e == nil || e.Fields["nickname"] != "mallory" {
+		t.Errorf("error %+v does not name the author", e)
+	}
+}
+
+func TestThreadInfoNotFound(t *testing.T) {
+	f := newFixture(t)
+	for _, idOrSlug := range []string{"missing", "999"} {
+		_, err := f.useCase.ThreadInfo(idOrSlug)
+		if !errors.Is(err, customErr.ErrThreadNotFound) {
+			t.Errorf("%s: err = %v, want ErrThreadNotFound", idOrSlug, err)
+		}
+	}
+}
+
+func TestGetPostsSorts(t *testing.T) {
+	f := newFixture(t)
+	// 1
+	// ├── 2
+	// │   └── 4
+	// └── 3
+	// 5
+	// └── 6
+	p1 := f.post(t, 0, "alice")
+	p2 := f.post(t, p1, "bob")
+	p3 := f.post(t, p1, "bob")
+	p4 := f.post(t, p2, "alice")
+	p5 := f.post(t, 0, "bob")
+	p6 := f.post(t, p5, "alice")
+
+	tests := []struct {
+		sort  string
+		limit int64
+		since uint64
+		desc  bool
+		want  []uint64
+	}{
+		{"flat", 3, 0, false, []uint64{p1, p2, p3}},
+		{"flat", 2, p4, true, []uint64{p3, p2}},
+		{"tree", 10, 0, false, []uint64{p1, p2, p4, p3, p5, p6}},
+		{"tree", 3, p4, false, []uint64{p3, p5, p6}},
+		{"tree", 10, 0, true, []uint64{p6, p5, p3, p4, p2, p1}},
+		{"tree", 2, p5, true, []uint64{p3, p4}},
+		{"parent_tree", 1, 0, false, []uint64{p1, p2, p4, p3}},
+		{"parent_tree", 1, p1, false, []uint64{p5, p6}},
+		{"parent_tree", 10, 0, true, []uint64{p5, p6, p1, p2, p4, p3}},
+		{"parent_tree", 1, p5, true, []uint64{p1, p2, p4, p3}},
+	}
+	for _, tt := range tests {
+		posts, _, err := f.useCase.GetPosts("generics", tt.limit, int64(tt.since), "", tt.sort, tt.desc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ids(posts); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s limit=%d since=%d desc=%v: got %v, want %v",
+				tt.sort, tt.limit, tt.since, tt.desc, got, tt.want)
+		}
+	}
+}
+
+func TestGetPostsCursors(t *testing.T) {
+	f := newFixture(t)
+	p1 := f.post(t, 0, "alice")
+	p2 := f.post(t, p1, "bob")
+	p3 := f.post(t, 0, "bob")
+	p4 := f.post(t, p1, "alice")
+	p5 := f.post(t, 0, "bob")
+	all := map[string][]uint64{
+		"flat":        {p1, p2, p3, p4, p5},
+		"tree":        {p1, p2, p4, p3, p5},
+		"parent_tree": {p1, p2, p4, p3, p5},
+	}
+
+	for sort, want := range all {
+		for _, desc := range []bool{false, true} {
+			posts, cursors, err := f.useCase.GetPosts("generics", 2, 0, "", sort, desc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var pages [][]uint64
+			for len(pages) < 10 {
+				pages = append(pages, ids(posts))
+				if cursors.Next == "" {
+					break
+				}
+				if posts, cursors, err = f.useCase.GetPosts("generics", 2, 0, cursors.Next, sort, false); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var got []uint64
+			for _, page := range pages {
+				got = append(got, page...)
+			}
+			if !desc && !reflect.DeepEqual(got, want) {
+				t.Errorf("%s: paged forwards %v, want %v", sort, pages, want)
+			}
+			if len(got) != len(want) {
+				t.Errorf("%s desc=%v: paged forwards %v, want %d posts", sort, desc, pages, len(want))
+			}
+
+			var back [][]uint64
+			for cursors.Prev != "" && len(back) < 10 {
+				if posts, cursors, err = f.useCase.GetPosts("generics", 2, 0, cursors.Prev, sort, false); err != nil {
+					t.Fatal(err)
+				}
+				back = append([][]uint64{ids(posts)}, back...)
+			}
+			if !reflect.DeepEqual(back, pages[:len(pages)-1]) {
+				t.Errorf("%s desc=%v: paged back %v, want %v", sort, desc, back, pages[:len(pages)-1])
+			}
+		}
+	}
+
+	if _, _, err := f.useCase.GetPosts("generics", 2, 0, "bogus", "flat", false); !errors.Is(err, customErr.ErrInvalid) {
+		t.Errorf("bad cursor: err = %v, want ErrInvalid", err)
+	}
+}
+
+func TestVoteThread(t *testing.T) {
+	f := newFixture(t)
+	steps := []struct {
+		nickname string
+		voice    int
+		want     int
+	}{
+		{"alice", 1, 1},
+		{"BOB", 1, 2},
+		{"bob", 1, 2},
+		{"Alice", -1, 0},
+	}
+	for _, step := range steps {
+		thread, err := f.useCase.VoteThread("generics", models.Vote{Nickname: step.nickname, Voice: step.voice})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if thread.Votes != step.want {
+			t.Errorf("%s votes %d: got %d, want %d", step.nickname, step.voice, thread.Votes, step.want)
+		}
+	}
+
+	_, err := f.useCase.VoteThread("generics", models.Vote{Nickname: "mallory", Voice: 1})
+	if !errors.Is(err, customErr.ErrUserNotFound) {
+		t.Errorf("err = %v, want ErrUserNotFound", err)
+	}
+}
+
+func TestGetPostsKeepsDeletedPostsInTree(t *testing.T) {
+	f := newFixture(t)
+	root := f.post(t, 0, "alice")
+	child := f.post(t, root, "bob")
+	if _, err := memory.NewPostRepo(f.store).DeletePost(root); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, sort := range []string{"flat", "tree", "parent_tree"} {
+		posts, _, err := f.useCase.GetPosts("generics", 10, 0, "", sort, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ids(posts); !reflect.DeepEqual(got, []uint64{root, child}) {
+			t.Fatalf("%s: got %v, want %v", sort, got, []uint64{root, child})
+		}
+		if !posts[0].Deleted || posts[0].Message != "" || posts[1].Message != "m" {
+			t.Errorf("%s: posts = %+v, want the root as a tombstone", sort, posts)
+		}
+	}
+}
+
+func TestDeleteThreadNeedsModerator(t *testing.T) {
+	f := newFixture(t)
+	bob := models.Actor{Nickname: "bob"}
+	alice := models.Actor{Nickname: "alice"}
+	thread, err := memory.NewThreadRepo(f.store).CreateThread(&models.Thread{Forum: "golang", Author: "bob", Title: "Mine", Message: "m", Slug: "mine"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.useCase.DeleteThread(bob, "mine"); !errors.Is(err, customErr.ErrForbidden) {
+		t.Errorf("delete by the author: err = %v, want ErrForbidden", err)
+	}
+	if _, err := f.useCase.SetArchived(alice, "mine", true); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.useCase.DeleteThread(alice, "mine"); !errors.Is(err, customErr.ErrThreadArchived) {
+		t.Errorf("owner deleting an archived thread: err = %v, want ErrThreadArchived", err)
+	}
+	deleted, err := f.useCase.DeleteThread(admin, "mine")
+	if err != nil || deleted.ID != thread.ID {
+		t.Errorf("admin deleting an archived thread = %+v, %v", deleted, err)
+	}
+}
+
+func TestDeleteThread(t *testing.T) {
+	f := newFixture(t)
+	threads := memory.NewThreadRepo(f.store)
+	kept, err := threads.CreateThread(&models.Thread{Forum: "golang", Author: "alice", Title: "Kept", Message: "m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.useCase.CreatePosts(strconv.FormatUint(kept.ID, 10), []models.Post{{Author: "alice", Message: "m"}}); err != nil {
+		t.Fatal(err)
+	}
+	root := f.post(t, 0, "bob")
+	f.post(t, root, "alice")
+	if _, err := memory.NewPostRepo(f.store).DeletePost(root); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.useCase.VoteThread("generics", models.Vote{Nickname: "bob", Voice: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := f.useCase.DeleteThread(models.Actor{}, "generics"); !errors.Is(err, customErr.ErrUnauthorized) {
+		t.Errorf("anonymous delete: err = %v, want ErrUnauthorized", err)
+	}
+	if _, err := f.useCase.DeleteThread(models.Actor{Nickname: "bob"}, "generics"); !errors.Is(err, customErr.ErrForbidden) {
+		t.Errorf("delete by a stranger: err = %v, want ErrForbidden", err)
+	}
+	if _, err := f.useCase.ThreadInfo("generics"); err != nil {
+		t.Fatalf("refused deletes removed the thread: %v", err)
+	}
+
+	deleted, err := f.useCase.DeleteThread(models.Actor{Nickname: "alice"}, "generics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if deleted.ID != f.thread.ID {
+		t.Errorf("deleted thread %d, want %d", deleted.ID, f.thread.ID)
+	}
+	if _, err := f.useCase.ThreadInfo("generics"); !errors.Is(err, customErr.ErrThreadNotFound) {
+		t.Errorf("err = %v, want ErrThreadNotFound", err)
+	}
+	if _, err := memory.NewPostRepo(f.store).GetPostInfoByID(root, nil); !errors.Is(err, customErr.ErrPostNotFound) {
+		t.Errorf("err = %v, want ErrPostNotFound", err)
+	}
+
+	forum, err := memory.NewForumRepo(f.store).FindBySlug("golang")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if forum.Threads != 1 || forum.Posts != 1 {
+		t.Errorf("forum counters = %d threads, %d posts, want 1 and 1", forum.Threads, forum.Posts)
+	}
+	users, err := memory.NewUserRepo(f.store).GetForumUsers("golang", 10, "", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0].Nickname != "alice" {
+		t.Errorf("forum users = %+v, want only alice", users)
+	}
+
+	if _, err := f.useCase.DeleteThread(admin, "generics"); !errors.Is(err, customErr.ErrThreadNotFound) {
+		t.Errorf("second delete: err = %v, want ErrThreadNotFound", err)
+	}
+}
+
+func TestArchivedThreadIsReadOnly(t *testing.T) {
+	f := newFixture(t)
+	f.post(t, 0, "alice")
+	thread, err := f.useCase.SetArchived(admin, "generics", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !thread.Archived {
+		t.Fatalf("thread = %+v, want archived", thread)
+	}
+
+	if _, err := f.useCase.CreatePosts("generics", []models.Post{{Author: "bob", Message: "m"}}); !errors.Is(err, customErr.ErrThreadArchived) {
+		t.Errorf("create posts: err = %v, want ErrThreadArchived", err)
+	}
+	if _, err := f.useCase.VoteThread("generics", models.Vote{Nickname: "bob", Voice: 1}); !errors.Is(err, customErr.ErrThreadArchived) {
+		t.Errorf("vote: err = %v, want ErrThreadArchived", err)
+	}
+	if _, err := f.useCase.ChangeThread(admin, "generics", models.Thread{Title: "Now"}, models.ThreadStatus{}); !errors.Is(err, customErr.ErrThreadArchived) {
+		t.Errorf("change: err = %v, want ErrThreadArchived", err)
+	}
+	posts, _, err := f.useCase.GetPosts("generics", 10, 0, "", "flat", false)
+	if err != nil || len(posts) != 1 {
+		t.Errorf("get posts = %v, %v, want the existing post", posts, err)
+	}
+
+	if _, err := f.useCase.SetArchived(admin, "generics", false); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := f.useCase.ChangeThread(admin, "generics", models.Thread{Title: "Now"}, models.ThreadStatus{}); err != nil {
+		t.Errorf("change after unarchive: %v", err)
+	}
+}
+
+func TestThreadEditPermissions(t *testing.T) {
+	f := newFixture(t)
+	bob := models.Actor{Nickname: "bob"}
+	if _, err := f.useCase.ChangeThread(bob, "generics", models.Thread{Title: "Mine"}, models.ThreadStatus{}); !errors.Is(err, customErr.ErrForbidden) {
+		t.Errorf("edit by a stranger: err = %v, want ErrForbidden", err)
+	}
+	if _, err := f.useCase.DeleteThread(bob, "generics"); !errors.Is(err, customErr.ErrForbidden) {
+		t.Errorf("delete by a stranger: err = %v, want ErrForbidden", err)
+	}
+	if _, err := f.useCase.SetArchived(bob, "generics", true); !errors.Is(err, customErr.ErrForbidden) {
+		t.Errorf("archive by a non-moderator: err = %v, want ErrForbidden", err)
+	}
+	thread, err := f.useCase.ChangeThread(models.Actor{Nickname: "Alice"}, "generics", models.Thread{Title: "Soon"}, models.ThreadStatus{})
+	if err != nil || thread.Title != "Soon" {
+		t.Errorf("edit by the author = %+v, %v", thread, err)
+	}
+
+	if _, err := f.forums.SetRole(models.ForumRole{Forum: "golang", Nickname: "bob", Role: models.RoleModerator}); err != nil {
+		t.Fatal(err)
+	}
+	if thread, err = f.useCase.ChangeThread(bob, "generics", models.Thread{Title: "Moderated"}, models.ThreadStatus{}); err != nil || thread.Title != "Moderated" {
+		t.Errorf("edit by a moderator = %+v, %v", thread, err)
+	}
+	if thread, err = f.useCase.SetArchived(bob, "generics", true); err != nil || !thread.Archived {
+		t.Errorf("archive by a moderator = %+v, %v", thread, err)
+	}
+}
+
+func TestChangeThreadVersion(t *testing.T) {
+	f := newFixture(t)
+	alice := models.Actor{Nickname: "alice"}
+	read, err := f.useCase.ThreadInfo("generics")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	thread, err := f.useCase.ChangeThread(alice, "generics", models.Thread{Title: "Soon", Version: read.Version}, models.ThreadStatus{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if thread.Version != read.Version+1 {
+		t.Errorf("version after an edit = %d, want %d", thread.Version, read.Version+1)
+	}
+	_, err = f.useCase.ChangeThread(alice, "generics", models.Thread{Title: "Never", Version: read.Version}, models.ThreadStatus{})
+	if !errors.Is(err, customErr.ErrStale) {
+		t.Errorf("edit against a stale version: err = %v, want ErrStale", err)
+	}
+
+	same, err := f.useCase.ChangeThread(alice, "generics", models.Thread{Title: "Soon", Version: thread.Version}, models.ThreadStatus{})
+	if err != nil || same.Version != thread.Version {
+		t.Errorf("edit changing nothing = %+v, %v, want version %d", same, err, thread.Version)
+	}
+	if _, err := f.useCase.VoteThread("generics", models.Vote{Nickname: "bob", Voice: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if thread, err = f.useCase.ChangeThread(alice, "generics", models.Thread{Title: "Later", Version: same.Version}, models.ThreadStatus{}); err != nil || thread.Version != same.Version+1 {
+		t.Errorf("edit against the version read before a vote = %+v, %v, want version %d", thread, err, same.Version+1)
+	}
+}
+
+func TestBannedUserCannotWrite(t *testing.T) {
+	f := newFixture(t)
+	if _, err := f.forums.SetRole(models.ForumRole{Forum: "golang", Nickname: "bob", Role: models.RoleBanned}); err != nil {
+		t.Fatal(err)
+	}
+
+	posts := []models.Post{{Author: "alice", Message: "m"}, {Author: "Bob", Message: "m"}}
+	if _, err := f.useCase.CreatePosts("generics", posts); !errors.Is(err, customErr.ErrForbidden) {
+		t.Errorf("create posts: err = %v, want ErrForbidden", err)
+	}
+	if _, err := f.useCase.VoteThread("generics", models.Vote{Nickname: "bob", Voice: 1}); !errors.Is(err, customErr.ErrForbidden) {
+		t.Errorf("vote: err = %v, want ErrForbidden", err)
+	}
+	if _, err := f.useCase.CreatePosts("generics", posts[:1]); err != nil {
+		t.Errorf("create posts by a member: %v", err)
+	}
+}
+
+func TestThreadStatus(t *testing.T) {
+	f := newFixture(t)
+	yes, no := true, false
+	bob := models.Actor{Nickname: "bob"}
+
+	if _, err := f.useCase.ChangeThread(bob, "generics", models.Thread{}, models.ThreadStatus{Locked: &yes}); !errors.Is(err, customErr.ErrForbidden) {
+		t.Errorf("lock by a non-moderator: err = %v, want ErrForbidden", err)
+	}
+	if _, err := f.useCase.ChangeThread(models.Actor{}, "generics", models.Thread{}, models.ThreadStatus{Locked: &yes}); !errors.Is(err, customErr.ErrUnauthorized) {
+		t.Errorf("anonymous lock: err = %v, want ErrUnauthorized", err)
+	}
+
+	alice := models.Actor{Nickname: "alice"}
+	thread, err := f.useCase.ChangeThread(alice, "generics", models.Thread{}, models.ThreadStatus{Locked: &yes, Closed: &yes})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !thread.Locked || !thread.Closed || thread.Pinned || thread.Title != "Generics" {
+		t.Errorf("thread = %+v, want locked and closed", thread)
+	}
+	if _, err := f.useCase.CreatePosts("generics", []models.Post{{Author: "bob", Message: "m"}}); !errors.Is(err, customErr.ErrThreadLocked) {
+		t.Errorf("create posts: err = %v, want ErrThreadLocked", err)
+	}
+	if _, err := f.useCase.VoteThread("generics", models.Vote{Nickname: "bob", Voice: 1}); !errors.Is(err, customErr.ErrThreadClosed) {
+		t.Errorf("vote: err = %v, want ErrThreadClosed", err)
+	}
+
+	thread, err = f.useCase.ChangeThread(alice, "generics", models.Thread{Title: "Open"}, models.ThreadStatus{Locked: &no})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if thread.Locked || !thread.Closed || thread.Title != "Open" {
+		t.Errorf("thread = %+v, want unlocked, still closed, retitled", thread)
+	}
+	f.post(t, 0, "bob")
+}
+
+func TestPinnedThreadsComeFirst(t *testing.T) {
+	f := newFixture(t)
+	threads := memory.NewThreadRepo(f.store)
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, slug := range []string{"old", "new"} {
+		_, err := threads.CreateThread(&models.Thread{
+			Forum: "golang", Author: "bob", Title: "t", Message: "m", Slug: slug,
+			Created: start.Add(time.Duration(i) * time.Hour),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	yes := true
+	if _, err := f.useCase.ChangeThread(admin, "old", models.Thread{}, models.ThreadStatus{Pinned: &yes}); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, desc := range []bool{false, true} {
+		list, err := threads.GetForumThreads("golang", 10, "", nil, desc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(list) != 3 || list[0].Slug != "old" {
+			t.Errorf("desc=%v: first thread = %+v, want the pinned one", desc, list)
+		}
+	}
+
+	for _, tt := range []struct {
+		since time.Time
+		desc  bool
+		want  []string
+	}{
+		{start.Add(time.Hour), false, []string{"old", "new", "generics"}},
+		{start.Add(-time.Hour), true, []string{"old"}},
+	} {
+		list, err := threads.GetForumThreads("golang", 10, tt.since.Format(time.RFC3339Nano), nil, tt.desc)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var slugs []string
+		for _, thread := range list {
+			slugs = append(slugs, thread.Slug)
+		}
+		if !reflect.DeepEqual(slugs, tt.want) {
+			t.Errorf("since %s, desc=%v: threads = %v, want %v", tt.since, tt.desc, slugs, tt.want)
+		}
+	}
+}
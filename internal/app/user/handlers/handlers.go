@@ -1,14 +1,16 @@
 package handlers
 
 import (
+	"DBForum/internal/app/auth"
 	customErr "DBForum/internal/app/errors"
 	"DBForum/internal/app/httputils"
+	"DBForum/internal/app/metrics"
 	"DBForum/internal/app/models"
 	userUseCase "DBForum/internal/app/user/usecase"
+	"DBForum/internal/app/validation"
 	"errors"
 	"github.com/mailru/easyjson"
 	"github.com/valyala/fasthttp"
-	"log"
 	"net/http"
 )
 
@@ -26,27 +28,38 @@ func (h *Handlers) CreateUser(ctx *fasthttp.RequestCtx) {
 	nickname := ctx.UserValue("nickname").(string)
 
 	user := models.User{Nickname: nickname}
+	if err := validation.Param("nickname", nickname, validation.Nickname); err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
+	if err := validation.UserCreate.Object(ctx.PostBody()); err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
 	if err := easyjson.Unmarshal(ctx.PostBody(), &user); err != nil {
-		httputils.Respond(ctx, http.StatusInternalServerError, nil)
-		log.Println(err)
+		httputils.RespondError(ctx, err)
+		return
+	}
+	var credentials models.Credentials
+	if err := easyjson.Unmarshal(ctx.PostBody(), &credentials); err != nil {
+		httputils.RespondError(ctx, err)
 		return
 	}
 
-	err := h.useCase.CreateUser(user)
+	err := h.useCase.CreateUser(user, credentials.Password)
 	if errors.Is(err, customErr.ErrDuplicate) {
+		metrics.ObserveError(ctx, err)
 		var users models.UserList
 		users, err = h.useCase.GetUsersByNickAndEmail(user.Nickname, user.Email)
 		if err != nil {
-			httputils.Respond(ctx, http.StatusInternalServerError, nil)
-			log.Println(err)
+			httputils.RespondError(ctx, err)
 			return
 		}
 		httputils.Respond(ctx, http.StatusConflict, users)
 		return
 	}
 	if err != nil {
-		httputils.Respond(ctx, http.StatusInternalServerError, nil)
-		log.Println(err)
+		httputils.RespondError(ctx, err)
 		return
 	}
 	httputils.Respond(ctx, http.StatusCreated, user)
@@ -54,22 +67,16 @@ func (h *Handlers) CreateUser(ctx *fasthttp.RequestCtx) {
 
 func (h *Handlers) GetUserInfo(ctx *fasthttp.RequestCtx) {
 	nickname := ctx.UserValue("nickname").(string)
-	user := &models.User{Nickname: nickname}
 
 	user, err := h.useCase.GetUserInfo(nickname)
-
-	if errors.Is(err, customErr.ErrUserNotFound) {
-		resp := map[string]string{
-			"message": "Can't find user by nickname: " + nickname,
-		}
-		httputils.RespondErr(ctx, http.StatusNotFound, resp)
+	if err != nil {
+		httputils.RespondError(ctx, err)
 		return
 	}
-	if err != nil {
-		httputils.Respond(ctx, http.StatusInternalServerError, nil)
-		log.Println(err)
+	if httputils.NotModified(ctx, user.Version) {
 		return
 	}
+	httputils.SetETag(ctx, user.Version)
 
 	httputils.Respond(ctx, http.StatusOK, user)
 }
@@ -78,26 +85,76 @@ func (h *Handlers) ChangeUser(ctx *fasthttp.RequestCtx) {
 	nickname := ctx.UserValue("nickname").(string)
 
 	user := models.User{Nickname: nickname}
+	if err := auth.AuthorizeSelf(ctx, nickname); err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
+	if err := validation.UserUpdate.Object(ctx.PostBody()); err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
 	if err := easyjson.Unmarshal(ctx.PostBody(), &user); err != nil {
-		httputils.Respond(ctx, http.StatusInternalServerError, nil)
-		log.Println(err)
+		httputils.RespondError(ctx, err)
 		return
 	}
-
-	err := h.useCase.ChangeUser(&user)
-	if errors.Is(err, customErr.ErrUserNotFound) {
-		resp := map[string]string{
-			"message": "Can't find user by nickname: " + nickname,
-		}
-		httputils.RespondErr(ctx, http.StatusNotFound, resp)
+	version, err := httputils.IfMatch(ctx)
+	if err != nil {
+		httputils.RespondError(ctx, err)
 		return
 	}
-	if errors.Is(err, customErr.ErrConflict) {
-		resp := map[string]string{
-			"message": "This email is already registered by user: ",
-		}
-		httputils.RespondErr(ctx, http.StatusConflict, resp)
+	user.Version = version
+
+	if err := h.useCase.ChangeUser(&user); err != nil {
+		httputils.RespondError(ctx, err)
 		return
 	}
+	httputils.SetETag(ctx, user.Version)
 	httputils.Respond(ctx, http.StatusOK, user)
 }
+
+func (h *Handlers) Search(ctx *fasthttp.RequestCtx) {
+	prefix := string(ctx.QueryArgs().Peek("prefix"))
+	fuzzy := ctx.QueryArgs().GetBool("fuzzy")
+	limit := ctx.QueryArgs().GetUintOrZero("limit")
+
+	users, err := h.useCase.SearchUsers(prefix, fuzzy, limit)
+	if err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
+	httputils.Respond(ctx, http.StatusOK, models.UserList(users))
+}
+
+func (h *Handlers) GetPosts(ctx *fasthttp.RequestCtx) {
+	nickname := ctx.UserValue("nickname").(string)
+	forumSlug := string(ctx.QueryArgs().Peek("forum"))
+	limit := int64(ctx.QueryArgs().GetUintOrZero("limit"))
+	since := int64(ctx.QueryArgs().GetUintOrZero("since"))
+	cursor := string(ctx.QueryArgs().Peek("cursor"))
+	desc := ctx.QueryArgs().GetBool("desc")
+
+	posts, cursors, err := h.useCase.GetUserPosts(nickname, forumSlug, limit, since, cursor, desc)
+	if err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
+	httputils.SetLinks(ctx, cursors)
+	httputils.Respond(ctx, http.StatusOK, models.PostList(posts))
+}
+
+func (h *Handlers) GetThreads(ctx *fasthttp.RequestCtx) {
+	nickname := ctx.UserValue("nickname").(string)
+	forumSlug := string(ctx.QueryArgs().Peek("forum"))
+	limit := ctx.QueryArgs().GetUintOrZero("limit")
+	since := string(ctx.QueryArgs().Peek("since"))
+	cursor := string(ctx.QueryArgs().Peek("cursor"))
+	desc := ctx.QueryArgs().GetBool("desc")
+
+	threads, cursors, err := h.useCase.GetUserThreads(nickname, forumSlug, limit, since, cursor, desc)
+	if err != nil {
+		httputils.RespondError(ctx, err)
+		return
+	}
+	httputils.SetLinks(ctx, cursors)
+	httputils.Respond(ctx, http.StatusOK, models.ThreadList(threads))
+}
@@ -0,0 +1,19 @@
+package user
+
+import "DBForum/internal/app/models"
+
+type Repository interface {
+	GetForumUsers(forumSlug string, limit int, since string, from *models.Position, desc bool) ([]models.User, error)
+	// CreateUser stores the profile and, unless passwordHash is empty, the
+	// credentials in one transaction.
+	CreateUser(user models.User, passwordHash string) error
+	GetUsersByNickAndEmail(nickname string, email string) ([]models.User, error)
+	GetUserByNick(nickname string) (*models.User, error)
+	ChangeUser(user *models.User) error
+	GetUserNickByEmail(email string) (string, error)
+	RepairForumUsers() (int64, error)
+	// SearchUsers returns the users whose nickname starts with prefix, most
+	// active first. fuzzy also matches nicknames and full names similar to
+	// prefix.
+	SearchUsers(prefix string, fuzzy bool, limit int) ([]models.User, error)
+}
@@ -3,7 +3,10 @@ package repository
 import (
 	customErr "DBForum/internal/app/errors"
 	"DBForum/internal/app/models"
+	"DBForum/internal/app/pagination"
+	"DBForum/internal/app/user"
 	"github.com/jackc/pgx"
+	"strings"
 )
 
 const (
@@ -46,19 +49,52 @@ const (
                                    $3,
                                    $4)`
 
+	insertCredentials = "INSERT INTO dbforum.credentials(nickname, password_hash) VALUES ($1, $2)"
+
 	selectUsersByNickAndEmail = "SELECT nickname, fullname, about, email FROM dbforum.users WHERE nickname = $1 OR email = $2"
 
-	selectByNickname = "SELECT nickname, fullname, about, email FROM dbforum.users WHERE nickname = $1"
+	selectByNickname = "SELECT nickname, fullname, about, email, version FROM dbforum.users WHERE nickname = $1"
 
 	updateUser = `UPDATE dbforum.users SET 
 					fullname=COALESCE(NULLIF($1, ''), fullname),
 					about=COALESCE(NULLIF($2, ''), about),
 					email=COALESCE(NULLIF($3, ''), email)
-					WHERE nickname=$4 RETURNING nickname, fullname, about, email`
+					WHERE nickname=$4 AND ($5::bigint = 0 OR version = $5) RETURNING nickname, fullname, about, email, version`
 
 	selectNickByEmail = "SELECT nickname FROM dbforum.users WHERE email = $1"
+
+	updateForumUsers = `UPDATE dbforum.forum_users SET
+					fullname=$1,
+					about=$2,
+					email=$3
+					WHERE nickname=$4 AND (fullname, about, email) IS DISTINCT FROM ($1, $2, $3)`
+
+	repairForumUsers = `UPDATE dbforum.forum_users AS fu SET
+					fullname=u.fullname,
+					about=u.about,
+					email=u.email
+					FROM dbforum.users AS u
+					WHERE u.nickname = fu.nickname
+					AND (fu.fullname, fu.about, fu.email) IS DISTINCT FROM (u.fullname, u.about, u.email)`
+
+	selectUsersByPrefix = `SELECT u.nickname, u.fullname, u.about, u.email FROM dbforum.users u
+					LEFT JOIN dbforum.user_activity a ON a.nickname = u.nickname
+					WHERE lower(u.nickname::text) LIKE $1
+					ORDER BY COALESCE(a.activity, 0) DESC, u.nickname
+					LIMIT $2`
+
+	selectUsersFuzzy = `SELECT u.nickname, u.fullname, u.about, u.email FROM dbforum.users u
+					LEFT JOIN dbforum.user_activity a ON a.nickname = u.nickname
+					WHERE lower(u.nickname::text) LIKE $1 OR u.nickname::text % $3 OR u.fullname % $3
+					ORDER BY greatest(similarity(u.nickname::text, $3), similarity(u.fullname, $3)) DESC,
+						COALESCE(a.activity, 0) DESC, u.nickname
+					LIMIT $2`
 )
 
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+var _ user.Repository = (*Repository)(nil)
+
 type Repository struct {
 	db *pgx.ConnPool
 }
@@ -69,7 +105,7 @@ func NewRepo(db *pgx.ConnPool) *Repository {
 	}
 }
 
-func (r *Repository) GetForumUsers(forumSlug string, limit int, since string, desc bool) ([]models.User, error) {
+func (r *Repository) GetForumUsers(forumSlug string, limit int, since string, from *models.Position, desc bool) ([]models.User, error) {
 	tx, err := r.db.Begin()
 	if err != nil {
 		return nil, err
@@ -83,10 +119,21 @@ func (r *Repository) GetForumUsers(forumSlug string, limit int, since string, de
 	}
 	if !row.Next() {
 		_ = tx.Rollback()
-		return nil, customErr.ErrForumNotFound
+		return nil, customErr.ForumNotFound(forumSlug)
 	}
 	row.Close()
-	if since == "" {
+	if from != nil {
+		since = from.Key
+	}
+	if from != nil && from.Before {
+		op, dir := pagination.Walk(desc, true)
+		_, listDir := pagination.Walk(desc, false)
+		query := pagination.Reorder("SELECT fu.nickname, fu.fullname, fu.about, fu.email "+
+			"FROM dbforum.forum_users AS fu "+
+			"WHERE fu.forum_slug = $1 AND fu.nickname "+op+" $2 "+
+			"ORDER BY fu.nickname "+dir+" LIMIT $3", "nickname "+listDir)
+		row, err = r.db.Query(query, forumSlug, since, limit)
+	} else if since == "" {
 		if desc {
 			row, err = r.db.Query("selectUsersByForumSlugDesc", forumSlug, limit)
 		} else {
@@ -125,17 +172,29 @@ func (r *Repository) GetForumUsers(forumSlug string, limit int, since string, de
 	return users, nil
 }
 
-func (r *Repository) CreateUser(user models.User) error {
-	_, err := r.db.Exec("insertUser", &user.Nickname, &user.Fullname, &user.About, &user.Email)
+func (r *Repository) CreateUser(user models.User, passwordHash string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("insertUser", &user.Nickname, &user.Fullname, &user.About, &user.Email)
 	if driverErr, ok := err.(pgx.PgError); ok {
 		if driverErr.Code == "23505" {
+			_ = tx.Rollback()
 			return customErr.ErrDuplicate
 		}
 	}
 	if err != nil {
+		_ = tx.Rollback()
 		return err
 	}
-	return nil
+	if passwordHash != "" {
+		if _, err = tx.Exec("insertCredentials", user.Nickname, passwordHash); err != nil {
+			_ = tx.Rollback()
+			return err
+		}
+	}
+	return tx.Commit()
 }
 
 func (r *Repository) GetUsersByNickAndEmail(nickname string, email string) ([]models.User, error) {
@@ -167,13 +226,14 @@ func (r *Repository) GetUserByNick(nickname string) (*models.User, error) {
 		return nil, err
 	}
 	if !rows.Next() {
-		return nil, customErr.ErrUserNotFound
+		return nil, customErr.UserNotFound(nickname)
 	}
 	err = rows.Scan(
 		&user.Nickname,
 		&user.Fullname,
 		&user.About,
-		&user.Email)
+		&user.Email,
+		&user.Version)
 	if err != nil {
 		return nil, err
 	}
@@ -186,20 +246,31 @@ func (r *Repository) ChangeUser(user *models.User) error {
 	if err != nil {
 		return err
 	}
-	err = tx.QueryRow("updateUser", &user.Fullname, &user.About, &user.Email, &user.Nickname).Scan(
+	expected := user.Version
+	err = tx.QueryRow("updateUser", &user.Fullname, &user.About, &user.Email, &user.Nickname, expected).Scan(
 		&user.Nickname,
 		&user.Fullname,
 		&user.About,
-		&user.Email)
+		&user.Email,
+		&user.Version)
 	if driverErr, ok := err.(pgx.PgError); ok {
 		if driverErr.Code == "23505" {
 			_ = tx.Rollback()
 			return customErr.ErrConflict
 		}
 	}
+	if err == pgx.ErrNoRows {
+		_ = tx.Rollback()
+		return r.staleOrMissing(user.Nickname, expected)
+	}
+	if err != nil {
+		_ = tx.Rollback()
+		return err
+	}
+	_, err = tx.Exec("updateForumUsers", &user.Fullname, &user.About, &user.Email, &user.Nickname)
 	if err != nil {
 		_ = tx.Rollback()
-		return customErr.ErrUserNotFound
+		return err
 	}
 	if err := tx.Commit(); err != nil {
 		_ = tx.Rollback()
@@ -208,6 +279,23 @@ func (r *Repository) ChangeUser(user *models.User) error {
 	return nil
 }
 
+// staleOrMissing explains an update against version expected that matched
+// no row: the user is missing, or has moved past that version.
+func (r *Repository) staleOrMissing(nickname string, expected uint64) error {
+	if expected == 0 {
+		return customErr.UserNotFound(nickname)
+	}
+	var id uint64
+	err := r.db.QueryRow("selectIDByNickname", nickname).Scan(&id)
+	if err == pgx.ErrNoRows {
+		return customErr.UserNotFound(nickname)
+	}
+	if err != nil {
+		return err
+	}
+	return customErr.Stale(expected)
+}
+
 func (r *Repository) GetUserNickByEmail(email string) (string, error) {
 	var nickname string
 	rows, err := r.db.Query(selectNickByEmail, email)
@@ -227,17 +315,71 @@ func (r *Repository) GetUserNickByEmail(email string) (string, error) {
 	return nickname, nil
 }
 
+// RepairForumUsers copies the current profile of every user into the
+// forum_users rows that drifted from it and returns how many were fixed.
+func (r *Repository) RepairForumUsers() (int64, error) {
+	tag, err := r.db.Exec(repairForumUsers)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
+
+func (r *Repository) SearchUsers(prefix string, fuzzy bool, limit int) ([]models.User, error) {
+	pattern := likeEscaper.Replace(strings.ToLower(prefix)) + "%"
+	var rows *pgx.Rows
+	var err error
+	if fuzzy {
+		rows, err = r.db.Query("selectUsersFuzzy", pattern, limit, prefix)
+	} else {
+		rows, err = r.db.Query("selectUsersByPrefix", pattern, limit)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var users []models.User
+	for rows.Next() {
+		u := models.User{}
+		err := rows.Scan(
+			&u.Nickname,
+			&u.Fullname,
+			&u.About,
+			&u.Email)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
 func (r *Repository) Prepare() error {
 	_, err := r.db.Prepare("insertUser", insertUser)
 	if err != nil {
 		return err
 	}
 
+	_, err = r.db.Prepare("selectIDByNickname", selectIDByNickname)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Prepare("insertCredentials", insertCredentials)
+	if err != nil {
+		return err
+	}
+
 	_, err = r.db.Prepare("updateUser", updateUser)
 	if err != nil {
 		return err
 	}
 
+	_, err = r.db.Prepare("updateForumUsers", updateForumUsers)
+	if err != nil {
+		return err
+	}
+
 	_, err = r.db.Prepare("selectUsersByForumSlugSinceDesc", selectUsersByForumSlugSinceDesc)
 	if err != nil {
 		return err
@@ -268,5 +410,15 @@ func (r *Repository) Prepare() error {
 		return err
 	}
 
+	_, err = r.db.Prepare("selectUsersByPrefix", selectUsersByPrefix)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.db.Prepare("selectUsersFuzzy", selectUsersFuzzy)
+	if err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -1,26 +1,49 @@
 package usecase
 
 import (
+	"DBForum/internal/app/auth"
+	customErr "DBForum/internal/app/errors"
 	"DBForum/internal/app/models"
-	userRepo "DBForum/internal/app/user/repository"
+	"DBForum/internal/app/pagination"
+	"DBForum/internal/app/post"
+	"DBForum/internal/app/thread"
+	"DBForum/internal/app/user"
+	"errors"
+	"time"
+)
+
+const (
+	defaultSearchLimit = 10
+	maxSearchLimit     = 100
+
+	defaultActivityLimit = 100
 )
 
 type UseCase struct {
-	repo userRepo.Repository
+	repo       user.Repository
+	postRepo   post.Repository
+	threadRepo thread.Repository
 }
 
-func NewUseCase(repo userRepo.Repository) *UseCase {
+func NewUseCase(repo user.Repository, postRepo post.Repository, threadRepo thread.Repository) *UseCase {
 	return &UseCase{
-		repo: repo,
+		repo:       repo,
+		postRepo:   postRepo,
+		threadRepo: threadRepo,
 	}
 }
 
-func (u *UseCase) CreateUser(user models.User) error {
-	err := u.repo.CreateUser(user)
-	if err != nil {
-		return err
+// CreateUser creates the profile and, when a password is given, the
+// credentials to log in with. Both are stored or neither is.
+func (u *UseCase) CreateUser(user models.User, password string) error {
+	hash := ""
+	if password != "" {
+		var err error
+		if hash, err = auth.HashPassword(password); err != nil {
+			return err
+		}
 	}
-	return nil
+	return u.repo.CreateUser(user, hash)
 }
 
 func (u *UseCase) GetUsersByNickAndEmail(nickname string, email string) ([]models.User, error) {
@@ -41,6 +64,13 @@ func (u *UseCase) GetUserInfo(nickname string) (*models.User, error) {
 
 func (u *UseCase) ChangeUser(user *models.User) error {
 	err := u.repo.ChangeUser(user)
+	if errors.Is(err, customErr.ErrConflict) {
+		owner, lookupErr := u.repo.GetUserNickByEmail(user.Email)
+		if lookupErr != nil {
+			return err
+		}
+		return customErr.EmailConflict(user.Email, owner)
+	}
 	if err != nil {
 		return err
 	}
@@ -54,3 +84,81 @@ func (u *UseCase) GetUserNickByEmail(email string) (string, error) {
 	}
 	return nickname, nil
 }
+
+func (u *UseCase) SearchUsers(prefix string, fuzzy bool, limit int) ([]models.User, error) {
+	if prefix == "" {
+		return nil, customErr.Invalid("prefix", "must not be empty")
+	}
+	if limit == 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+	users, err := u.repo.SearchUsers(prefix, fuzzy, limit)
+	if err != nil {
+		return nil, err
+	}
+	if users == nil {
+		return []models.User{}, nil
+	}
+	return users, nil
+}
+
+// GetUserPosts lists what the user has posted across all forums, or in one
+// forum when forumSlug is set. Paging works like the flat thread listing.
+func (u *UseCase) GetUserPosts(nickname string, forumSlug string, limit int64, since int64, cursor string, desc bool) ([]models.Post, models.Cursors, error) {
+	if _, err := u.repo.GetUserByNick(nickname); err != nil {
+		return nil, models.Cursors{}, err
+	}
+	if limit == 0 {
+		limit = defaultActivityLimit
+	}
+	from, desc, err := pagination.Parse(cursor, desc)
+	if err != nil {
+		return nil, models.Cursors{}, err
+	}
+	posts, err := u.postRepo.GetUserPosts(nickname, forumSlug, limit+1, since, from, desc)
+	if err != nil {
+		return nil, models.Cursors{}, err
+	}
+	if len(posts) == 0 {
+		return []models.Post{}, models.Cursors{}, nil
+	}
+	first, last, more := pagination.Trim(len(posts), int(limit), from != nil && from.Before)
+	posts = posts[first:last]
+	for i := range posts {
+		posts[i].Tombstone()
+	}
+	cursors := pagination.Links(from, more, since > 0, desc, posts[0].Position(), posts[len(posts)-1].Position())
+	return posts, cursors, nil
+}
+
+func (u *UseCase) GetUserThreads(nickname string, forumSlug string, limit int, since string, cursor string, desc bool) ([]models.Thread, models.Cursors, error) {
+	if _, err := u.repo.GetUserByNick(nickname); err != nil {
+		return nil, models.Cursors{}, err
+	}
+	if since != "" {
+		if _, err := time.Parse(time.RFC3339Nano, since); err != nil {
+			return nil, models.Cursors{}, customErr.Invalid("since", "must be an RFC 3339 date-time")
+		}
+	}
+	if limit == 0 {
+		limit = defaultActivityLimit
+	}
+	from, desc, err := pagination.Parse(cursor, desc)
+	if err != nil {
+		return nil, models.Cursors{}, err
+	}
+	threads, err := u.threadRepo.GetUserThreads(nickname, forumSlug, limit+1, since, from, desc)
+	if err != nil {
+		return nil, models.Cursors{}, err
+	}
+	if len(threads) == 0 {
+		return []models.Thread{}, models.Cursors{}, nil
+	}
+	first, last, more := pagination.Trim(len(threads), limit, from != nil && from.Before)
+	threads = threads[first:last]
+	cursors := pagination.Links(from, more, since != "", desc, threads[0].Position(), threads[len(threads)-1].Position())
+	return threads, cursors, nil
+}
@@ -0,0 +1,243 @@
+package usecase
+
+import (
+	"DBForum/internal/app/auth"
+	customErr "DBForum/internal/app/errors"
+	"DBForum/internal/app/memory"
+	"DBForum/internal/app/models"
+	"errors"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestCreateAndChangeUser(t *testing.T) {
+	store := memory.NewStore()
+	u := NewUseCase(memory.NewUserRepo(store), memory.NewPostRepo(store), memory.NewThreadRepo(store))
+
+	alice := models.User{Nickname: "alice", Fullname: "Alice", Email: "alice@example.com"}
+	if err := u.CreateUser(alice, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := u.CreateUser(models.User{Nickname: "bob", Fullname: "Bob", Email: "bob@example.com"}, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	err := u.CreateUser(models.User{Nickname: "carol", Fullname: "Carol", Email: "ALICE@example.com"}, "")
+	if !errors.Is(err, customErr.ErrDuplicate) {
+		t.Fatalf("err = %v, want ErrDuplicate", err)
+	}
+	users, err := u.GetUsersByNickAndEmail("BOB", "Alice@Example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 {
+		t.Errorf("conflicting users = %v, want alice and bob", users)
+	}
+
+	change := &models.User{Nickname: "ALICE", About: "gopher"}
+	if err := u.ChangeUser(change); err != nil {
+		t.Fatal(err)
+	}
+	if *change != (models.User{Nickname: "alice", Fullname: "Alice", About: "gopher", Email: "alice@example.com", Version: 2}) {
+		t.Errorf("changed user = %+v", change)
+	}
+
+	err = u.ChangeUser(&models.User{Nickname: "alice", Email: "bob@example.com"})
+	if !errors.Is(err, customErr.ErrConflict) {
+		t.Errorf("err = %v, want ErrConflict", err)
+	}
+	err = u.ChangeUser(&models.User{Nickname: "mallory", About: "x"})
+	if !errors.Is(err, customErr.ErrUserNotFound) {
+		t.Errorf("err = %v, want ErrUserNotFound", err)
+	}
+}
+
+func TestCreateUserWithPassword(t *testing.T) {
+	store := memory.NewStore()
+	u := NewUseCase(memory.NewUserRepo(store), memory.NewPostRepo(store), memory.NewThreadRepo(store))
+	if err := u.CreateUser(models.User{Nickname: "alice", Fullname: "Alice", Email: "alice@example.com"}, "password alice"); err != nil {
+		t.Fatal(err)
+	}
+	credentials, err := memory.NewAuthRepo(store).FindCredentials("alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !auth.CheckPassword(credentials.Password, "password alice") {
+		t.Errorf("stored hash %q does not match the password", credentials.Password)
+	}
+}
+
+func TestChangeUserUpdatesForumUsers(t *testing.T) {
+	store := memory.NewStore()
+	users := memory.NewUserRepo(store)
+	u := NewUseCase(users, memory.NewPostRepo(store), memory.NewThreadRepo(store))
+	if err := u.CreateUser(models.User{Nickname: "alice", Fullname: "Alice", Email: "alice@example.com"}, ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := memory.NewForumRepo(store).CreateForum(&models.Forum{Slug: "golang", Title: "Go", User: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	_, err := memory.NewThreadRepo(store).CreateThread(&models.Thread{Forum: "golang", Author: "alice", Title: "t", Message: "m"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := u.ChangeUser(&models.User{Nickname: "alice", Email: "liddell@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+	forumUsers, err := users.GetForumUsers("golang", 10, "", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(forumUsers) != 1 || forumUsers[0].Email != "liddell@example.com" {
+		t.Errorf("forum users = %v, want alice with the new email", forumUsers)
+	}
+	if repaired, _ := users.RepairForumUsers(); repaired != 0 {
+		t.Errorf("repaired %d rows of an up to date store", repaired)
+	}
+}
+
+func TestSearchUsers(t *testing.T) {
+	store := memory.NewStore()
+	u := NewUseCase(memory.NewUserRepo(store), memory.NewPostRepo(store), memory.NewThreadRepo(store))
+	for _, user := range []models.User{
+		{Nickname: "Alex", Fullname: "Alex Green", Email: "alex@example.com"},
+		{Nickname: "alice", Fullname: "Alice Liddell", Email: "alice@example.com"},
+		{Nickname: "al_bundy", Fullname: "Al Bundy", Email: "al@example.com"},
+		{Nickname: "bob", Fullname: "Robert Alexander", Email: "bob@example.com"},
+	} {
+		if err := u.CreateUser(user, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := memory.NewForumRepo(store).CreateForum(&models.Forum{Slug: "golang", Title: "Go", User: "alice"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, author := range []string{"alice", "bob", "bob"} {
+		if _, err := memory.NewThreadRepo(store).CreateThread(&models.Thread{Forum: "golang", Author: author, Title: "t", Message: "m"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	nicknames := func(users []models.User) []string {
+		result := []string{}
+		for _, user := range users {
+			result = append(result, user.Nickname)
+		}
+		return result
+	}
+	tests := []struct {
+		prefix string
+		fuzzy  bool
+		limit  int
+		want   []string
+	}{
+		{"AL", false, 0, []string{"alice", "al_bundy", "Alex"}},
+		{"al_", false, 0, []string{"al_bundy"}},
+		{"al", false, 1, []string{"alice"}},
+		{"alexander", true, 0, []string{"bob", "Alex"}},
+		{"alexand", true, 0, []string{"Alex", "bob"}},
+		{"zed", true, 0, []string{}},
+	}
+	for _, tt := range tests {
+		users, err := u.SearchUsers(tt.prefix, tt.fuzzy, tt.limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := nicknames(users); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SearchUsers(%q, %v) = %v, want %v", tt.prefix, tt.fuzzy, got, tt.want)
+		}
+	}
+
+	if _, err := u.SearchUsers("", false, 0); !errors.Is(err, customErr.ErrInvalid) {
+		t.Errorf("empty prefix: err = %v, want ErrInvalid", err)
+	}
+}
+
+func TestUserActivity(t *testing.T) {
+	store := memory.NewStore()
+	u := NewUseCase(memory.NewUserRepo(store), memory.NewPostRepo(store), memory.NewThreadRepo(store))
+	for _, nickname := range []string{"alice", "bob"} {
+		if err := u.CreateUser(models.User{Nickname: nickname, Fullname: nickname, Email: nickname + "@example.com"}, ""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	forums := memory.NewForumRepo(store)
+	threads := memory.NewThreadRepo(store)
+	posts := memory.NewPostRepo(store)
+	var threadIDs []uint64
+	for _, slug := range []string{"golang", "rust"} {
+		if err := forums.CreateForum(&models.Forum{Slug: slug, Title: slug, User: "bob"}); err != nil {
+			t.Fatal(err)
+		}
+		created, err := threads.CreateThread(&models.Thread{Forum: slug, Author: "alice", Title: "t", Message: "m"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		threadIDs = append(threadIDs, created.ID)
+	}
+	if _, err := threads.CreateThread(&models.Thread{Forum: "golang", Author: "bob", Title: "t", Message: "m"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, id := range threadIDs {
+		thread := strconv.FormatUint(id, 10)
+		if _, err := posts.CreatePosts(thread, []models.Post{{Author: "alice", Message: "a1"}, {Author: "bob", Message: "b"}, {Author: "alice", Message: "a2"}}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ids := func(posts []models.Post) []uint64 {
+		result := []uint64{}
+		for _, p := range posts {
+			result = append(result, p.ID)
+		}
+		return result
+	}
+	got, _, err := u.GetUserPosts("ALICE", "", 0, 0, "", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []uint64{1, 3, 4, 6}; !reflect.DeepEqual(ids(got), want) {
+		t.Errorf("posts = %v, want %v", ids(got), want)
+	}
+	got, _, _ = u.GetUserPosts("alice", "", 2, 4, "", true)
+	if want := []uint64{3, 1}; !reflect.DeepEqual(ids(got), want) {
+		t.Errorf("posts since 4 desc = %v, want %v", ids(got), want)
+	}
+	got, _, _ = u.GetUserPosts("alice", "rust", 0, 0, "", false)
+	if want := []uint64{4, 6}; !reflect.DeepEqual(ids(got), want) {
+		t.Errorf("posts in rust = %v, want %v", ids(got), want)
+	}
+
+	if _, err := posts.DeletePost(6); err != nil {
+		t.Fatal(err)
+	}
+	got, _, _ = u.GetUserPosts("alice", "rust", 0, 5, "", false)
+	if len(got) != 1 || !got[0].Deleted || got[0].Message != "" {
+		t.Errorf("deleted post = %+v, want a tombstone", got)
+	}
+
+	userThreads, _, err := u.GetUserThreads("alice", "", 0, "", "", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(userThreads) != 2 || userThreads[0].ID != threadIDs[1] || userThreads[1].ID != threadIDs[0] {
+		t.Errorf("threads desc = %v, want %v reversed", userThreads, threadIDs)
+	}
+	userThreads, _, _ = u.GetUserThreads("alice", "golang", 0, "", "", false)
+	if len(userThreads) != 1 || userThreads[0].ID != threadIDs[0] {
+		t.Errorf("threads in golang = %v", userThreads)
+	}
+	userThreads, _, _ = u.GetUserThreads("bob", "rust", 0, "", "", false)
+	if userThreads == nil || len(userThreads) != 0 {
+		t.Errorf("bob's threads in rust = %v, want an empty list", userThreads)
+	}
+
+	if _, _, err := u.GetUserThreads("alice", "", 0, "yesterday", "", false); !errors.Is(err, customErr.ErrInvalid) {
+		t.Errorf("bad since: err = %v, want ErrInvalid", err)
+	}
+	if _, _, err := u.GetUserPosts("mallory", "", 0, 0, "", false); !errors.Is(err, customErr.ErrUserNotFound) {
+		t.Errorf("unknown user: err = %v, want ErrUserNotFound", err)
+	}
+}
@@ -0,0 +1,81 @@
+package validation
+
+import customErr "DBForum/internal/app/errors"
+
+var (
+	ForumCreate = Schema{
+		{Name: "title", Kind: String, Required: true},
+		{Name: "user", Kind: String, Required: true, Check: Nickname},
+		{Name: "slug", Kind: String, Required: true, Check: Slug},
+	}
+
+	ThreadCreate = Schema{
+		{Name: "title", Kind: String, Required: true},
+		{Name: "author", Kind: String, Required: true, Check: Nickname},
+		{Name: "message", Kind: String, Required: true},
+		{Name: "forum", Kind: String},
+		{Name: "slug", Kind: String, Check: Slug},
+		{Name: "created", Kind: DateTime},
+		{Name: "votes", Kind: Integer},
+	}
+
+	ThreadUpdate = Schema{
+		{Name: "title", Kind: String},
+		{Name: "message", Kind: String},
+		{Name: "locked", Kind: Boolean},
+		{Name: "pinned", Kind: Boolean},
+		{Name: "closed", Kind: Boolean},
+	}
+
+	PostCreate = Schema{
+		{Name: "author", Kind: String, Required: true, Check: Nickname},
+		{Name: "message", Kind: String, Required: true},
+		{Name: "parent", Kind: Integer, Check: NonNegative},
+		{Name: "created", Kind: DateTime},
+		{Name: "isEdited", Kind: Boolean},
+	}
+
+	PostUpdate = Schema{
+		{Name: "message", Kind: String},
+	}
+
+	Vote = Schema{
+		{Name: "nickname", Kind: String, Required: true, Check: Nickname},
+		{Name: "voice", Kind: Integer, Required: true, Check: Voice},
+	}
+
+	UserCreate = Schema{
+		{Name: "fullname", Kind: String, Required: true},
+		{Name: "email", Kind: String, Required: true, Check: Email},
+		{Name: "about", Kind: String},
+		{Name: "password", Kind: String, Check: Password},
+	}
+
+	UserUpdate = Schema{
+		{Name: "fullname", Kind: String},
+		{Name: "email", Kind: String, Check: Email},
+		{Name: "about", Kind: String},
+	}
+
+	ForumRole = Schema{
+		{Name: "role", Kind: String, Required: true, Check: Role},
+	}
+
+	Login = Schema{
+		{Name: "nickname", Kind: String, Required: true, Check: Nickname},
+		{Name: "password", Kind: String, Required: true},
+	}
+
+	SetPassword = Schema{
+		{Name: "password", Kind: String, Required: true, Check: Password},
+	}
+)
+
+// Param validates a path parameter such as the nickname in
+// /api/user/{nickname}/create.
+func Param(name string, value string, check func(value interface{}) string) error {
+	if message := check(value); message != "" {
+		return customErr.Invalid(name, message)
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+// Package validation checks request bodies against a small schema before
+// they are unmarshalled into models, so bad input gets a 400 naming the
+// offending field instead of a 500.
+package validation
+
+import (
+	customErr "DBForum/internal/app/errors"
+	"DBForum/internal/app/models"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/go-openapi/strfmt"
+	"math"
+	"regexp"
+	"time"
+)
+
+type Kind int
+
+const (
+	String Kind = iota
+	Integer
+	Boolean
+	DateTime
+)
+
+func (k Kind) String() string {
+	switch k {
+	case Integer:
+		return "an integer"
+	case Boolean:
+		return "a boolean"
+	case DateTime:
+		return "an RFC 3339 date-time"
+	default:
+		return "a string"
+	}
+}
+
+// Field describes one member of a JSON object. Check runs after the type
+// check and only on present values.
+type Field struct {
+	Name     string
+	Kind     Kind
+	Required bool
+	Check    func(value interface{}) string
+}
+
+type Schema []Field
+
+var (
+	nicknameRe = regexp.MustCompile(`^[A-Za-z0-9_.]+$`)
+	slugRe     = regexp.MustCompile(`^(\d|\w|-|_)*(\w|-|_)(\d|\w|-|_)*$`)
+)
+
+func Nickname(value interface{}) string {
+	if !nicknameRe.MatchString(value.(string)) {
+		return "may contain only latin letters, digits, '_' and '.'"
+	}
+	return ""
+}
+
+func Slug(value interface{}) string {
+	s := value.(string)
+	if !slugRe.MatchString(s) || isDigits(s) {
+		return "may contain only letters, digits, '-' and '_' and must not be a number"
+	}
+	return ""
+}
+
+func Email(value interface{}) string {
+	if !strfmt.IsEmail(value.(string)) {
+		return "is not a valid email"
+	}
+	return ""
+}
+
+func NonNegative(value interface{}) string {
+	if value.(int64) < 0 {
+		return "must not be negative"
+	}
+	return ""
+}
+
+func Voice(value interface{}) string {
+	if v := value.(int64); v != 1 && v != -1 {
+		return "must be 1 or -1"
+	}
+	return ""
+}
+
+// Role accepts the forum roles that can be granted; owner comes only with
+// the forum.
+func Role(value interface{}) string {
+	switch value.(string) {
+	case models.RoleModerator, models.RoleMember, models.RoleBanned:
+		return ""
+	}
+	return "must be one of moderator, member, banned"
+}
+
+// Password bounds the length: bcrypt ignores everything past 72 bytes.
+func Password(value interface{}) string {
+	if n := len(value.(string)); n < 8 || n > 72 {
+		return "must be from 8 to 72 bytes long"
+	}
+	return ""
+}
+
+func isDigits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+// Object validates body as a single JSON object.
+func (s Schema) Object(body []byte) error {
+	var object map[string]interface{}
+	if err := decode(body, &object); err != nil || object == nil {
+		return customErr.Invalid("", "body must be a JSON object")
+	}
+	return s.validate(object, "")
+}
+
+// Array validates body as a JSON array of objects; fields are reported as
+// e.g. [2].author.
+func (s Schema) Array(body []byte) error {
+	var items []interface{}
+	if err := decode(body, &items); err != nil || items == nil {
+		return customErr.Invalid("", "body must be a JSON array")
+	}
+	for i, item := range items {
+		prefix := fmt.Sprintf("[%d].", i)
+		object, ok := item.(map[string]interface{})
+		if !ok {
+			return customErr.Invalid(prefix[:len(prefix)-1], "must be a JSON object")
+		}
+		if err := s.validate(object, prefix); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func decode(body []byte, v interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(v); err != nil {
+		return err
+	}
+	if decoder.More() {
+		return fmt.Errorf("trailing data")
+	}
+	return nil
+}
+
+func (s Schema) validate(object map[string]interface{}, prefix string) error {
+	for _, f := range s {
+		raw, ok := object[f.Name]
+		if !ok || raw == nil {
+			if f.Required {
+				return customErr.Invalid(prefix+f.Name, "is required")
+			}
+			continue
+		}
+		value, ok := convert(raw, f.Kind)
+		if !ok {
+			return customErr.Invalid(prefix+f.Name, "must be "+f.Kind.String())
+		}
+		if f.Required && f.Kind == String && value == "" {
+			return customErr.Invalid(prefix+f.Name, "must not be empty")
+		}
+		if f.Check == nil || (f.Kind == String && value == "") {
+			continue
+		}
+		if message := f.Check(value); message != "" {
+			return customErr.Invalid(prefix+f.Name, message)
+		}
+	}
+	return nil
+}
+
+func convert(raw interface{}, kind Kind) (interface{}, bool) {
+	switch kind {
+	case Integer:
+		n, ok := raw.(json.Number)
+		if !ok {
+			return nil, false
+		}
+		i, err := n.Int64()
+		if err != nil {
+			f, err := n.Float64()
+			if err != nil || f != math.Trunc(f) || math.Abs(f) > math.MaxInt64 {
+				return nil, false
+			}
+			i = int64(f)
+		}
+		return i, true
+	case Boolean:
+		b, ok := raw.(bool)
+		return b, ok
+	case DateTime:
+		s, ok := raw.(string)
+		if !ok {
+			return nil, false
+		}
+		if _, err := time.Parse(time.RFC3339Nano, s); err != nil {
+			return nil, false
+		}
+		return s, true
+	default:
+		s, ok := raw.(string)
+		return s, ok
+	}
+}
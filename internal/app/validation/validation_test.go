@@ -0,0 +1,73 @@
+package validation
+
+import (
+	customErr "DBForum/internal/app/errors"
+	"errors"
+	"testing"
+)
+
+func TestSchemas(t *testing.T) {
+	tests := []struct {
+		name   string
+		check  func([]byte) error
+		body   string
+		field  string
+		reject bool
+	}{
+		{"forum ok", ForumCreate.Object, `{"title":"Go","user":"alice.b_1","slug":"go-lang"}`, "", false},
+		{"forum missing slug", ForumCreate.Object, `{"title":"Go","user":"alice"}`, "slug", true},
+		{"forum numeric slug", ForumCreate.Object, `{"title":"Go","user":"alice","slug":"42"}`, "slug", true},
+		{"forum bad nickname", ForumCreate.Object, `{"title":"Go","user":"al ice","slug":"go"}`, "user", true},
+		{"forum empty title", ForumCreate.Object, `{"title":"","user":"alice","slug":"go"}`, "title", true},
+		{"thread ok", ThreadCreate.Object, `{"title":"t","author":"alice","message":"m","created":"2021-06-01T12:00:00.000+03:00"}`, "", false},
+		{"thread bad created", ThreadCreate.Object, `{"title":"t","author":"alice","message":"m","created":"yesterday"}`, "created", true},
+		{"thread votes type", ThreadCreate.Object, `{"title":"t","author":"alice","message":"m","votes":"1"}`, "votes", true},
+		{"thread update empty", ThreadUpdate.Object, `{}`, "", false},
+		{"posts ok", PostCreate.Array, `[{"author":"alice","message":"m","parent":3},{"author":"bob","message":"m"}]`, "", false},
+		{"posts empty", PostCreate.Array, `[]`, "", false},
+		{"posts no message", PostCreate.Array, `[{"author":"alice","message":"m"},{"author":"bob"}]`, "[1].message", true},
+		{"posts float parent", PostCreate.Array, `[{"author":"alice","message":"m","parent":1.5}]`, "[0].parent", true},
+		{"posts negative parent", PostCreate.Array, `[{"author":"alice","message":"m","parent":-1}]`, "[0].parent", true},
+		{"posts not objects", PostCreate.Array, `[1]`, "[0]", true},
+		{"posts not array", PostCreate.Array, `{"author":"alice"}`, "", true},
+		{"vote ok", Vote.Object, `{"nickname":"alice","voice":-1}`, "", false},
+		{"vote bad voice", Vote.Object, `{"nickname":"alice","voice":2}`, "voice", true},
+		{"vote null voice", Vote.Object, `{"nickname":"alice","voice":null}`, "voice", true},
+		{"user ok", UserCreate.Object, `{"fullname":"Alice","email":"alice@example.com"}`, "", false},
+		{"user bad email", UserCreate.Object, `{"fullname":"Alice","email":"alice"}`, "email", true},
+		{"user short password", UserCreate.Object, `{"fullname":"Alice","email":"alice@example.com","password":"123"}`, "password", true},
+		{"login no password", Login.Object, `{"nickname":"alice"}`, "password", true},
+		{"role ok", ForumRole.Object, `{"role":"banned"}`, "", false},
+		{"role owner", ForumRole.Object, `{"role":"owner"}`, "role", true},
+		{"user update about type", UserUpdate.Object, `{"about":7}`, "about", true},
+		{"malformed", UserUpdate.Object, `{"about":`, "", true},
+		{"trailing data", UserUpdate.Object, `{} {}`, "", true},
+		{"null body", UserUpdate.Object, `null`, "", true},
+	}
+	for _, tt := range tests {
+		err := tt.check([]byte(tt.body))
+		if !tt.reject {
+			if err != nil {
+				t.Errorf("%s: unexpected error %v", tt.name, err)
+			}
+			continue
+		}
+		var verr *customErr.Error
+		if !errors.As(err, &verr) || !errors.Is(err, customErr.ErrInvalid) {
+			t.Errorf("%s: err = %v, want an ErrInvalid *customErr.Error", tt.name, err)
+			continue
+		}
+		if verr.Fields["field"] != tt.field {
+			t.Errorf("%s: field = %q, want %q (%v)", tt.name, verr.Fields["field"], tt.field, err)
+		}
+	}
+}
+
+func TestParam(t *testing.T) {
+	if err := Param("nickname", "j.doe_1", Nickname); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := Param("nickname", "j/doe", Nickname); err == nil {
+		t.Error("j/doe accepted as a nickname")
+	}
+}